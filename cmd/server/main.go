@@ -2,25 +2,45 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"os"
+	"strings"
 
+	"exmple.com/database-query-server/internal/config"
 	"exmple.com/database-query-server/internal/handlers"
 	"exmple.com/database-query-server/internal/repository"
+	"exmple.com/database-query-server/internal/utils"
 	"exmple.com/database-query-server/pkg/types"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// configEnv names the environment variable holding the config file path when -config isn't set
+const configEnv = "DB_QUERY_SERVER_CONFIG"
+
 func main() {
+	configPath := flag.String("config", os.Getenv(configEnv), "path to the YAML config file (env "+configEnv+")")
+	flag.Parse()
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+
 	// Instantiate repository
-	repository, err := repository.New()
+	repository, err := repository.New(cfg)
 	if err != nil {
 		log.Fatal(context.Background(), "Can not instantiate repository package, error: %v", err)
 		os.Exit(1)
 	}
 
-	qh := handlers.NewQueryHandler(repository)
+	qh := handlers.NewQueryHandler(repository,
+		handlers.WithQueryTimeout(cfg.Server.QueryTimeout, cfg.Server.MaxQueryTimeout),
+		handlers.WithCursors(cfg.Server.CursorIdleTimeout, cfg.Server.MaxCursors),
+		handlers.WithResults(*cfg.Server.ResultRows, cfg.Server.ResultTTL, cfg.Server.MaxResults),
+	)
+	defer qh.Close()
 
 	s := server.NewMCPServer("**StreamableHTTP API Server", "1.0.0",
 		server.WithToolCapabilities(true),
@@ -35,9 +55,21 @@ func main() {
 			mcp.WithTitleAnnotation("Execute DB Query"),
 			mcp.WithString("query", mcp.Description("DB query")),
 			mcp.WithInputSchema[types.QueryRequest](),
+			handlers.WithFormats(),
+			mcp.WithOutputSchema[types.QueryResponse](),
+		),
+		handlers.QueryToolHandler(qh.ExecuteQuery),
+	)
+
+	s.AddTool(
+		mcp.NewTool("fetch_more",
+			mcp.WithDescription("Fetch the next rows of an execute_query result that returned a cursor"),
+			mcp.WithTitleAnnotation("Fetch more rows"),
+			mcp.WithInputSchema[types.FetchMoreRequest](),
+			handlers.WithFormats(),
 			mcp.WithOutputSchema[types.QueryResponse](),
 		),
-		mcp.NewStructuredToolHandler(qh.ExecuteQuery),
+		handlers.QueryToolHandler(qh.FetchMore),
 	)
 
 	s.AddTool(
@@ -46,20 +78,21 @@ func main() {
 			mcp.WithTitleAnnotation("Execute DB Query"),
 			mcp.WithString("prepared", mcp.Description("DB query")),
 			mcp.WithInputSchema[types.PreparedRequest](),
+			handlers.WithFormats(),
 			mcp.WithOutputSchema[types.QueryResponse](),
 		),
-		mcp.NewStructuredToolHandler(qh.ExecutePrepared),
+		handlers.QueryToolHandler(qh.ExecutePrepared),
 	)
 
 	s.AddTool(
 		mcp.NewTool("get_schema",
-			mcp.WithDescription("Retrieve database schema information"),
+			mcp.WithDescription("Retrieve database schema information of tables given by name, schema.table or glob pattern, or of every table of a schema"),
 			mcp.WithTitleAnnotation("Execute get_schema operations"),
 			mcp.WithString("schema", mcp.Description("DB schema")),
 			mcp.WithInputSchema[types.SchemaRequest](),
 			mcp.WithOutputSchema[types.QueryResponse](),
 		),
-		mcp.NewStructuredToolHandler(qh.GetSchema),
+		handlers.QueryToolHandler(qh.GetSchema),
 	)
 
 	s.AddTool(
@@ -73,10 +106,89 @@ func main() {
 		mcp.NewStructuredToolHandler(qh.GetStatus),
 	)
 
+	s.AddTool(
+		mcp.NewTool("list_databases",
+			mcp.WithDescription("List the configured databases, their names are the database argument of the other tools"),
+			mcp.WithTitleAnnotation("List databases"),
+			mcp.WithInputSchema[types.ListDatabasesRequest](),
+			mcp.WithOutputSchema[types.ListDatabasesResponse](),
+		),
+		mcp.NewStructuredToolHandler(qh.ListDatabases),
+	)
+
+	s.AddTool(
+		mcp.NewTool("list_schemas",
+			mcp.WithDescription("List the schemas of a database with their table counts, filtered by a glob pattern and paged"),
+			mcp.WithTitleAnnotation("List schemas"),
+			mcp.WithInputSchema[types.ListSchemasRequest](),
+			mcp.WithOutputSchema[types.ListSchemasResponse](),
+		),
+		mcp.NewStructuredToolHandler(qh.ListSchemas),
+	)
+
+	s.AddTool(
+		mcp.NewTool("list_tables",
+			mcp.WithDescription("List the tables and views of a schema with estimated row counts, sizes and comments, filtered by a glob pattern and type and paged"),
+			mcp.WithTitleAnnotation("List tables"),
+			mcp.WithInputSchema[types.ListTablesRequest](),
+			mcp.WithOutputSchema[types.ListTablesResponse](),
+		),
+		mcp.NewStructuredToolHandler(qh.ListTables),
+	)
+
+	s.AddTool(
+		mcp.NewTool("get_er_diagram",
+			mcp.WithDescription("Render the foreign keys between the tables of a schema, or the N-hop neighbourhood of a table, as a Mermaid erDiagram or Graphviz DOT"),
+			mcp.WithTitleAnnotation("Entity-relationship diagram"),
+			mcp.WithInputSchema[types.ERDiagramRequest](),
+			mcp.WithOutputSchema[types.ERDiagramResponse](),
+		),
+		mcp.NewStructuredToolHandler(qh.GetERDiagram),
+	)
+
+	s.AddResourceTemplate(
+		mcp.NewResourceTemplate(handlers.ResultURITemplate, "Query result",
+			mcp.WithTemplateDescription("All rows of a query result linked by a tool response, ext is one of "+strings.Join(utils.FormatExts(), ", ")),
+		),
+		qh.ReadResult,
+	)
+
+	s.AddResource(
+		mcp.NewResource(handlers.SchemaIndexURI, "Schema index",
+			mcp.WithResourceDescription("The tables and views of every database with the schema:// URIs of their definitions"),
+			mcp.WithMIMEType("application/json"),
+		),
+		qh.ReadSchemaIndex,
+	)
+
+	s.AddResourceTemplate(
+		mcp.NewResourceTemplate(handlers.SchemaURITemplate, "Table schema",
+			mcp.WithTemplateDescription("The columns, keys, constraints, indexes and comment of a table or view"),
+			mcp.WithTemplateMIMEType("application/json"),
+		),
+		qh.ReadTableSchema,
+	)
+
+	if cfg.Server.Transport == config.TransportStdio {
+		if err := server.ServeStdio(s); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Start StreamableHTTP server
-	log.Println("**Starting StreamableHTTP server on :8080")
+	log.Printf("**Starting StreamableHTTP server on %s", cfg.Server.Listen)
 	httpServer := server.NewStreamableHTTPServer(s)
-	if err := httpServer.Start(":8080"); err != nil {
+	if err := httpServer.Start(cfg.Server.Listen); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// loadConfig reads the config file at path, without a path the legacy POSTGRES_* environment variables are used
+func loadConfig(path string) (*config.Config, error) {
+	if path == "" {
+		log.Printf("no config file given, using POSTGRES_* environment variables")
+		return config.FromEnv()
+	}
+	return config.Load(path)
+}
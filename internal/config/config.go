@@ -0,0 +1,446 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultListen is the address the StreamableHTTP server listens on when none is configured
+	DefaultListen = ":8080"
+	// DefaultQueryTimeout is the execute_query timeout when neither the request nor the config set one
+	DefaultQueryTimeout = 30 * time.Second
+	// DefaultMaxQueryTimeout is the largest execute_query timeout a request can ask for when the config doesn't set one
+	DefaultMaxQueryTimeout = 5 * time.Minute
+	// DefaultLimit is the execute_query row limit when neither the request nor the policy set one
+	DefaultLimit = 10
+	// DefaultMaxRows is the largest execute_query row limit a request can ask for when the policy doesn't set one
+	DefaultMaxRows = 10000
+	// DefaultCursorIdleTimeout is how long an execute_query cursor is kept without a fetch_more when the config doesn't set it
+	DefaultCursorIdleTimeout = 2 * time.Minute
+	// DefaultMaxCursors is the number of cursors held at once when the config doesn't set it
+	DefaultMaxCursors = 10
+	// DefaultResultRows is the number of rows above which a result is stored as a resource when the config doesn't set it
+	DefaultResultRows = 100
+	// DefaultResultTTL is how long a stored result is kept without a read when the config doesn't set it
+	DefaultResultTTL = 10 * time.Minute
+	// DefaultMaxResults is the number of results stored at once when the config doesn't set it
+	DefaultMaxResults = 100
+	// TransportHTTP serves MCP over StreamableHTTP
+	TransportHTTP = "http"
+	// TransportStdio serves MCP over stdin/stdout
+	TransportStdio = "stdio"
+	// DriverPostgres is the driver name of PostgreSQL databases
+	DriverPostgres = "postgres"
+	// DriverSQLite is the driver name of SQLite database files
+	DriverSQLite = "sqlite"
+	// DriverMySQL is the driver name of MySQL and MariaDB databases
+	DriverMySQL = "mysql"
+)
+
+// Drivers lists the database drivers the server can connect to
+var Drivers = []string{DriverPostgres, DriverSQLite, DriverMySQL}
+
+var (
+	transports  = []string{TransportHTTP, TransportStdio}
+	sslModes    = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// settingPattern matches Postgres configuration parameters, custom ones are prefixed ie. app.tenant
+	settingPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+	envPattern     = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+)
+
+// Config describes the MCP server and the databases it exposes
+type Config struct {
+	Server    ServerConfig     `yaml:"server"`
+	Databases []DatabaseConfig `yaml:"databases"`
+}
+
+// ServerConfig holds the MCP transport settings
+type ServerConfig struct {
+	Listen    string `yaml:"listen"`    // ie. ":8080", used by the http transport
+	Transport string `yaml:"transport"` // http or stdio
+
+	QueryTimeout    time.Duration `yaml:"query_timeout"`     // used when execute_query doesn't set a timeout
+	MaxQueryTimeout time.Duration `yaml:"max_query_timeout"` // upper bound of the execute_query timeout
+
+	CursorIdleTimeout time.Duration `yaml:"cursor_idle_timeout"` // cursors without a fetch_more for this long are closed
+	MaxCursors        int           `yaml:"max_cursors"`         // cursors held at once, each holds a connection of its database
+
+	// ResultRows is the most rows of a tool response, results with more rows are stored as query-result resources.
+	// 0 stores every result, -1 never stores. It's a pointer so an unset value can be told from 0.
+	ResultRows *int          `yaml:"result_rows"`
+	ResultTTL  time.Duration `yaml:"result_ttl"`  // stored results without a read for this long are removed
+	MaxResults int           `yaml:"max_results"` // results stored at once
+}
+
+// DatabaseConfig describes a single named database connection
+type DatabaseConfig struct {
+	Name    string `yaml:"name"`    // logical name used by the `database` tool argument
+	Driver  string `yaml:"driver"`  // see Drivers
+	Default bool   `yaml:"default"` // used when a request doesn't name a database
+
+	// DSN is passed to the driver as is, when set the connection parts below are ignored.
+	// SQLite databases only use dbname (the database file path) and options, MySQL ignores sslmode.
+	DSN      string            `yaml:"dsn"`
+	Host     string            `yaml:"host"`
+	Port     int               `yaml:"port"`
+	User     string            `yaml:"user"`
+	Password string            `yaml:"password"`
+	DBName   string            `yaml:"dbname"`
+	SSLMode  string            `yaml:"sslmode"`
+	Options  map[string]string `yaml:"options"` // extra driver specific connection parameters
+
+	Pool   PoolConfig   `yaml:"pool"`
+	Policy PolicyConfig `yaml:"policy"`
+}
+
+// PoolConfig holds the database/sql connection pool limits, zero values keep the database/sql defaults
+type PoolConfig struct {
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+// PolicyConfig restricts what tool calls can do against a database
+type PolicyConfig struct {
+	ReadOnly     bool `yaml:"read_only"`     // rejects execute_prepared calls
+	DefaultLimit int  `yaml:"default_limit"` // execute_query row limit when the request has none
+	MaxRows      int  `yaml:"max_rows"`      // hard cap of the execute_query row limit, larger requests are capped
+
+	// SetLocal settings are applied with SET LOCAL to every execute_query transaction, ie. lock_timeout: 1s.
+	// Postgres only.
+	SetLocal map[string]string `yaml:"set_local"`
+}
+
+// Load reads, expands and validates the YAML config file at path.
+// ${VAR} and ${VAR:-default} references in values are replaced with environment variables.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse expands and validates a YAML config document
+func Parse(data []byte) (*Config, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if err := expandEnv(&doc); err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	// an empty document leaves doc without content
+	if len(doc.Content) > 0 {
+		root := doc.Content[0]
+		if err := checkKnownFields(root, reflect.TypeOf(cfg).Elem()); err != nil {
+			return nil, err
+		}
+		if err := root.Decode(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.setDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// FromEnv builds the legacy single database config from the POSTGRES_* environment variables
+func FromEnv() (*Config, error) {
+	db := DatabaseConfig{
+		Name:     "primary",
+		Driver:   DriverPostgres,
+		Default:  true,
+		Host:     os.Getenv("POSTGRES_HOST"),
+		User:     os.Getenv("POSTGRES_USER"),
+		Password: os.Getenv("POSTGRES_PW"),
+		DBName:   os.Getenv("POSTGRES_DB"),
+		SSLMode:  os.Getenv("POSTGRES_SSLMODE"),
+	}
+	if port := os.Getenv("POSTGRES_PORT"); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("POSTGRES_PORT %q is not a number", port)
+		}
+		db.Port = p
+	}
+
+	cfg := &Config{Databases: []DatabaseConfig{db}}
+	cfg.setDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the config and returns every problem found, joined in a single error
+func (c *Config) Validate() error {
+	var errs []error
+
+	if !contains(transports, c.Server.Transport) {
+		errs = append(errs, fmt.Errorf("server.transport %q is not supported (supported: %v)", c.Server.Transport, transports))
+	}
+	if c.Server.Transport == TransportHTTP && c.Server.Listen == "" {
+		errs = append(errs, fmt.Errorf("server.listen is required by the %s transport", TransportHTTP))
+	}
+
+	if c.Server.QueryTimeout < 0 {
+		errs = append(errs, fmt.Errorf("server.query_timeout can not be negative"))
+	}
+	if c.Server.MaxQueryTimeout < 0 {
+		errs = append(errs, fmt.Errorf("server.max_query_timeout can not be negative"))
+	}
+	if c.Server.QueryTimeout > c.Server.MaxQueryTimeout {
+		errs = append(errs, fmt.Errorf("server.query_timeout (%s) can not exceed server.max_query_timeout (%s)", c.Server.QueryTimeout, c.Server.MaxQueryTimeout))
+	}
+
+	if c.Server.CursorIdleTimeout < 0 {
+		errs = append(errs, fmt.Errorf("server.cursor_idle_timeout can not be negative"))
+	}
+	if c.Server.MaxCursors < 0 {
+		errs = append(errs, fmt.Errorf("server.max_cursors can not be negative"))
+	}
+	if c.Server.ResultRows != nil && *c.Server.ResultRows < -1 {
+		errs = append(errs, fmt.Errorf("server.result_rows can not be negative, -1 disables stored results"))
+	}
+	if c.Server.ResultTTL < 0 {
+		errs = append(errs, fmt.Errorf("server.result_ttl can not be negative"))
+	}
+	if c.Server.MaxResults < 0 {
+		errs = append(errs, fmt.Errorf("server.max_results can not be negative"))
+	}
+
+	if len(c.Databases) == 0 {
+		errs = append(errs, fmt.Errorf("databases: at least one database is required"))
+	}
+
+	names := make(map[string]int)
+	defaults := 0
+	for i, db := range c.Databases {
+		prefix := fmt.Sprintf("databases[%d]", i)
+		if db.Name != "" {
+			prefix = fmt.Sprintf("databases[%d] (%s)", i, db.Name)
+		}
+
+		if db.Name == "" {
+			errs = append(errs, fmt.Errorf("%s: name is required", prefix))
+		} else if !namePattern.MatchString(db.Name) {
+			errs = append(errs, fmt.Errorf("%s: name may only contain letters, digits, '_' and '-'", prefix))
+		} else if j, ok := names[db.Name]; ok {
+			errs = append(errs, fmt.Errorf("%s: name is already used by databases[%d]", prefix, j))
+		} else {
+			names[db.Name] = i
+		}
+
+		if db.Default {
+			defaults++
+		}
+
+		if db.Driver == "" {
+			errs = append(errs, fmt.Errorf("%s: driver is required (supported: %v)", prefix, Drivers))
+		} else if !contains(Drivers, db.Driver) {
+			errs = append(errs, fmt.Errorf("%s: driver %q is not supported (supported: %v)", prefix, db.Driver, Drivers))
+		}
+
+		if db.DSN == "" && db.DBName == "" {
+			errs = append(errs, fmt.Errorf("%s: either dsn or dbname is required", prefix))
+		}
+		if db.Port < 0 || db.Port > 65535 {
+			errs = append(errs, fmt.Errorf("%s: port %d is out of range", prefix, db.Port))
+		}
+		if db.SSLMode != "" && !contains(sslModes, db.SSLMode) {
+			errs = append(errs, fmt.Errorf("%s: sslmode %q is not valid (valid: %v)", prefix, db.SSLMode, sslModes))
+		}
+
+		if db.Pool.MaxOpenConns < 0 {
+			errs = append(errs, fmt.Errorf("%s: pool.max_open_conns can not be negative", prefix))
+		}
+		if db.Pool.MaxIdleConns < 0 {
+			errs = append(errs, fmt.Errorf("%s: pool.max_idle_conns can not be negative", prefix))
+		}
+		if db.Pool.MaxOpenConns > 0 && db.Pool.MaxIdleConns > db.Pool.MaxOpenConns {
+			errs = append(errs, fmt.Errorf("%s: pool.max_idle_conns (%d) can not exceed pool.max_open_conns (%d)", prefix, db.Pool.MaxIdleConns, db.Pool.MaxOpenConns))
+		}
+		if db.Pool.ConnMaxLifetime < 0 {
+			errs = append(errs, fmt.Errorf("%s: pool.conn_max_lifetime can not be negative", prefix))
+		}
+		if db.Pool.ConnMaxIdleTime < 0 {
+			errs = append(errs, fmt.Errorf("%s: pool.conn_max_idle_time can not be negative", prefix))
+		}
+
+		if db.Policy.DefaultLimit < 0 {
+			errs = append(errs, fmt.Errorf("%s: policy.default_limit can not be negative", prefix))
+		}
+		if db.Policy.MaxRows < 0 {
+			errs = append(errs, fmt.Errorf("%s: policy.max_rows can not be negative", prefix))
+		}
+		if db.Policy.MaxRows > 0 && db.Policy.DefaultLimit > db.Policy.MaxRows {
+			errs = append(errs, fmt.Errorf("%s: policy.default_limit (%d) can not exceed policy.max_rows (%d)", prefix, db.Policy.DefaultLimit, db.Policy.MaxRows))
+		}
+		if len(db.Policy.SetLocal) > 0 && db.Driver != DriverPostgres {
+			errs = append(errs, fmt.Errorf("%s: policy.set_local is only supported by the %s driver", prefix, DriverPostgres))
+		}
+		settings := make([]string, 0, len(db.Policy.SetLocal))
+		for name := range db.Policy.SetLocal {
+			settings = append(settings, name)
+		}
+		sort.Strings(settings)
+		for _, name := range settings {
+			if !settingPattern.MatchString(name) {
+				errs = append(errs, fmt.Errorf("%s: policy.set_local %q is not a valid setting name", prefix, name))
+			}
+		}
+	}
+
+	if defaults > 1 {
+		errs = append(errs, fmt.Errorf("databases: only one database can be marked as default, found %d", defaults))
+	}
+
+	return errors.Join(errs...)
+}
+
+// DefaultDatabase returns the name of the database used when a request doesn't name one
+func (c *Config) DefaultDatabase() string {
+	for _, db := range c.Databases {
+		if db.Default {
+			return db.Name
+		}
+	}
+	if len(c.Databases) > 0 {
+		return c.Databases[0].Name
+	}
+	return ""
+}
+
+func (c *Config) setDefaults() {
+	if c.Server.Transport == "" {
+		c.Server.Transport = TransportHTTP
+	}
+	if c.Server.Listen == "" && c.Server.Transport == TransportHTTP {
+		c.Server.Listen = DefaultListen
+	}
+	if c.Server.QueryTimeout == 0 {
+		c.Server.QueryTimeout = DefaultQueryTimeout
+	}
+	if c.Server.MaxQueryTimeout == 0 {
+		c.Server.MaxQueryTimeout = max(DefaultMaxQueryTimeout, c.Server.QueryTimeout)
+	}
+	if c.Server.CursorIdleTimeout == 0 {
+		c.Server.CursorIdleTimeout = DefaultCursorIdleTimeout
+	}
+	if c.Server.MaxCursors == 0 {
+		c.Server.MaxCursors = DefaultMaxCursors
+	}
+	if c.Server.ResultRows == nil {
+		resultRows := DefaultResultRows
+		c.Server.ResultRows = &resultRows
+	}
+	if c.Server.ResultTTL == 0 {
+		c.Server.ResultTTL = DefaultResultTTL
+	}
+	if c.Server.MaxResults == 0 {
+		c.Server.MaxResults = DefaultMaxResults
+	}
+}
+
+// checkKnownFields rejects mapping keys that don't match a yaml tag of the target struct, so typos don't go unnoticed
+func checkKnownFields(node *yaml.Node, t reflect.Type) error {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return checkKnownFields(node, t.Elem())
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for _, item := range node.Content {
+			if err := checkKnownFields(item, t.Elem()); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		fields := make(map[string]reflect.Type, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+			fields[name] = t.Field(i).Type
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			ft, ok := fields[key.Value]
+			if !ok {
+				return fmt.Errorf("line %d: unknown field %q", key.Line, key.Value)
+			}
+			if err := checkKnownFields(value, ft); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandEnv replaces ${VAR} and ${VAR:-default} in every scalar value of the document.
+// Values are expanded after parsing so secrets can't break the YAML structure.
+func expandEnv(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var missing []string
+		node.Value = envPattern.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			m := envPattern.FindStringSubmatch(ref)
+			if val, ok := os.LookupEnv(m[1]); ok {
+				return val
+			}
+			if m[2] != "" {
+				return m[3]
+			}
+			missing = append(missing, m[1])
+			return ref
+		})
+		if len(missing) > 0 {
+			return fmt.Errorf("line %d: environment variable %s is not set", node.Line, missing[0])
+		}
+		// plain scalars are re-resolved so ie. `port: ${PG_PORT}` still decodes into an int
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+		return nil
+	}
+
+	for _, child := range node.Content {
+		if err := expandEnv(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"exmple.com/database-query-server/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Setenv("TEST_PG_PASSWORD", "s3cret: with spaces")
+	t.Setenv("TEST_PG_PORT", "5433")
+
+	valid := `
+server:
+  listen: ":9090"
+  query_timeout: 10s
+databases:
+  - name: primary
+    driver: postgres
+    host: localhost
+    port: ${TEST_PG_PORT}
+    user: mcp
+    password: ${TEST_PG_PASSWORD}
+    dbname: app
+    pool:
+      max_open_conns: 10
+      max_idle_conns: 5
+      conn_max_lifetime: 30m
+    policy:
+      set_local:
+        lock_timeout: 1s
+  - name: analytics
+    driver: postgres
+    default: true
+    dsn: "postgres://mcp@analytics/${TEST_PG_DB:-warehouse}"
+    policy:
+      read_only: true
+      default_limit: 50
+      max_rows: 1000
+`
+	resultRows := config.DefaultResultRows
+	expected := &config.Config{
+		Server: config.ServerConfig{Listen: ":9090", Transport: config.TransportHTTP, QueryTimeout: 10 * time.Second, MaxQueryTimeout: config.DefaultMaxQueryTimeout, CursorIdleTimeout: config.DefaultCursorIdleTimeout, MaxCursors: config.DefaultMaxCursors,
+			ResultRows: &resultRows, ResultTTL: config.DefaultResultTTL, MaxResults: config.DefaultMaxResults},
+		Databases: []config.DatabaseConfig{
+			{
+				Name:     "primary",
+				Driver:   config.DriverPostgres,
+				Host:     "localhost",
+				Port:     5433,
+				User:     "mcp",
+				Password: "s3cret: with spaces",
+				DBName:   "app",
+				Pool:     config.PoolConfig{MaxOpenConns: 10, MaxIdleConns: 5, ConnMaxLifetime: 30 * time.Minute},
+				Policy:   config.PolicyConfig{SetLocal: map[string]string{"lock_timeout": "1s"}},
+			},
+			{
+				Name:    "analytics",
+				Driver:  config.DriverPostgres,
+				Default: true,
+				DSN:     "postgres://mcp@analytics/warehouse",
+				Policy:  config.PolicyConfig{ReadOnly: true, DefaultLimit: 50, MaxRows: 1000},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    *config.Config
+		wantErr string
+	}{
+		{name: "Happy Flow - valid config with env expansion", data: valid, want: expected},
+		{name: "Sad Flow - missing environment variable", data: "databases:\n  - name: primary\n    driver: postgres\n    password: ${TEST_PG_UNDEFINED}\n", wantErr: "line 4: environment variable TEST_PG_UNDEFINED is not set"},
+		{name: "Sad Flow - unknown field", data: "databases:\n  - name: primary\n    drivr: postgres\n", wantErr: `line 3: unknown field "drivr"`},
+		{name: "Sad Flow - no databases", data: "server:\n  listen: \":8080\"\n", wantErr: "databases: at least one database is required"},
+		{name: "Sad Flow - unsupported driver", data: "databases:\n  - name: primary\n    driver: oracle\n    dbname: app\n", wantErr: `databases[0] (primary): driver "oracle" is not supported (supported: [postgres sqlite mysql])`},
+		{name: "Sad Flow - unsupported transport", data: "server:\n  transport: grpc\ndatabases:\n  - name: primary\n    driver: postgres\n    dbname: app\n", wantErr: `server.transport "grpc" is not supported (supported: [http stdio])`},
+		{name: "Sad Flow - query timeout exceeds the maximum", data: "server:\n  query_timeout: 2m\n  max_query_timeout: 1m\ndatabases:\n  - name: primary\n    driver: postgres\n    dbname: app\n", wantErr: "server.query_timeout (2m0s) can not exceed server.max_query_timeout (1m0s)"},
+		{name: "Sad Flow - negative cursor settings", data: "server:\n  cursor_idle_timeout: -1s\n  max_cursors: -1\ndatabases:\n  - name: primary\n    driver: postgres\n    dbname: app\n", wantErr: "server.cursor_idle_timeout can not be negative\nserver.max_cursors can not be negative"},
+		{name: "Sad Flow - negative result settings", data: "server:\n  result_rows: -2\n  result_ttl: -1m\n  max_results: -1\ndatabases:\n  - name: primary\n    driver: postgres\n    dbname: app\n", wantErr: "server.result_rows can not be negative, -1 disables stored results\nserver.result_ttl can not be negative\nserver.max_results can not be negative"},
+		{name: "Sad Flow - set_local guards", data: "databases:\n  - name: primary\n    driver: postgres\n    dbname: app\n    policy:\n      set_local:\n        \"lock_timeout; DROP\": 1s\n  - name: local\n    driver: sqlite\n    dbname: app.db\n    policy:\n      set_local:\n        lock_timeout: 1s\n",
+			wantErr: "databases[0] (primary): policy.set_local \"lock_timeout; DROP\" is not a valid setting name\ndatabases[1] (local): policy.set_local is only supported by the postgres driver"},
+		{name: "Sad Flow - default limit exceeds max rows", data: "databases:\n  - name: primary\n    driver: postgres\n    dbname: app\n    policy:\n      default_limit: 500\n      max_rows: 100\n",
+			wantErr: "databases[0] (primary): policy.default_limit (500) can not exceed policy.max_rows (100)"},
+		{name: "Sad Flow - every problem is reported", data: "databases:\n  - name: primary\n    driver: postgres\n  - name: primary\n    driver: postgres\n    dbname: app\n    pool:\n      max_open_conns: 2\n      max_idle_conns: 4\n",
+			wantErr: "databases[0] (primary): either dsn or dbname is required\ndatabases[1] (primary): name is already used by databases[0]\ndatabases[1] (primary): pool.max_idle_conns (4) can not exceed pool.max_open_conns (2)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := config.Parse([]byte(tt.data))
+			if gotErr != nil {
+				if tt.wantErr == "" {
+					t.Errorf("Parse() failed: %v", gotErr)
+				}
+				assert.EqualError(t, gotErr, tt.wantErr)
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatal("Parse() succeeded unexpectedly")
+			}
+			assert.EqualValues(t, tt.want, got)
+			assert.Equal(t, "analytics", got.DefaultDatabase())
+		})
+	}
+}
+
+func TestParse_ResultRows(t *testing.T) {
+	tests := []struct {
+		name   string
+		server string
+		want   int
+	}{
+		{name: "Happy Flow - unset is the default", server: "server:\n  listen: \":8080\"\n", want: config.DefaultResultRows},
+		{name: "Happy Flow - 0 stores every result", server: "server:\n  result_rows: 0\n", want: 0},
+		{name: "Happy Flow - -1 never stores", server: "server:\n  result_rows: -1\n", want: -1},
+		{name: "Happy Flow - threshold", server: "server:\n  result_rows: 500\n", want: 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := config.Parse([]byte(tt.server + "databases:\n  - name: primary\n    driver: postgres\n    dbname: app\n"))
+			if err != nil {
+				t.Fatalf("Parse() failed: %v", err)
+			}
+			if assert.NotNil(t, got.Server.ResultRows) {
+				assert.Equal(t, tt.want, *got.Server.ResultRows)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  transport: stdio\ndatabases:\n  - name: primary\n    driver: postgres\n    dbname: app\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	assert.Equal(t, config.TransportStdio, got.Server.Transport)
+	assert.Equal(t, "", got.Server.Listen)
+	assert.Equal(t, config.DefaultQueryTimeout, got.Server.QueryTimeout)
+	assert.Equal(t, config.DefaultMaxQueryTimeout, got.Server.MaxQueryTimeout)
+	assert.Equal(t, "primary", got.DefaultDatabase())
+
+	_, err = config.Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("POSTGRES_USER", "user_name")
+	t.Setenv("POSTGRES_PW", "secret")
+	t.Setenv("POSTGRES_DB", "dbname")
+	t.Setenv("POSTGRES_PORT", "5432")
+
+	got, err := config.FromEnv()
+	if err != nil {
+		t.Fatalf("FromEnv() failed: %v", err)
+	}
+	assert.Equal(t, config.DefaultListen, got.Server.Listen)
+	assert.Equal(t, []config.DatabaseConfig{{
+		Name:     "primary",
+		Driver:   config.DriverPostgres,
+		Default:  true,
+		Port:     5432,
+		User:     "user_name",
+		Password: "secret",
+		DBName:   "dbname",
+	}}, got.Databases)
+
+	t.Setenv("POSTGRES_PORT", "postgres")
+	_, err = config.FromEnv()
+	assert.EqualError(t, err, `POSTGRES_PORT "postgres" is not a number`)
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// CatalogFilter selects a page of a schema or table listing, the database applies it so large catalogs aren't read whole
+type CatalogFilter struct {
+	Pattern string // glob pattern of the name as in path.Match, every name matches an empty pattern
+	Type    string // type of the tables listed by ListTables, every type when empty
+	Limit   int    // entries returned, every entry when 0
+	Offset  int    // entries skipped, only applied with a limit
+}
+
+// limitClause returns the LIMIT and OFFSET clause of the page, empty when the filter has no limit
+func (f CatalogFilter) limitClause() string {
+	if f.Limit <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", f.Limit, max(f.Offset, 0))
+}
+
+// patternRegexp translates the glob pattern into an anchored regular expression for Postgres ~ and MySQL REGEXP_LIKE,
+// an empty pattern stays empty
+func (f CatalogFilter) patternRegexp() (string, error) {
+	if _, err := path.Match(f.Pattern, ""); err != nil {
+		return "", fmt.Errorf("invalid pattern %q: %w", f.Pattern, err)
+	}
+	if f.Pattern == "" {
+		return "", nil
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	inClass := false
+	for i := 0; i < len(f.Pattern); i++ {
+		c := f.Pattern[i]
+		switch {
+		case c == '\\':
+			// path.Match accepted the pattern, an escape is followed by a character
+			i++
+			if inClass {
+				b.WriteString(`\` + string(f.Pattern[i]))
+			} else {
+				b.WriteString(regexp.QuoteMeta(string(f.Pattern[i])))
+			}
+		case inClass && c == '[':
+			b.WriteString(`\[`)
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+			b.WriteByte(c)
+		case c == '[':
+			inClass = true
+			b.WriteByte(c)
+			if i+1 < len(f.Pattern) && f.Pattern[i+1] == '^' {
+				i++
+				b.WriteByte('^')
+			}
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String(), nil
+}
+
+// patternGlob translates the glob pattern into a SQLite GLOB pattern, which has no escapes, an escaped character
+// becomes a class of its own. Unlike path.Match a GLOB * also matches /.
+func (f CatalogFilter) patternGlob() (string, error) {
+	if _, err := path.Match(f.Pattern, ""); err != nil {
+		return "", fmt.Errorf("invalid pattern %q: %w", f.Pattern, err)
+	}
+	var b strings.Builder
+	inClass := false
+	for i := 0; i < len(f.Pattern); i++ {
+		c := f.Pattern[i]
+		switch {
+		case c == '\\':
+			i++
+			if inClass {
+				b.WriteByte(f.Pattern[i])
+			} else {
+				b.WriteString("[" + string(f.Pattern[i]) + "]")
+			}
+		case c == '[' && !inClass:
+			inClass = true
+			b.WriteByte(c)
+		case c == ']' && inClass:
+			inClass = false
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
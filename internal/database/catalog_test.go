@@ -0,0 +1,46 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogFilter_Pattern(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		wantRegexp string
+		wantGlob   string
+		wantErr    bool
+	}{
+		{name: "Happy Flow - no pattern", pattern: "", wantRegexp: "", wantGlob: ""},
+		{name: "Happy Flow - wildcards", pattern: "order_?_*", wantRegexp: `^order_[^/]_[^/]*$`, wantGlob: "order_?_*"},
+		{name: "Happy Flow - regexp characters are quoted", pattern: "a.b+c", wantRegexp: `^a\.b\+c$`, wantGlob: "a.b+c"},
+		{name: "Happy Flow - classes", pattern: "t[^0-9][ab]", wantRegexp: `^t[^0-9][ab]$`, wantGlob: "t[^0-9][ab]"},
+		{name: "Happy Flow - escaped wildcard", pattern: `a\*`, wantRegexp: `^a\*$`, wantGlob: "a[*]"},
+		{name: "Happy Flow - escape in a class", pattern: `[\]x]`, wantRegexp: `^[\]x]$`, wantGlob: "[]x]"},
+		{name: "Sad Flow - unterminated class", pattern: "order_[0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := CatalogFilter{Pattern: tt.pattern}
+			gotRegexp, err := f.patternRegexp()
+			if tt.wantErr {
+				assert.ErrorContains(t, err, `invalid pattern "order_[0"`)
+				_, err = f.patternGlob()
+				assert.Error(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("patternRegexp() failed: %v", err)
+			}
+			assert.Equal(t, tt.wantRegexp, gotRegexp)
+			gotGlob, err := f.patternGlob()
+			if err != nil {
+				t.Fatalf("patternGlob() failed: %v", err)
+			}
+			assert.Equal(t, tt.wantGlob, gotGlob)
+		})
+	}
+}
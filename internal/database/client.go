@@ -2,12 +2,73 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/pkg/types"
 )
 
 // Repository implements commond DB client methods
 // This allow each DB SDK to be wrapped in a Repository ie. Postgress, Redis etc
 type ClientInterface interface {
-	ExecQuery(ctx context.Context, query string, params map[string]any) ([]map[string]interface{}, error)
-	ExecPrepared(ctx context.Context, statement string, params []any) ([]map[string]interface{}, error)
-	GetSchema(ctx context.Context, tables []string) ([]map[string]interface{}, error)
+	Reader
+	Writer
+}
+
+// Reader is the read only side of a client, the database itself rejects writes made through ExecQuery
+type Reader interface {
+	// ExecQuery returns the first maxRows rows of the result, the database stops producing rows at the limit.
+	// 0 reads every row.
+	ExecQuery(ctx context.Context, query string, params map[string]any, maxRows int) (*types.ResultSet, error)
+	// OpenCursor starts a query whose rows are read page by page, ctx only bounds the start of the query
+	OpenCursor(ctx context.Context, query string, params map[string]any) (Cursor, error)
+	// GetSchema returns the columns of the tables, a table is a name or a schema.table name. Unknown tables are left out.
+	GetSchema(ctx context.Context, tables []string) ([]types.TableSchema, error)
+	// DescribeTables returns the columns, keys, constraints, indexes and comments of the tables, unknown tables are left out
+	DescribeTables(ctx context.Context, tables []string) ([]types.TableSchema, error)
+	// TableNames returns the tables and views of a schema sorted by name, the tables an unqualified name can refer to
+	// when schema is empty
+	TableNames(ctx context.Context, schema string) ([]types.TableName, error)
+	// ListSchemas returns the page of the schemas apart from the system ones sorted by name selected by the filter
+	ListSchemas(ctx context.Context, filter CatalogFilter) ([]types.SchemaInfo, error)
+	// ListTables returns the page of the tables and views of a schema like TableNames selected by the filter, with
+	// their size estimates and comments
+	ListTables(ctx context.Context, schema string, filter CatalogFilter) ([]types.TableInfo, error)
+	Status(ctx context.Context) (*Status, error)
+}
+
+// Cursor reads the rows of a query page by page, it holds a connection of the pool until it's closed
+type Cursor interface {
+	// Fetch returns up to n rows and whether the query has more, ctx bounds the read
+	Fetch(ctx context.Context, n int) (*types.ResultSet, bool, error)
+	Close() error
+}
+
+// Writer runs statements that can modify the database
+type Writer interface {
+	ExecPrepared(ctx context.Context, statement string, params []any) (*types.ResultSet, error)
+}
+
+// Status is the database health reported by get_connection_status
+type Status struct {
+	Pool          sql.DBStats   // statistics of the client side connection pool
+	PingLatency   time.Duration // round trip of the ping
+	PingedAt      time.Time     // when the ping completed
+	ServerVersion string
+}
+
+// NewClient creates the client matching the driver of the database config
+func NewClient(cfg config.DatabaseConfig) (ClientInterface, error) {
+	switch cfg.Driver {
+	case config.DriverPostgres:
+		return NewPostgressClient(cfg)
+	case config.DriverSQLite:
+		return NewSQLiteClient(cfg)
+	case config.DriverMySQL:
+		return NewMySQLClient(cfg)
+	default:
+		return nil, fmt.Errorf("database %q: driver %q is not supported", cfg.Name, cfg.Driver)
+	}
 }
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"exmple.com/database-query-server/internal/config"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_postgresDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.DatabaseConfig
+		want string
+	}{
+		{name: "Happy Flow - DSN is used as is", cfg: config.DatabaseConfig{DSN: "postgres://user@host/db", Host: "ignored"}, want: "postgres://user@host/db"},
+		{name: "Happy Flow - connection parts", cfg: config.DatabaseConfig{Host: "db", Port: 5433, User: "mcp", DBName: "app", SSLMode: "require"}, want: "dbname=app host=db port=5433 sslmode=require user=mcp"},
+		{name: "Happy Flow - sslmode defaults to disable", cfg: config.DatabaseConfig{DBName: "app"}, want: "dbname=app sslmode=disable"},
+		{name: "Happy Flow - values are quoted", cfg: config.DatabaseConfig{DBName: "app", Password: `it's a \ secret`}, want: `dbname=app password='it\'s a \\ secret' sslmode=disable`},
+		{name: "Happy Flow - extra options", cfg: config.DatabaseConfig{DBName: "app", Options: map[string]string{"application_name": "mcp"}}, want: "application_name=mcp dbname=app sslmode=disable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, postgresDSN(tt.cfg))
+		})
+	}
+}
+
+func Test_mysqlDSN(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.DatabaseConfig
+		want    string
+		wantErr bool
+	}{
+		{name: "Happy Flow - DSN gets parseTime", cfg: config.DatabaseConfig{DSN: "mcp:secret@tcp(db:3306)/app"}, want: "mcp:secret@tcp(db:3306)/app?parseTime=true"},
+		{name: "Happy Flow - connection parts", cfg: config.DatabaseConfig{Host: "db", User: "mcp", Password: "secret", DBName: "app"}, want: "mcp:secret@tcp(db:3306)/app?parseTime=true"},
+		{name: "Happy Flow - custom port and options", cfg: config.DatabaseConfig{Host: "db", Port: 3307, User: "mcp", DBName: "app", Options: map[string]string{"charset": "utf8mb4"}}, want: "mcp@tcp(db:3307)/app?parseTime=true&charset=utf8mb4"},
+		{name: "Sad Flow - invalid DSN", cfg: config.DatabaseConfig{DSN: "mcp@db/app"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := mysqlDSN(tt.cfg)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("mysqlDSN() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("mysqlDSN() succeeded unexpectedly")
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPostgress_ExecQuery_SetLocal(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() failed: %v", err)
+	}
+	defer db.Close()
+
+	pg := &Postgress{Pg: db, guards: []guard{{name: "lock_timeout", value: "1s"}, {name: "search_path", value: "reporting, public"}}}
+	query := "SELECT id FROM users"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT set_config($1, $2, true)")).WithArgs("lock_timeout", "1s").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("SELECT set_config($1, $2, true)")).WithArgs("search_path", "reporting, public").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(regexp.QuoteMeta(query)).ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectRollback()
+
+	got, err := pg.ExecQuery(context.Background(), query, nil, 0)
+	if err != nil {
+		t.Fatalf("ExecQuery() failed: %v", err)
+	}
+	assert.EqualValues(t, [][]any{{int64(1)}}, got.Rows)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
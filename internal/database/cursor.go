@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"exmple.com/database-query-server/pkg/types"
+)
+
+// ErrCursorClosed is returned by Fetch once the cursor was closed or failed
+var ErrCursorClosed = errors.New("cursor is closed")
+
+// pageReader reads the next n rows of a cursor, fewer than n rows are returned at the end of the result.
+// ctx is the context of the Fetch, the rows are read with the context of the cursor.
+type pageReader func(ctx context.Context, n int) (*types.ResultSet, error)
+
+// startFunc starts the query of a cursor in the read only scope opened by a beginFunc,
+// done releases what the query holds before the scope ends
+type startFunc func(ctx context.Context, q querier) (read pageReader, done func(), err error)
+
+// rowCursor is the Cursor of the database/sql clients, a row is read ahead of every page to know if there are more
+type rowCursor struct {
+	mu      sync.Mutex
+	read    pageReader
+	done    func()
+	columns []types.Column
+	pending [][]any
+	eof     bool
+	closed  bool
+	end     func()             // ends the read only scope
+	cancel  context.CancelFunc // cancels the context of the scope
+}
+
+// openCursor opens the read only scope of begin and starts the query in it. The scope uses a context that outlives
+// ctx, which only bounds the opening, the scope ends when the cursor is closed or fails.
+func openCursor(ctx context.Context, begin beginFunc, start startFunc) (Cursor, error) {
+	cctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	q, end, err := begin(cctx)
+	if err != nil {
+		cancel()
+		return nil, timeoutError(ctx, err)
+	}
+	read, done, err := start(cctx, q)
+	if err != nil {
+		end()
+		cancel()
+		return nil, timeoutError(ctx, err)
+	}
+	return &rowCursor{read: read, done: done, end: end, cancel: cancel}, nil
+}
+
+// heldRows starts the query and keeps its rows open, the driver reads them as pages are fetched
+func heldRows(query string, args []any, decode valueDecoder) startFunc {
+	return func(ctx context.Context, q querier) (pageReader, func(), error) {
+		rows, err := q.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, nil, err
+		}
+		columnTypes, err := rows.ColumnTypes()
+		if err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		columns := resultColumns(columnTypes)
+		read := func(fetchCtx context.Context, n int) (*types.ResultSet, error) {
+			page, err := scanRows(rows, columnTypes, n, decode, progressFrom(fetchCtx))
+			if err != nil {
+				return nil, err
+			}
+			if len(page) < n {
+				// Rows.Err will report the error that ended the result early
+				if err := rows.Err(); err != nil {
+					return nil, err
+				}
+			}
+			return &types.ResultSet{Columns: columns, Rows: page}, nil
+		}
+		return read, func() { rows.Close() }, nil
+	}
+}
+
+// Fetch returns up to n rows and whether the result has more. When ctx is done while rows are read the cursor is closed.
+func (c *rowCursor) Fetch(ctx context.Context, n int) (*types.ResultSet, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, false, ErrCursorClosed
+	}
+
+	stop := context.AfterFunc(ctx, c.cancel)
+	defer stop()
+
+	for !c.eof && len(c.pending) <= n {
+		want := n + 1 - len(c.pending)
+		readCtx := ctx
+		if progress := progressFrom(ctx); progress != nil {
+			// rows read ahead by the previous Fetch are counted
+			offset := len(c.pending)
+			readCtx = WithProgress(ctx, func(rows int) { progress(offset + rows) })
+		}
+		page, err := c.read(readCtx, want)
+		if err != nil {
+			c.close()
+			return nil, false, timeoutError(ctx, err)
+		}
+		if c.columns == nil {
+			c.columns = page.Columns
+		}
+		c.pending = append(c.pending, page.Rows...)
+		c.eof = len(page.Rows) < want
+	}
+
+	k := min(n, len(c.pending))
+	rows := append([][]any{}, c.pending[:k]...)
+	c.pending = c.pending[k:]
+	return &types.ResultSet{Columns: c.columns, Rows: rows}, len(c.pending) > 0, nil
+}
+
+// Close ends the read only scope and releases its connection, it's safe to call more than once
+func (c *rowCursor) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.close()
+	return nil
+}
+
+func (c *rowCursor) close() {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.pending = nil
+	c.done()
+	c.end()
+	c.cancel()
+}
+
+// queryPage runs a statement returning a page of rows ie. FETCH, in the scope of a cursor. progress may be nil.
+func queryPage(ctx context.Context, q querier, query string, decode valueDecoder, progress ProgressFunc) (*types.ResultSet, error) {
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return itterateRows(rows, 0, decode, progress)
+}
@@ -0,0 +1,236 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/internal/sqlparser"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/go-sql-driver/mysql"
+)
+
+type MySQL struct {
+	DB *sql.DB
+}
+
+// NewMySQLClient creates a new MySQL or MariaDB client for the given database config
+func NewMySQLClient(cfg config.DatabaseConfig) (ClientInterface, error) {
+	dsn, err := mysqlDSN(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mysql dsn for database %q: %w", cfg.Name, err)
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mysql database %q: %w", cfg.Name, err)
+	}
+	applyPool(db, cfg.Pool)
+
+	return &MySQL{
+		DB: db,
+	}, nil
+}
+
+// mysqlDSN builds a go-sql-driver/mysql DSN, cfg.DSN is parsed so parseTime is always enabled
+func mysqlDSN(cfg config.DatabaseConfig) (string, error) {
+	var c *mysql.Config
+	if cfg.DSN != "" {
+		var err error
+		c, err = mysql.ParseDSN(cfg.DSN)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		c = mysql.NewConfig()
+		c.User = cfg.User
+		c.Passwd = cfg.Password
+		c.DBName = cfg.DBName
+		if cfg.Host != "" {
+			port := 3306
+			if cfg.Port > 0 {
+				port = cfg.Port
+			}
+			c.Net = "tcp"
+			c.Addr = net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+		}
+	}
+	// DATETIME and TIMESTAMP columns are returned as time.Time instead of raw bytes
+	c.ParseTime = true
+	for k, v := range cfg.Options {
+		if c.Params == nil {
+			c.Params = map[string]string{}
+		}
+		c.Params[k] = v
+	}
+	return c.FormatDSN(), nil
+}
+
+// ExecQuery executes a query in a READ ONLY transaction on the MySQL database and returns the result set
+func (s *MySQL) ExecQuery(ctx context.Context, query string, params map[string]any, maxRows int) (*types.ResultSet, error) {
+	query, args, err := sqlparser.Bind(query, params, sqlparser.MySQL)
+	if err != nil {
+		return nil, err
+	}
+	if maxRows <= 0 {
+		return readOnlyQuery(ctx, s.DB, nil, query, args, maxRows, decodeMySQLValue)
+	}
+
+	tx, end, err := s.selectLimit(maxRows)(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	return execQuery(ctx, tx, query, args, maxRows, decodeMySQLValue)
+}
+
+// selectLimit returns the beginFunc of a READ ONLY transaction whose result is capped at maxRows rows by the server
+// with sql_select_limit, the driver reads and discards the rest of a result when its rows are closed early. A LIMIT
+// of the query itself takes precedence. The session variable outlives the transaction, it's reset before the connection
+// goes back to the pool and the connection is discarded when the reset fails.
+func (s *MySQL) selectLimit(maxRows int) beginFunc {
+	return func(ctx context.Context) (querier, func(), error) {
+		conn, err := s.DB.Conn(ctx)
+		if err != nil {
+			return nil, nil, timeoutError(ctx, err)
+		}
+		release := func() {
+			// the query's context may be done, the reset gets a deadline of its own
+			rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), statusTimeout)
+			defer cancel()
+			if _, err := conn.ExecContext(rctx, "SET SESSION sql_select_limit = DEFAULT"); err != nil {
+				log.Printf("failed to reset sql_select_limit, discarding the connection: %v", err)
+				conn.Raw(func(any) error { return driver.ErrBadConn })
+			}
+			conn.Close()
+		}
+
+		// SET doesn't accept bind parameters, the value is an integer
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET SESSION sql_select_limit = %d", maxRows)); err != nil {
+			release()
+			return nil, nil, timeoutError(ctx, err)
+		}
+		tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			release()
+			return nil, nil, timeoutError(ctx, err)
+		}
+		return tx, func() {
+			tx.Rollback()
+			release()
+		}, nil
+	}
+}
+
+// OpenCursor starts a query in a READ ONLY transaction that is held until the cursor is closed, the driver streams
+// the rows as they are fetched. A cursor left idle longer than the server's net_write_timeout fails.
+func (s *MySQL) OpenCursor(ctx context.Context, query string, params map[string]any) (Cursor, error) {
+	query, args, err := sqlparser.Bind(query, params, sqlparser.MySQL)
+	if err != nil {
+		return nil, err
+	}
+	return openCursor(ctx, readOnlyTx(s.DB, nil), heldRows(query, args, decodeMySQLValue))
+}
+
+// ExecPrepared executes a prepared statement with the given parameters and returns the number of affected rows.
+func (s *MySQL) ExecPrepared(ctx context.Context, statement string, params []any) (*types.ResultSet, error) {
+	return execPrepared(ctx, s.DB, statement, params)
+}
+
+// decodeMySQLValue converts the raw bytes returned by the driver for text, decimal and json columns,
+// binary columns are returned as types.Binary
+func decodeMySQLValue(column *sql.ColumnType, value interface{}) interface{} {
+	b, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+	switch typ := column.DatabaseTypeName(); {
+	case typ == "DECIMAL":
+		return json.Number(b)
+	case typ == "JSON":
+		if json.Valid(b) {
+			return json.RawMessage(b)
+		}
+	case strings.Contains(typ, "INT") || typ == "YEAR":
+		if n, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(string(b), 10, 64); err == nil {
+			return n
+		}
+	case typ == "FLOAT" || typ == "DOUBLE":
+		if f, err := strconv.ParseFloat(string(b), 64); err == nil {
+			return f
+		}
+	case typ == "BINARY" || typ == "VARBINARY" || strings.HasSuffix(typ, "BLOB") || typ == "BIT" || typ == "GEOMETRY":
+		return types.Binary(b)
+	}
+	return string(b)
+}
+
+// GetSchema returns the columns of the tables, a name is in the connection's database unless it's qualified with
+// another. The data type is the type name without its length, in the same shape as the Postgres information_schema.
+func (s *MySQL) GetSchema(ctx context.Context, tables []string) ([]types.TableSchema, error) {
+	q := `SELECT column_name, data_type, is_nullable, column_default, ordinal_position, character_maximum_length
+	FROM information_schema.columns
+	WHERE table_schema = ? AND table_name = ? ORDER BY ordinal_position`
+
+	stmt, err := s.DB.PrepareContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var schemas []types.TableSchema
+	for _, table := range tables {
+		t, err := s.lookupTable(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			continue
+		}
+		rows, err := stmt.QueryContext(ctx, t.Schema, t.Name)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var c types.ColumnSchema
+			var nullable string
+			var def sql.NullString
+			var length sql.NullInt64
+			if err := rows.Scan(&c.Name, &c.DataType, &nullable, &def, &c.Position, &length); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			c.DataType = strings.ToLower(c.DataType)
+			c.Nullable = nullable == "YES"
+			if def.Valid {
+				c.Default = &def.String
+			}
+			if length.Valid {
+				c.MaxLength = &length.Int64
+			}
+			t.Columns = append(t.Columns, c)
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, *t)
+	}
+	return schemas, nil
+}
+
+// Status pings the database and returns the pool statistics and server version
+func (s *MySQL) Status(ctx context.Context) (*Status, error) {
+	return dbStatus(ctx, s.DB, "SELECT VERSION()")
+}
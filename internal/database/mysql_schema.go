@@ -0,0 +1,279 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"exmple.com/database-query-server/pkg/types"
+)
+
+// DescribeTables returns the columns, keys, constraints, indexes and comments of the specified tables or views, a name
+// is in the connection's database unless it's qualified with another ie. sales.orders. MySQL doesn't keep the statement
+// of an index, its definition is built from the keys. Unknown tables are left out.
+func (s *MySQL) DescribeTables(ctx context.Context, tables []string) ([]types.TableSchema, error) {
+	var described []types.TableSchema
+	for _, table := range tables {
+		t, err := s.lookupTable(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			continue
+		}
+
+		if t.Columns, err = s.describeColumns(ctx, t.Schema, t.Name); err != nil {
+			return nil, fmt.Errorf("failed to describe columns of %q: %w", table, err)
+		}
+		if err := s.describeKeys(ctx, t); err != nil {
+			return nil, fmt.Errorf("failed to describe keys of %q: %w", table, err)
+		}
+		if t.Checks, err = s.describeChecks(ctx, t.Schema, t.Name); err != nil {
+			return nil, fmt.Errorf("failed to describe checks of %q: %w", table, err)
+		}
+		if t.Indexes, err = s.describeIndexes(ctx, t.Schema, t.Name); err != nil {
+			return nil, fmt.Errorf("failed to describe indexes of %q: %w", table, err)
+		}
+		described = append(described, *t)
+	}
+	return described, nil
+}
+
+// lookupTable returns the database, name and comment of a table or view, the table is nil when it doesn't exist
+func (s *MySQL) lookupTable(ctx context.Context, table string) (*types.TableSchema, error) {
+	schema, name := splitTable(table)
+	t := &types.TableSchema{}
+	err := s.DB.QueryRowContext(ctx, `SELECT table_schema, table_name,
+		CASE WHEN table_type <> 'VIEW' THEN COALESCE(table_comment, '') ELSE '' END FROM information_schema.tables
+	WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ?`, schema, name).Scan(&t.Schema, &t.Name, &t.Comment)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up table %q: %w", table, err)
+	}
+	return t, nil
+}
+
+// TableNames returns the tables and views of a database, the connection's database when schema is empty
+func (s *MySQL) TableNames(ctx context.Context, schema string) ([]types.TableName, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT table_schema, table_name FROM information_schema.tables
+	WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE())
+	ORDER BY table_name`, schema)
+	if err != nil {
+		return nil, err
+	}
+	return scanTableNames(rows)
+}
+
+// ListSchemas returns the databases of the server apart from the system ones, MySQL has no schema owners or comments
+func (s *MySQL) ListSchemas(ctx context.Context, filter CatalogFilter) ([]types.SchemaInfo, error) {
+	pattern, err := filter.patternRegexp()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.QueryContext(ctx, `SELECT s.schema_name, '',
+		(SELECT count(*) FROM information_schema.tables AS t WHERE t.table_schema = s.schema_name), ''
+	FROM information_schema.schemata AS s
+	WHERE s.schema_name NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')
+		AND (? = '' OR REGEXP_LIKE(s.schema_name, ?, 'c'))
+	ORDER BY s.schema_name`+filter.limitClause(), pattern, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return scanSchemaInfos(rows)
+}
+
+// mysqlTableType is the ListTables type of an information_schema.tables row
+const mysqlTableType = `CASE WHEN table_type = 'VIEW' THEN 'view' ELSE 'table' END`
+
+// ListTables returns the tables and views of a database, the connection's database when schema is empty.
+// The rows are the estimate of information_schema.tables, exact for MyISAM tables. MySQL reports VIEW as the
+// comment of every view, it's left out.
+func (s *MySQL) ListTables(ctx context.Context, schema string, filter CatalogFilter) ([]types.TableInfo, error) {
+	pattern, err := filter.patternRegexp()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.QueryContext(ctx, `SELECT table_schema, table_name, `+mysqlTableType+`,
+		CASE WHEN table_type <> 'VIEW' THEN table_rows END,
+		CASE WHEN table_type <> 'VIEW' THEN data_length + index_length END,
+		CASE WHEN table_type <> 'VIEW' THEN COALESCE(table_comment, '') ELSE '' END
+	FROM information_schema.tables
+	WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE())
+		AND (? = '' OR REGEXP_LIKE(table_name, ?, 'c')) AND (? = '' OR `+mysqlTableType+` = ?)
+	ORDER BY table_name`+filter.limitClause(), schema, pattern, pattern, filter.Type, filter.Type)
+	if err != nil {
+		return nil, err
+	}
+	return scanTableInfos(rows)
+}
+
+// describeColumns returns the columns of a table, the data type is the column type with its length ie. varchar(200)
+func (s *MySQL) describeColumns(ctx context.Context, schema, table string) ([]types.ColumnSchema, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT column_name, column_type, is_nullable, column_default, ordinal_position,
+		COALESCE(column_comment, ''), character_maximum_length
+	FROM information_schema.columns
+	WHERE table_schema = ? AND table_name = ?
+	ORDER BY ordinal_position`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []types.ColumnSchema
+	for rows.Next() {
+		var c types.ColumnSchema
+		var nullable string
+		var def sql.NullString
+		var length sql.NullInt64
+		if err := rows.Scan(&c.Name, &c.DataType, &nullable, &def, &c.Position, &c.Comment, &length); err != nil {
+			return nil, err
+		}
+		c.DataType = strings.ToLower(c.DataType)
+		c.Nullable = nullable == "YES"
+		if def.Valid {
+			c.Default = &def.String
+		}
+		if length.Valid {
+			c.MaxLength = &length.Int64
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// describeKeys adds the primary key, unique constraints and foreign keys of a table, a constraint has a row per column
+func (s *MySQL) describeKeys(ctx context.Context, t *types.TableSchema) error {
+	rows, err := s.DB.QueryContext(ctx, `SELECT tc.constraint_name, tc.constraint_type, k.column_name,
+		COALESCE(k.referenced_table_schema, ''), COALESCE(k.referenced_table_name, ''), COALESCE(k.referenced_column_name, ''),
+		COALESCE(rc.update_rule, ''), COALESCE(rc.delete_rule, '')
+	FROM information_schema.table_constraints AS tc
+	JOIN information_schema.key_column_usage AS k ON k.constraint_schema = tc.constraint_schema
+		AND k.constraint_name = tc.constraint_name AND k.table_name = tc.table_name
+	LEFT JOIN information_schema.referential_constraints AS rc ON rc.constraint_schema = tc.constraint_schema
+		AND rc.constraint_name = tc.constraint_name AND rc.table_name = tc.table_name
+	WHERE tc.table_schema = ? AND tc.table_name = ? AND tc.constraint_type IN ('PRIMARY KEY', 'UNIQUE', 'FOREIGN KEY')
+	ORDER BY tc.constraint_type, tc.constraint_name, k.ordinal_position`, t.Schema, t.Name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	last := ""
+	for rows.Next() {
+		var name, kind, column, refSchema, refTable, refColumn, onUpdate, onDelete string
+		if err := rows.Scan(&name, &kind, &column, &refSchema, &refTable, &refColumn, &onUpdate, &onDelete); err != nil {
+			return err
+		}
+		first := kind+name != last
+		last = kind + name
+		switch kind {
+		case "PRIMARY KEY":
+			t.PrimaryKey = append(t.PrimaryKey, column)
+		case "UNIQUE":
+			if first {
+				t.Uniques = append(t.Uniques, types.UniqueConstraint{Name: name})
+			}
+			u := &t.Uniques[len(t.Uniques)-1]
+			u.Columns = append(u.Columns, column)
+		case "FOREIGN KEY":
+			if first {
+				t.ForeignKeys = append(t.ForeignKeys, types.ForeignKey{
+					Name:      name,
+					RefSchema: refSchema,
+					RefTable:  refTable,
+					OnUpdate:  onUpdate,
+					OnDelete:  onDelete,
+				})
+			}
+			fk := &t.ForeignKeys[len(t.ForeignKeys)-1]
+			fk.Columns = append(fk.Columns, column)
+			fk.RefColumns = append(fk.RefColumns, refColumn)
+		}
+	}
+	return rows.Err()
+}
+
+// describeChecks returns the check constraints of a table
+func (s *MySQL) describeChecks(ctx context.Context, schema, table string) ([]types.CheckConstraint, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT cc.constraint_name, cc.check_clause
+	FROM information_schema.table_constraints AS tc
+	JOIN information_schema.check_constraints AS cc ON cc.constraint_schema = tc.constraint_schema
+		AND cc.constraint_name = tc.constraint_name
+	WHERE tc.table_schema = ? AND tc.table_name = ? AND tc.constraint_type = 'CHECK'
+	ORDER BY cc.constraint_name`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checks []types.CheckConstraint
+	for rows.Next() {
+		var c types.CheckConstraint
+		var clause string
+		if err := rows.Scan(&c.Name, &clause); err != nil {
+			return nil, err
+		}
+		c.Definition = "CHECK (" + clause + ")"
+		checks = append(checks, c)
+	}
+	return checks, rows.Err()
+}
+
+// describeIndexes returns the indexes of a table, a key without a column name is an expression
+func (s *MySQL) describeIndexes(ctx context.Context, schema, table string) ([]types.Index, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT index_name, non_unique, COALESCE(column_name, ''), COALESCE(index_type, '')
+	FROM information_schema.statistics
+	WHERE table_schema = ? AND table_name = ?
+	ORDER BY index_name, seq_in_index`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []types.Index
+	var kinds []string
+	for rows.Next() {
+		var name, column, kind string
+		var nonUnique bool
+		if err := rows.Scan(&name, &nonUnique, &column, &kind); err != nil {
+			return nil, err
+		}
+		if column == "" {
+			column = "<expression>"
+		}
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != name {
+			indexes = append(indexes, types.Index{Name: name, Unique: !nonUnique, Primary: name == "PRIMARY"})
+			kinds = append(kinds, kind)
+		}
+		idx := &indexes[len(indexes)-1]
+		idx.Columns = append(idx.Columns, column)
+	}
+	for i := range indexes {
+		indexes[i].Definition = mysqlIndexDef(table, &indexes[i], kinds[i])
+	}
+	return indexes, rows.Err()
+}
+
+// mysqlIndexDef returns the statement that creates an index, the primary key is written as its table constraint
+func mysqlIndexDef(table string, idx *types.Index, kind string) string {
+	columns := make([]string, len(idx.Columns))
+	for i, c := range idx.Columns {
+		columns[i] = "`" + strings.ReplaceAll(c, "`", "``") + "`"
+	}
+	if idx.Primary {
+		return "PRIMARY KEY (" + strings.Join(columns, ", ") + ")"
+	}
+	create := "CREATE INDEX"
+	switch {
+	case idx.Unique:
+		create = "CREATE UNIQUE INDEX"
+	case kind == "FULLTEXT" || kind == "SPATIAL":
+		create = "CREATE " + kind + " INDEX"
+	}
+	return fmt.Sprintf("%s `%s` ON `%s` (%s)", create, strings.ReplaceAll(idx.Name, "`", "``"),
+		strings.ReplaceAll(table, "`", "``"), strings.Join(columns, ", "))
+}
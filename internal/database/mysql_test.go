@@ -0,0 +1,363 @@
+package database_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/pkg/types"
+	sqle "github.com/dolthub/go-mysql-server"
+	"github.com/dolthub/go-mysql-server/memory"
+	"github.com/dolthub/go-mysql-server/server"
+	gsql "github.com/dolthub/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMySQLClient starts an in-process MySQL compatible server and returns a client populated with the customers table
+func newMySQLClient(t *testing.T) database.ClientInterface {
+	t.Helper()
+	db := memory.NewDatabase("app")
+	db.BaseDatabase.EnablePrimaryKeyIndexes()
+	pro := memory.NewDBProvider(db)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv, err := server.NewServer(server.Config{Protocol: "tcp", Listener: l}, sqle.NewDefault(pro), gsql.NewContext, memory.NewSessionBuilder(pro), nil)
+	if err != nil {
+		t.Fatalf("failed to create mysql server: %v", err)
+	}
+	go srv.Start()
+	t.Cleanup(func() { srv.Close() })
+
+	client, err := database.NewClient(config.DatabaseConfig{
+		Name:   "orders",
+		Driver: config.DriverMySQL,
+		DSN:    "root@tcp(" + l.Addr().String() + ")/app",
+	})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	stmts := []string{
+		"CREATE TABLE customers (id INT PRIMARY KEY, name VARCHAR(200) NOT NULL, balance DECIMAL(10,2), tags JSON, avatar BLOB, created DATETIME)",
+		"INSERT INTO customers VALUES (1, 'Bob', 10.50, '[\"vip\"]', X'0102', '2024-01-02 03:04:05'), (2, 'Anna', NULL, NULL, NULL, NULL)",
+	}
+	for _, stmt := range stmts {
+		if _, err := client.ExecPrepared(context.Background(), stmt, nil); err != nil {
+			t.Fatalf("failed to populate database: %v", err)
+		}
+	}
+	return client
+}
+
+func TestMySQL_ExecQuery(t *testing.T) {
+	client := newMySQLClient(t)
+
+	columns := []types.Column{
+		{Name: "id", Type: "INT"}, {Name: "name", Type: "VARCHAR"}, {Name: "balance", Type: "DECIMAL", Precision: 10, Scale: 2},
+		{Name: "tags", Type: "JSON"}, {Name: "avatar", Type: "BLOB"}, {Name: "created", Type: "DATETIME"},
+	}
+	row := []any{int64(1), "Bob", json.Number("10.50"), json.RawMessage(`["vip"]`), types.Binary{1, 2}, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	row1 := []any{int64(2), "Anna", nil, nil, nil, nil}
+	expectedAll := &types.ResultSet{Columns: columns, Rows: [][]any{row, row1}}
+	expectedById := &types.ResultSet{Columns: columns, Rows: [][]any{row1}}
+
+	paramsById := map[string]any{"1": 2}
+
+	tests := []struct {
+		name    string
+		query   string
+		params  map[string]any
+		want    *types.ResultSet
+		wantErr bool
+	}{
+		{name: "Happy Flow execute_query - SELECT all", query: "SELECT id, name, balance, tags, avatar, created FROM customers ORDER BY id", want: expectedAll, wantErr: false},
+		{name: "Happy Flow execute_query - SELECT by id", query: "SELECT id, name, balance, tags, avatar, created FROM customers WHERE id = ?", params: paramsById, want: expectedById, wantErr: false},
+		{name: "Sad Flow execute_query - table doesn't exist", query: "SELECT * FROM orders", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := client.ExecQuery(context.Background(), tt.query, tt.params, 0)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("ExecQuery() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("ExecQuery() succeeded unexpectedly")
+			}
+			assert.EqualValues(t, tt.want, got)
+		})
+	}
+}
+
+func TestMySQL_ExecQuery_SelectLimit(t *testing.T) {
+	client := newMySQLClient(t)
+	ctx := context.Background()
+
+	// the server applies the cap, the client doesn't read and discard the rows above it
+	got, err := client.ExecQuery(ctx, "SELECT @@SESSION.sql_select_limit AS cap", nil, 5)
+	if err != nil {
+		t.Fatalf("ExecQuery() failed: %v", err)
+	}
+	assert.EqualValues(t, [][]any{{int64(5)}}, got.Rows)
+
+	got, err = client.ExecQuery(ctx, "SELECT id FROM customers ORDER BY id", nil, 1)
+	if err != nil {
+		t.Fatalf("ExecQuery() failed: %v", err)
+	}
+	assert.EqualValues(t, [][]any{{int64(1)}}, got.Rows)
+
+	// sql_select_limit is reset before the connection goes back to the pool, a query without a cap reads every row
+	got, err = client.ExecQuery(ctx, "SELECT id FROM customers ORDER BY id", nil, 0)
+	if err != nil {
+		t.Fatalf("ExecQuery() failed: %v", err)
+	}
+	assert.EqualValues(t, [][]any{{int64(1)}, {int64(2)}}, got.Rows)
+}
+
+func TestMySQL_OpenCursor(t *testing.T) {
+	client := newMySQLClient(t)
+	ctx := context.Background()
+
+	cursor, err := client.OpenCursor(ctx, "SELECT id, name FROM customers WHERE id > ? ORDER BY id", map[string]any{"1": 0})
+	if err != nil {
+		t.Fatalf("OpenCursor() failed: %v", err)
+	}
+	defer cursor.Close()
+
+	columns := []types.Column{{Name: "id", Type: "INT"}, {Name: "name", Type: "VARCHAR"}}
+	pages := []struct {
+		rows [][]any
+		more bool
+	}{
+		{rows: [][]any{{int64(1), "Bob"}}, more: true},
+		{rows: [][]any{{int64(2), "Anna"}}, more: false},
+	}
+	for _, page := range pages {
+		got, more, err := cursor.Fetch(ctx, 1)
+		if err != nil {
+			t.Fatalf("Fetch() failed: %v", err)
+		}
+		assert.EqualValues(t, &types.ResultSet{Columns: columns, Rows: page.rows}, got)
+		assert.Equal(t, page.more, more)
+	}
+}
+
+func TestMySQL_ExecPrepared(t *testing.T) {
+	client := newMySQLClient(t)
+
+	columns := []types.Column{{Name: "message"}, {Name: "rowsAffected"}}
+	expected := &types.ResultSet{Columns: columns, Rows: [][]any{{"success", int64(1)}}}
+	expectedUpdateAll := &types.ResultSet{Columns: columns, Rows: [][]any{{"success", int64(3)}}}
+
+	tests := []struct {
+		name      string
+		statement string
+		params    []any
+		want      *types.ResultSet
+		wantErr   bool
+	}{
+		{name: "Happy Flow execute_prepared - INSERT", statement: "INSERT INTO customers (id, name) VALUES (?, ?)", params: []any{3, "Joe"}, want: expected, wantErr: false},
+		{name: "Happy Flow execute_prepared - UPDATE all rows", statement: "UPDATE customers SET balance = 1", want: expectedUpdateAll, wantErr: false},
+		{name: "Sad Flow execute_prepared - duplicate primary key", statement: "INSERT INTO customers (id, name) VALUES (?, ?)", params: []any{1, "Bob"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := client.ExecPrepared(context.Background(), tt.statement, tt.params)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("ExecPrepared() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("ExecPrepared() succeeded unexpectedly")
+			}
+			assert.EqualValues(t, tt.want, got)
+		})
+	}
+}
+
+func TestMySQL_GetSchema(t *testing.T) {
+	client := newMySQLClient(t)
+
+	length, blob := int64(200), int64(65535)
+	customers := types.TableSchema{Schema: "app", Name: "customers", Columns: []types.ColumnSchema{
+		{Name: "id", DataType: "int", Position: 1},
+		{Name: "name", DataType: "varchar", Position: 2, MaxLength: &length},
+		{Name: "balance", DataType: "decimal", Nullable: true, Position: 3},
+		{Name: "tags", DataType: "json", Nullable: true, Position: 4},
+		{Name: "avatar", DataType: "blob", Nullable: true, Position: 5, MaxLength: &blob},
+		{Name: "created", DataType: "datetime", Nullable: true, Position: 6},
+	}}
+
+	tests := []struct {
+		name    string
+		tables  []string
+		want    []types.TableSchema
+		wantErr bool
+	}{
+		{name: "Happy Flow get_schema - GET customers SCHEMA", tables: []string{"customers"}, want: []types.TableSchema{customers}, wantErr: false},
+		{name: "Happy Flow get_schema - GET schema of a qualified table", tables: []string{"app.customers"}, want: []types.TableSchema{customers}, wantErr: false},
+		{name: "Happy Flow get_schema - GET schema for table that doesn't exist", tables: []string{"orders", "other.customers"}, want: nil, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := client.GetSchema(context.Background(), tt.tables)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("GetSchema() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("GetSchema() succeeded unexpectedly")
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMySQL_TableNames(t *testing.T) {
+	client := newMySQLClient(t)
+
+	got, err := client.TableNames(context.Background(), "")
+	if err != nil {
+		t.Fatalf("TableNames() failed: %v", err)
+	}
+	assert.Equal(t, []types.TableName{{Schema: "app", Name: "customers"}}, got)
+
+	got, err = client.TableNames(context.Background(), "other")
+	if err != nil {
+		t.Fatalf("TableNames() failed: %v", err)
+	}
+	assert.Empty(t, got)
+}
+
+func TestMySQL_DescribeTables(t *testing.T) {
+	client := newMySQLClient(t)
+	stmts := []string{
+		`CREATE TABLE orders (id INT PRIMARY KEY, customer_id INT NOT NULL, code VARCHAR(20), total DECIMAL(10,2) DEFAULT 0,
+			CONSTRAINT orders_code UNIQUE (code), CONSTRAINT orders_customer FOREIGN KEY (customer_id) REFERENCES customers (id) ON DELETE CASCADE,
+			CONSTRAINT positive CHECK (total >= 0)) COMMENT 'customer orders'`,
+		"CREATE INDEX orders_total ON orders (customer_id, total)",
+	}
+	for _, stmt := range stmts {
+		if _, err := client.ExecPrepared(context.Background(), stmt, nil); err != nil {
+			t.Fatalf("failed to populate database: %v", err)
+		}
+	}
+
+	got, err := client.DescribeTables(context.Background(), []string{"missing", "orders"})
+	if err != nil {
+		t.Fatalf("DescribeTables() failed: %v", err)
+	}
+	if !assert.Len(t, got, 1) {
+		return
+	}
+	orders := got[0]
+	assert.Equal(t, "app", orders.Schema)
+	assert.Equal(t, "orders", orders.Name)
+	assert.Equal(t, "customer orders", orders.Comment)
+	if assert.Len(t, orders.Columns, 4) {
+		assert.Equal(t, types.ColumnSchema{Name: "customer_id", DataType: "int", Position: 2}, orders.Columns[1])
+		assert.True(t, orders.Columns[2].Nullable)
+		assert.Equal(t, int64(20), *orders.Columns[2].MaxLength)
+		assert.NotNil(t, orders.Columns[3].Default)
+	}
+	assert.Equal(t, []string{"id"}, orders.PrimaryKey)
+	assert.Equal(t, []types.ForeignKey{{Name: "orders_customer", Columns: []string{"customer_id"}, RefSchema: "app", RefTable: "customers",
+		RefColumns: []string{"id"}, OnUpdate: "NO ACTION", OnDelete: "CASCADE"}}, orders.ForeignKeys)
+	assert.Equal(t, []types.UniqueConstraint{{Name: "orders_code", Columns: []string{"code"}}}, orders.Uniques)
+	if assert.Len(t, orders.Checks, 1) {
+		assert.Equal(t, "positive", orders.Checks[0].Name)
+		assert.Contains(t, orders.Checks[0].Definition, "total")
+	}
+	assert.Contains(t, orders.Indexes, types.Index{Name: "PRIMARY", Columns: []string{"id"}, Unique: true, Primary: true, Definition: "PRIMARY KEY (`id`)"})
+	assert.Contains(t, orders.Indexes, types.Index{Name: "orders_total", Columns: []string{"customer_id", "total"},
+		Definition: "CREATE INDEX `orders_total` ON `orders` (`customer_id`, `total`)"})
+}
+
+func TestMySQL_ListTables(t *testing.T) {
+	client := newMySQLClient(t)
+	if _, err := client.ExecPrepared(context.Background(), "CREATE VIEW big_customers AS SELECT id FROM customers WHERE balance > 5", nil); err != nil {
+		t.Fatalf("failed to populate database: %v", err)
+	}
+
+	got, err := client.ListTables(context.Background(), "", database.CatalogFilter{})
+	if err != nil {
+		t.Fatalf("ListTables() failed: %v", err)
+	}
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, types.TableInfo{Schema: "app", Name: "big_customers", Type: "view"}, got[0])
+		assert.Equal(t, "customers", got[1].Name)
+		assert.Equal(t, "table", got[1].Type)
+	}
+
+	got, err = client.ListTables(context.Background(), "other", database.CatalogFilter{})
+	if err != nil {
+		t.Fatalf("ListTables() failed: %v", err)
+	}
+	assert.Empty(t, got)
+
+	filters := []struct {
+		name   string
+		filter database.CatalogFilter
+		want   []string
+	}{
+		{name: "Happy Flow - pattern", filter: database.CatalogFilter{Pattern: "big_*"}, want: []string{"big_customers"}},
+		{name: "Happy Flow - pattern is case sensitive", filter: database.CatalogFilter{Pattern: "Big_*"}},
+		{name: "Happy Flow - type", filter: database.CatalogFilter{Type: "table"}, want: []string{"customers"}},
+		{name: "Happy Flow - page", filter: database.CatalogFilter{Limit: 1, Offset: 1}, want: []string{"customers"}},
+	}
+	for _, tt := range filters {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.ListTables(context.Background(), "", tt.filter)
+			if err != nil {
+				t.Fatalf("ListTables() failed: %v", err)
+			}
+			var names []string
+			for _, table := range got {
+				names = append(names, table.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestMySQL_ListSchemas(t *testing.T) {
+	client := newMySQLClient(t)
+
+	got, err := client.ListSchemas(context.Background(), database.CatalogFilter{})
+	if err != nil {
+		t.Fatalf("ListSchemas() failed: %v", err)
+	}
+	assert.Contains(t, got, types.SchemaInfo{Name: "app", Tables: 1})
+
+	got, err = client.ListSchemas(context.Background(), database.CatalogFilter{Pattern: "a?p", Limit: 1})
+	if err != nil {
+		t.Fatalf("ListSchemas() failed: %v", err)
+	}
+	assert.Equal(t, []types.SchemaInfo{{Name: "app", Tables: 1}}, got)
+}
+
+func TestMySQL_Status(t *testing.T) {
+	client := newMySQLClient(t)
+
+	got, err := client.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+	assert.Equal(t, "8.0.31", got.ServerVersion)
+	assert.GreaterOrEqual(t, got.Pool.OpenConnections, 1)
+	assert.Positive(t, got.PingLatency)
+	assert.WithinDuration(t, time.Now(), got.PingedAt, time.Minute)
+}
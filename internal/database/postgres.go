@@ -3,193 +3,283 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
-	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
-	_ "github.com/lib/pq"
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/internal/sqlparser"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/lib/pq"
 )
 
-var (
-	DB_USER     = os.Getenv("POSTGRES_USER")
-	DB_PASSWORD = os.Getenv("POSTGRES_PW")
-	DB_NAME     = os.Getenv("POSTGRES_DB")
-)
+// pqQueryCanceled is the SQLSTATE of statements cancelled by statement_timeout
+const pqQueryCanceled = "57014"
+
+// pgCursorName names the cursor declared by OpenCursor, each cursor has a transaction of its own
+const pgCursorName = "mcp_cursor"
 
 type Postgress struct {
-	Pg *sql.DB
+	Pg     *sql.DB
+	guards []guard
 }
 
-// NewPostgressClient creates a new PostgreSQL client
-func NewPostgressClient() (ClientInterface, error) {
-	// remove host=postgresql when running localy
-	connStr := fmt.Sprintf("user=%s dbname=%s password=%s sslmode=disable", DB_USER, DB_NAME, DB_PASSWORD)
+// guard is a setting applied with SET LOCAL to every ExecQuery transaction
+type guard struct {
+	name, value string
+}
 
-	pg, err := sql.Open("postgres", connStr)
+// NewPostgressClient creates a new PostgreSQL client for the given database config
+func NewPostgressClient(cfg config.DatabaseConfig) (ClientInterface, error) {
+	pg, err := sql.Open("postgres", postgresDSN(cfg))
 	if err != nil {
-		log.Fatal(err)
-		return nil, err
+		return nil, fmt.Errorf("failed to open postgres database %q: %w", cfg.Name, err)
+	}
+	applyPool(pg, cfg.Pool)
+
+	names := make([]string, 0, len(cfg.Policy.SetLocal))
+	for name := range cfg.Policy.SetLocal {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	guards := make([]guard, 0, len(names))
+	for _, name := range names {
+		guards = append(guards, guard{name: name, value: cfg.Policy.SetLocal[name]})
 	}
 
 	return &Postgress{
-		Pg: pg,
-	}, err
+		Pg:     pg,
+		guards: guards,
+	}, nil
 }
 
-// ExecQuery executes a query on the PostgreSQL database and returns the results as a slice of maps
-func (s *Postgress) ExecQuery(ctx context.Context, query string, params map[string]any) ([]map[string]interface{}, error) {
-	// remove
-	log.Printf("ExecQuery query: %v \n", query)
-	log.Printf("ExecQuery params: %v \n", params)
-
-	var allMaps []map[string]interface{}
-	var rows *sql.Rows
-	stmt, err := s.Pg.PrepareContext(ctx, query)
-	if err != nil {
-		return nil, err
+// postgresDSN builds a lib/pq key=value connection string, cfg.DSN is used as is when set
+func postgresDSN(cfg config.DatabaseConfig) string {
+	if cfg.DSN != "" {
+		return cfg.DSN
 	}
-	defer stmt.Close()
 
-	// decide if system should use QueryContext with params
-	if len(params) > 0 {
-		var args []any
-
-		for k := range params {
-			args = append(args, params[k])
-		}
-		rows, err = stmt.QueryContext(ctx, args...)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		rows, err = stmt.QueryContext(ctx)
-		if err != nil {
-			return nil, err
+	params := map[string]string{}
+	for k, v := range cfg.Options {
+		params[k] = v
+	}
+	set := func(key, value string) {
+		if value != "" {
+			params[key] = value
 		}
 	}
-	defer rows.Close()
-
-	columns, err := rows.Columns()
-	if err != nil {
-		return nil, err
+	set("host", cfg.Host)
+	if cfg.Port > 0 {
+		set("port", strconv.Itoa(cfg.Port))
 	}
+	set("user", cfg.User)
+	set("password", cfg.Password)
+	set("dbname", cfg.DBName)
+	// keep the previous default for local docker compose setups
+	params["sslmode"] = "disable"
+	set("sslmode", cfg.SSLMode)
 
-	if len(columns) == 0 {
-		// Default response for operations that don't return rows
-		// ie. CREATE SCHEMA schema_name OR INSERT INTO schema_name.table etc
-		defaultResp := make(map[string]interface{})
-		defaultResp["message"] = "success"
-		allMaps = append(allMaps, defaultResp)
-
-	} else {
-		// The system handles dynamic queries, so the results are scanned into a slice of pointers to interface{} variables.
-		allMaps, err = s.itterateRows(rows, len(columns), columns)
-		if err != nil {
-			return nil, err
-		}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 
-	// remove
-	log.Printf("ExecQuery response: %v \n ", allMaps)
-	return allMaps, nil
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+quoteDSNValue(params[k]))
+	}
+	return strings.Join(parts, " ")
 }
 
-// ExecPrepared executes a prepared statement with the given parameters and returns the results as a slice of maps.
-func (s *Postgress) ExecPrepared(ctx context.Context, statement string, params []any) ([]map[string]interface{}, error) {
-	var allMaps []map[string]interface{}
+// quoteDSNValue quotes values containing spaces, quotes or backslashes as lib/pq expects
+func quoteDSNValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
 
-	stmt, err := s.Pg.PrepareContext(ctx, statement)
+// ExecQuery executes a query in a READ ONLY transaction on the PostgreSQL database and returns the result set
+func (s *Postgress) ExecQuery(ctx context.Context, query string, params map[string]any, maxRows int) (*types.ResultSet, error) {
+	query, args, err := sqlparser.Bind(query, params, sqlparser.Postgres)
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
 
-	result, err := stmt.ExecContext(ctx, params...)
-	if err != nil {
-		return nil, err
+	var result *types.ResultSet
+	if maxRows > 0 && pgDeclarable(query) {
+		result, err = s.fetchQuery(ctx, query, args, maxRows)
+	} else {
+		result, err = readOnlyQuery(ctx, s.Pg, s.setLocal(ctx), query, args, maxRows, decodePostgresValue)
 	}
-	rows, err := result.RowsAffected()
 	if err != nil {
-		return nil, err
+		return nil, pgTimeoutError(err)
 	}
-
-	defaultResp := make(map[string]interface{})
-	defaultResp["message"] = "success"
-	defaultResp["rowsAffected"] = rows
-	allMaps = append(allMaps, defaultResp)
-
-	return allMaps, nil
+	return result, nil
 }
 
-// GetSchema retrieves the schema information for the specified tables
-func (s *Postgress) GetSchema(ctx context.Context, tables []string) ([]map[string]interface{}, error) {
-	log.Printf("GetSchema params: %v \n", tables)
-
-	q := `select column_name, data_type, character_maximum_length from INFORMATION_SCHEMA.COLUMNS where table_name =$1;`
-
-	var allMaps []map[string]interface{}
-	var rows *sql.Rows
-	stmt, err := s.Pg.PrepareContext(ctx, q)
+// fetchQuery declares a cursor for the query and fetches maxRows rows from it, so Postgres stops producing rows at the
+// limit. lib/pq reads and discards the rest of a result when its rows are closed early.
+func (s *Postgress) fetchQuery(ctx context.Context, query string, args []any, maxRows int) (*types.ResultSet, error) {
+	tx, end, err := readOnlyTx(s.Pg, s.setLocal(ctx))(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer stmt.Close()
-
-	var args []any
+	defer end()
 
-	for k := range tables {
-		args = append(args, tables[k])
+	// the transaction holds a single cursor, it's closed by the rollback
+	if _, err := tx.ExecContext(ctx, "DECLARE "+pgCursorName+" NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return nil, timeoutError(ctx, err)
 	}
-	rows, err = stmt.QueryContext(ctx, args...)
+	result, err := queryPage(ctx, tx, fmt.Sprintf("FETCH FORWARD %d FROM %s", maxRows, pgCursorName), decodePostgresValue, progressFrom(ctx))
 	if err != nil {
-		return nil, err
+		return nil, timeoutError(ctx, err)
 	}
-	defer rows.Close()
+	return result, nil
+}
 
-	columns, err := rows.Columns()
+// pgDeclarable reports whether a cursor can be declared for the query, DECLARE takes SELECT and VALUES queries
+// but not EXPLAIN or SHOW
+func pgDeclarable(query string) bool {
+	stmt, err := sqlparser.Parse(query, sqlparser.Postgres)
 	if err != nil {
-		return nil, err
+		return false
+	}
+	switch stmt.Keyword {
+	case "SELECT", "WITH", "VALUES", "TABLE":
+		return true
 	}
+	return false
+}
 
-	allMaps, err = s.itterateRows(rows, len(columns), columns)
+// OpenCursor declares a server side cursor for the query in a READ ONLY transaction that is held until the cursor
+// is closed. Every page is a FETCH of its own, so statement_timeout and the guards apply to each page.
+func (s *Postgress) OpenCursor(ctx context.Context, query string, params map[string]any) (Cursor, error) {
+	query, args, err := sqlparser.Bind(query, params, sqlparser.Postgres)
 	if err != nil {
 		return nil, err
 	}
 
-	return allMaps, nil
+	start := func(ctx context.Context, q querier) (pageReader, func(), error) {
+		// the transaction holds a single cursor, it's closed by the rollback
+		if _, err := q.ExecContext(ctx, "DECLARE "+pgCursorName+" NO SCROLL CURSOR FOR "+query, args...); err != nil {
+			return nil, nil, err
+		}
+		read := func(fetchCtx context.Context, n int) (*types.ResultSet, error) {
+			page, err := queryPage(ctx, q, fmt.Sprintf("FETCH FORWARD %d FROM %s", n, pgCursorName), decodePostgresValue, progressFrom(fetchCtx))
+			if err != nil {
+				return nil, pgTimeoutError(err)
+			}
+			return page, nil
+		}
+		return read, func() {}, nil
+	}
+	cursor, err := openCursor(ctx, readOnlyTx(s.Pg, s.setLocal(ctx)), start)
+	if err != nil {
+		return nil, pgTimeoutError(err)
+	}
+	return cursor, nil
 }
 
-func (s *Postgress) itterateRows(rows *sql.Rows, sliceSize int, columns []string) ([]map[string]interface{}, error) {
-	// The system handles dynamic queries, so the results are scanned into a slice of pointers to interface{} variables.
-	var allMaps []map[string]interface{}
-	for rows.Next() {
-		values := make([]interface{}, sliceSize)
-		pointers := make([]interface{}, sliceSize)
-		for i := range values {
-			pointers[i] = &values[i]
-		}
-		if err := rows.Scan(pointers...); err != nil {
-			// Check for a scan error.
-			// Query rows will be closed with defer.
-			return nil, err
+// pgTimeoutError wraps errors of statements cancelled by statement_timeout with ErrQueryTimeout
+func pgTimeoutError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pqQueryCanceled {
+		return fmt.Errorf("%w: %v", ErrQueryTimeout, err)
+	}
+	return err
+}
+
+// setLocal returns the transaction setup of ExecQuery, the statement_timeout matching the context deadline makes
+// Postgres cancel the query itself instead of only the client giving up on it, then the configured guards are applied
+func (s *Postgress) setLocal(ctx context.Context) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout := time.Until(deadline).Milliseconds()
+			if timeout < 1 {
+				timeout = 1
+			}
+			// SET doesn't accept bind parameters, the value is an integer
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout)); err != nil {
+				return err
+			}
 		}
-		resultMap := make(map[string]interface{})
-		for i, val := range values {
-			resultMap[columns[i]] = val
+		for _, g := range s.guards {
+			// set_config with is_local = true is SET LOCAL with bind parameters
+			if _, err := tx.ExecContext(ctx, "SELECT set_config($1, $2, true)", g.name, g.value); err != nil {
+				return fmt.Errorf("failed to set %s: %w", g.name, err)
+			}
 		}
-		allMaps = append(allMaps, resultMap)
+		return nil
 	}
-	// If the database is being written to ensure to check for Close
-	// errors that may be returned from the driver. The query may
-	// encounter an auto-commit error and be forced to rollback changes.
-	err := rows.Close()
+}
+
+// ExecPrepared executes a prepared statement with the given parameters and returns the number of affected rows.
+func (s *Postgress) ExecPrepared(ctx context.Context, statement string, params []any) (*types.ResultSet, error) {
+	return execPrepared(ctx, s.Pg, statement, params)
+}
+
+// GetSchema returns the columns of the tables, a name is looked up in the search_path unless it's qualified with a schema
+func (s *Postgress) GetSchema(ctx context.Context, tables []string) ([]types.TableSchema, error) {
+	log.Printf("GetSchema params: %v \n", tables)
+
+	q := `select column_name, data_type, is_nullable = 'YES', column_default, ordinal_position, character_maximum_length
+	from INFORMATION_SCHEMA.COLUMNS where table_schema = $1 and table_name = $2 order by ordinal_position;`
+
+	stmt, err := s.Pg.PrepareContext(ctx, q)
 	if err != nil {
 		return nil, err
 	}
+	defer stmt.Close()
 
-	// Rows.Err will report the last error encountered by Rows.Scan.
-	if err := rows.Err(); err != nil {
-		return nil, err
+	var schemas []types.TableSchema
+	for _, table := range tables {
+		_, t, err := s.lookupTable(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			continue
+		}
+		rows, err := stmt.QueryContext(ctx, t.Schema, t.Name)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var c types.ColumnSchema
+			var def sql.NullString
+			var length sql.NullInt64
+			if err := rows.Scan(&c.Name, &c.DataType, &c.Nullable, &def, &c.Position, &length); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if def.Valid {
+				c.Default = &def.String
+			}
+			if length.Valid {
+				c.MaxLength = &length.Int64
+			}
+			t.Columns = append(t.Columns, c)
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, *t)
 	}
-	return allMaps, nil
+	return schemas, nil
+}
+
+// Status pings the database and returns the pool statistics and server version
+func (s *Postgress) Status(ctx context.Context) (*Status, error) {
+	return dbStatus(ctx, s.Pg, "SHOW server_version")
 }
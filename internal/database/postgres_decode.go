@@ -0,0 +1,211 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+
+	"exmple.com/database-query-server/pkg/types"
+)
+
+// decodePostgresValue converts the values lib/pq returns as raw bytes by the column type name
+func decodePostgresValue(column *sql.ColumnType, value interface{}) interface{} {
+	return decodePostgres(column.DatabaseTypeName(), value)
+}
+
+// decodePostgres converts a value of the given Postgres type into a value that keeps its meaning in JSON:
+// numeric is an exact json.Number, json and jsonb are embedded as raw JSON, arrays are slices, bytea is
+// types.Binary and the other text types ie. uuid, interval, inet are strings
+func decodePostgres(typeName string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		// NaN and Infinity can't be marshalled to JSON
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		return v
+	case []byte:
+		switch {
+		case typeName == "BYTEA":
+			// lib/pq already decoded the hex format
+			return types.Binary(v)
+		case strings.HasPrefix(typeName, "_"):
+			if arr, ok := parsePgArray(string(v), strings.TrimPrefix(typeName, "_")); ok {
+				return arr
+			}
+			return string(v)
+		}
+		return decodePgText(typeName, string(v))
+	}
+	return value
+}
+
+// decodePgText converts the text representation of a value of the given type
+func decodePgText(typeName, s string) interface{} {
+	switch typeName {
+	case "NUMERIC":
+		// NaN and Infinity aren't valid JSON numbers
+		if _, err := strconv.ParseFloat(s, 64); err == nil && s != "NaN" && !strings.Contains(s, "Infinity") {
+			return json.Number(s)
+		}
+	case "JSON", "JSONB":
+		if json.Valid([]byte(s)) {
+			return json.RawMessage(s)
+		}
+	case "INT2", "INT4", "INT8", "OID":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case "FLOAT4", "FLOAT8":
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return f
+		}
+	case "BOOL":
+		switch s {
+		case "t":
+			return true
+		case "f":
+			return false
+		}
+	case "BYTEA":
+		// elements of bytea arrays are in the hex format
+		if b, ok := decodePgHex(s); ok {
+			return b
+		}
+	}
+	return s
+}
+
+// decodePgHex decodes the \x hex format of bytea
+func decodePgHex(s string) (types.Binary, bool) {
+	if !strings.HasPrefix(s, `\x`) || len(s)%2 != 0 {
+		return nil, false
+	}
+	b := make(types.Binary, 0, (len(s)-2)/2)
+	for i := 2; i < len(s); i += 2 {
+		n, err := strconv.ParseUint(s[i:i+2], 16, 8)
+		if err != nil {
+			return nil, false
+		}
+		b = append(b, byte(n))
+	}
+	return b, true
+}
+
+// parsePgArray parses the text representation of an array ie. {1,2,NULL} or {{"a b",c},{d,e}} into nested
+// slices, the elements are decoded as values of elemType. ok is false when s isn't a valid array literal.
+func parsePgArray(s, elemType string) (arr []interface{}, ok bool) {
+	// arrays with a lower bound other than 1 are prefixed with the dimensions ie. [0:1]={1,2}
+	if strings.HasPrefix(s, "[") {
+		i := strings.Index(s, "=")
+		if i < 0 {
+			return nil, false
+		}
+		s = s[i+1:]
+	}
+	p := &pgArrayParser{s: s, elemType: elemType}
+	arr, ok = p.array()
+	if !ok || p.pos != len(p.s) {
+		return nil, false
+	}
+	return arr, true
+}
+
+type pgArrayParser struct {
+	s        string
+	pos      int
+	elemType string
+}
+
+// delim is the element delimiter, box is the only built in type that doesn't use a comma
+func (p *pgArrayParser) delim() byte {
+	if p.elemType == "BOX" {
+		return ';'
+	}
+	return ','
+}
+
+func (p *pgArrayParser) array() ([]interface{}, bool) {
+	if p.pos >= len(p.s) || p.s[p.pos] != '{' {
+		return nil, false
+	}
+	p.pos++
+	arr := []interface{}{}
+	if p.pos < len(p.s) && p.s[p.pos] == '}' {
+		p.pos++
+		return arr, true
+	}
+	for p.pos < len(p.s) {
+		var elem interface{}
+		switch p.s[p.pos] {
+		case '{':
+			sub, ok := p.array()
+			if !ok {
+				return nil, false
+			}
+			elem = sub
+		case '"':
+			s, ok := p.quoted()
+			if !ok {
+				return nil, false
+			}
+			elem = decodePgText(p.elemType, s)
+		default:
+			s := p.unquoted()
+			if s == "NULL" {
+				elem = nil
+			} else {
+				elem = decodePgText(p.elemType, s)
+			}
+		}
+		arr = append(arr, elem)
+
+		if p.pos >= len(p.s) {
+			return nil, false
+		}
+		switch p.s[p.pos] {
+		case '}':
+			p.pos++
+			return arr, true
+		case p.delim():
+			p.pos++
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// quoted reads a double quoted element, a backslash escapes the next character
+func (p *pgArrayParser) quoted() (string, bool) {
+	var b strings.Builder
+	p.pos++
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case '\\':
+			if p.pos >= len(p.s) {
+				return "", false
+			}
+			b.WriteByte(p.s[p.pos])
+			p.pos++
+		case '"':
+			return b.String(), true
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false
+}
+
+// unquoted reads an element up to the next delimiter or the end of the array
+func (p *pgArrayParser) unquoted() string {
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] != p.delim() && p.s[p.pos] != '}' {
+		p.pos++
+	}
+	return strings.TrimSpace(p.s[start:p.pos])
+}
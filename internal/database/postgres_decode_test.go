@@ -0,0 +1,44 @@
+package database
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_decodePostgres(t *testing.T) {
+	tests := []struct {
+		name     string
+		typeName string
+		value    any
+		want     any
+	}{
+		{name: "Happy Flow - numeric is an exact number", typeName: "NUMERIC", value: []byte("12345678901234567890.0001"), want: json.Number("12345678901234567890.0001")},
+		{name: "Happy Flow - numeric NaN is a string", typeName: "NUMERIC", value: []byte("NaN"), want: "NaN"},
+		{name: "Happy Flow - jsonb is embedded", typeName: "JSONB", value: []byte(`{"a": [1, 2]}`), want: json.RawMessage(`{"a": [1, 2]}`)},
+		{name: "Happy Flow - uuid is a string", typeName: "UUID", value: []byte("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"), want: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"},
+		{name: "Happy Flow - interval is a string", typeName: "INTERVAL", value: []byte("1 day 02:00:00"), want: "1 day 02:00:00"},
+		{name: "Happy Flow - inet is a string", typeName: "INET", value: []byte("10.0.0.1/32"), want: "10.0.0.1/32"},
+		{name: "Happy Flow - bytea is binary", typeName: "BYTEA", value: []byte{0xde, 0xad}, want: types.Binary{0xde, 0xad}},
+		{name: "Happy Flow - int array", typeName: "_INT4", value: []byte("{1,NULL,3}"), want: []any{int64(1), nil, int64(3)}},
+		{name: "Happy Flow - nested text array with quotes", typeName: "_TEXT", value: []byte(`{{"a b","say \"hi\""},{NULL,"NULL"}}`), want: []any{[]any{"a b", `say "hi"`}, []any{nil, "NULL"}}},
+		{name: "Happy Flow - text array keeps semicolons", typeName: "_TEXT", value: []byte("{a;b,c}"), want: []any{"a;b", "c"}},
+		{name: "Happy Flow - array with dimensions", typeName: "_NUMERIC", value: []byte("[0:1]={1.5,2}"), want: []any{json.Number("1.5"), json.Number("2")}},
+		{name: "Happy Flow - bool array", typeName: "_BOOL", value: []byte("{t,f}"), want: []any{true, false}},
+		{name: "Happy Flow - jsonb array", typeName: "_JSONB", value: []byte(`{"{\"a\": 1}"}`), want: []any{json.RawMessage(`{"a": 1}`)}},
+		{name: "Happy Flow - bytea array", typeName: "_BYTEA", value: []byte(`{"\\x0102"}`), want: []any{types.Binary{1, 2}}},
+		{name: "Happy Flow - empty array", typeName: "_INT8", value: []byte("{}"), want: []any{}},
+		{name: "Happy Flow - float NaN is a string", typeName: "FLOAT8", value: math.NaN(), want: "NaN"},
+		{name: "Happy Flow - driver values are kept", typeName: "INT8", value: int64(7), want: int64(7)},
+		{name: "Sad Flow - invalid array literal is a string", typeName: "_INT4", value: []byte("{1,2"), want: "{1,2"},
+		{name: "Sad Flow - invalid json is a string", typeName: "JSON", value: []byte("{"), want: "{"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, decodePostgres(tt.typeName, tt.value))
+		})
+	}
+}
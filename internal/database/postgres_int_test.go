@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"exmple.com/database-query-server/pkg/types"
 	"github.com/ory/dockertest/v3"
 	"github.com/ory/dockertest/v3/docker"
 	"github.com/stretchr/testify/assert"
@@ -22,13 +23,13 @@ var testRepo ClientInterface
 func TestMain(m *testing.M) {
 	// uses a sensible default on windows (tcp/http) and linux/osx (socket)
 	pool, err := dockertest.NewPool("")
-	if err != nil {
-		log.Fatalf("Could not construct pool: %s", err)
+	if err == nil {
+		err = pool.Client.Ping()
 	}
-
-	err = pool.Client.Ping()
 	if err != nil {
-		log.Fatalf("Could not connect to Docker: %s", err)
+		// the SQLite and sqlmock tests don't need Docker, only the Postgres integration tests are skipped
+		log.Printf("Could not connect to Docker, skipping Postgres integration tests: %s", err)
+		os.Exit(m.Run())
 	}
 
 	// pulls an image, creates a container based on it and runs it
@@ -99,14 +100,34 @@ func createTables() error {
 	return nil
 }
 
+// requireDocker skips Postgres integration tests when TestMain couldn't start the container
+func requireDocker(t *testing.T) {
+	t.Helper()
+	if testRepo == nil {
+		t.Skip("Docker is not available")
+	}
+}
+
 func Test_pingDB(t *testing.T) {
+	requireDocker(t)
 	err := db.Ping()
 	if err != nil {
 		t.Error("can't ping DB")
 	}
 }
 
+func TestPostgress_Status(t *testing.T) {
+	requireDocker(t)
+	got, err := testRepo.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+	assert.NotEmpty(t, got.ServerVersion)
+	assert.GreaterOrEqual(t, got.Pool.OpenConnections, 1)
+}
+
 func TestPostgress_ExecPrepared(t *testing.T) {
+	requireDocker(t)
 	insertParams := []any{
 		3,
 		"Joe",
@@ -130,17 +151,9 @@ func TestPostgress_ExecPrepared(t *testing.T) {
 	updateStmt := "UPDATE public.usersTest SET firt_name = $1, email = $2, updated_at = $3 WHERE id = $4"
 	updateStmtErr := "UPDATE public.usersTest SET firt_name = $1, email = $2, updated_at = $3, WHERE id = $4"
 
-	var expected []map[string]interface{}
-	row := make(map[string]interface{})
-	row["message"] = "success"
-	row["rowsAffected"] = int64(1)
-	expected = append(expected, row)
-
-	var expectedMultInsert []map[string]interface{}
-	row1 := make(map[string]interface{})
-	row1["message"] = "success"
-	row1["rowsAffected"] = int64(2)
-	expectedMultInsert = append(expectedMultInsert, row1)
+	columns := []types.Column{{Name: "message"}, {Name: "rowsAffected"}}
+	expected := &types.ResultSet{Columns: columns, Rows: [][]any{{"success", int64(1)}}}
+	expectedMultInsert := &types.ResultSet{Columns: columns, Rows: [][]any{{"success", int64(2)}}}
 
 	multipleRowsParams := []any{}
 
@@ -148,7 +161,7 @@ func TestPostgress_ExecPrepared(t *testing.T) {
 		name      string
 		statement string
 		params    []any
-		want      []map[string]interface{}
+		want      *types.ResultSet
 		wantErr   bool
 	}{
 		// TODO add more tests
@@ -178,52 +191,29 @@ func TestPostgress_ExecPrepared(t *testing.T) {
 }
 
 func TestPostgress_ExecQuery(t *testing.T) {
+	requireDocker(t)
 	paramsEmpty := map[string]any{}
 	params := map[string]any{}
-	params["id"] = 1
+	params["1"] = 1
 	query := "SELECT id, firt_name, last_name FROM public.usersTest;"
 	querySelectById := "SELECT id, firt_name, last_name FROM public.usersTest WHERE id = $1"
 	queryErr := "SELECT id, firt_name, last_name FROM public.UNDEFINED WHERE id = $1"
 
-	var expectedSelectById []map[string]interface{}
-	row := make(map[string]interface{})
-	row["firt_name"] = "TestUser1"
-	row["id"] = int64(1)
-	row["last_name"] = "Test surname"
-	expectedSelectById = append(expectedSelectById, row)
-
-	var expectedSelectAll []map[string]interface{}
-	row1 := make(map[string]interface{})
-	row1["firt_name"] = "TestUser1"
-	row1["id"] = int64(1)
-	row1["last_name"] = "Test surname"
-
-	row2 := make(map[string]interface{})
-	row2["firt_name"] = "TestUser2"
-	row2["id"] = int64(2)
-	row2["last_name"] = "Surname 2"
-
-	row3 := make(map[string]interface{})
-	row3["firt_name"] = "Joe - UPDATED"
-	row3["id"] = int64(3)
-	row3["last_name"] = "Blogs"
-
-	row4 := make(map[string]interface{})
-	row4["firt_name"] = "MrPawel"
-	row4["id"] = int64(4)
-	row4["last_name"] = "My surname"
-
-	row5 := make(map[string]interface{})
-	row5["firt_name"] = "Mr. X"
-	row5["id"] = int64(5)
-	row5["last_name"] = "xXx"
-	expectedSelectAll = append(expectedSelectAll, row1, row2, row3, row4, row5)
+	columns := []types.Column{{Name: "id", Type: "INT4"}, {Name: "firt_name", Type: "VARCHAR"}, {Name: "last_name", Type: "VARCHAR"}}
+	expectedSelectById := &types.ResultSet{Columns: columns, Rows: [][]any{{int64(1), "TestUser1", "Test surname"}}}
+	expectedSelectAll := &types.ResultSet{Columns: columns, Rows: [][]any{
+		{int64(1), "TestUser1", "Test surname"},
+		{int64(2), "TestUser2", "Surname 2"},
+		{int64(3), "Joe - UPDATED", "Blogs"},
+		{int64(4), "MrPawel", "My surname"},
+		{int64(5), "Mr. X", "xXx"},
+	}}
 
 	tests := []struct {
 		name    string
 		query   string
 		params  map[string]any
-		want    []map[string]interface{}
+		want    *types.ResultSet
 		wantErr bool
 	}{
 		// TODO keep an eye on SELECT * FROM test, as it could cause issues
@@ -234,7 +224,7 @@ func TestPostgress_ExecQuery(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, gotErr := testRepo.ExecQuery(context.Background(), tt.query, tt.params)
+			got, gotErr := testRepo.ExecQuery(context.Background(), tt.query, tt.params, 0)
 			if gotErr != nil {
 				if !tt.wantErr {
 					t.Errorf("ExecQuery() failed: %v", gotErr)
@@ -252,6 +242,7 @@ func TestPostgress_ExecQuery(t *testing.T) {
 }
 
 func TestPostgress_GetSchema(t *testing.T) {
+	requireDocker(t)
 	tables := []string{
 		"userstest",
 	}
@@ -260,58 +251,34 @@ func TestPostgress_GetSchema(t *testing.T) {
 		"userstestXXXX",
 	}
 
-	var expectedEmpty []map[string]interface{}
-
-	var expected []map[string]interface{}
-	row := make(map[string]interface{})
-	row["column_name"] = "id"
-	row["data_type"] = "integer"
-	row["character_maximum_length"] = interface{}(nil)
-
-	row1 := make(map[string]interface{})
-	row1["column_name"] = "firt_name"
-	row1["data_type"] = "character varying"
-	row1["character_maximum_length"] = int64(255)
-
-	row2 := make(map[string]interface{})
-	row2["column_name"] = "last_name"
-	row2["data_type"] = "character varying"
-	row2["character_maximum_length"] = int64(255)
-
-	row3 := make(map[string]interface{})
-	row3["column_name"] = "email"
-	row3["data_type"] = "character varying"
-	row3["character_maximum_length"] = int64(255)
-
-	row4 := make(map[string]interface{})
-	row4["column_name"] = "password"
-	row4["data_type"] = "character varying"
-	row4["character_maximum_length"] = int64(60)
-
-	row5 := make(map[string]interface{})
-	row5["column_name"] = "is_admin"
-	row5["data_type"] = "boolean"
-	row5["character_maximum_length"] = interface{}(nil)
-
-	row6 := make(map[string]interface{})
-	row6["column_name"] = "created_at"
-	row6["data_type"] = "timestamp without time zone"
-	row6["character_maximum_length"] = interface{}(nil)
-
-	row7 := make(map[string]interface{})
-	row7["column_name"] = "updated_at"
-	row7["data_type"] = "timestamp without time zone"
-	row7["character_maximum_length"] = interface{}(nil)
-	expected = append(expected, row, row1, row2, row3, row4, row5, row6, row7)
+	column := func(name, dataType string, position int, length *int64) types.ColumnSchema {
+		return types.ColumnSchema{Name: name, DataType: dataType, Nullable: true, Position: position, MaxLength: length}
+	}
+	admin, length, password := "false", int64(255), int64(60)
+	id := column("id", "integer", 1, nil)
+	id.Nullable = false
+	isAdmin := column("is_admin", "boolean", 6, nil)
+	isAdmin.Default = &admin
+	expected := []types.TableSchema{{Schema: "public", Name: "userstest", Columns: []types.ColumnSchema{
+		id,
+		column("firt_name", "character varying", 2, &length),
+		column("last_name", "character varying", 3, &length),
+		column("email", "character varying", 4, &length),
+		column("password", "character varying", 5, &password),
+		isAdmin,
+		column("created_at", "timestamp without time zone", 7, nil),
+		column("updated_at", "timestamp without time zone", 8, nil),
+	}}}
 
 	tests := []struct {
 		name    string
 		tables  []string
-		want    []map[string]interface{}
+		want    []types.TableSchema
 		wantErr bool
 	}{
 		{name: "Happy Flow get_schema - GET userstest SCHEMA", tables: tables, want: expected, wantErr: false},
-		{name: "Happy Flow get_schema - GET schema for table that doesn't exist", tables: tablesDoesntExist, want: expectedEmpty, wantErr: false},
+		{name: "Happy Flow get_schema - GET schema of a qualified table", tables: []string{"public.userstest"}, want: expected, wantErr: false},
+		{name: "Happy Flow get_schema - GET schema for table that doesn't exist", tables: tablesDoesntExist, want: nil, wantErr: false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -332,3 +299,24 @@ func TestPostgress_GetSchema(t *testing.T) {
 		})
 	}
 }
+
+func TestPostgress_DescribeTables(t *testing.T) {
+	requireDocker(t)
+
+	got, err := testRepo.DescribeTables(context.Background(), []string{"userstest", "public.userstestXXXX"})
+	if err != nil {
+		t.Fatalf("DescribeTables() failed: %v", err)
+	}
+	if !assert.Len(t, got, 1) {
+		return
+	}
+	users := got[0]
+	assert.Equal(t, "public", users.Schema)
+	assert.Equal(t, "userstest", users.Name)
+	if assert.Len(t, users.Columns, 8) {
+		assert.Equal(t, types.ColumnSchema{Name: "id", DataType: "integer", Position: 1}, users.Columns[0])
+		assert.Equal(t, "character varying(255)", users.Columns[1].DataType)
+		assert.Equal(t, int64(255), *users.Columns[1].MaxLength)
+		assert.Equal(t, "false", *users.Columns[5].Default)
+	}
+}
@@ -2,6 +2,11 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"exmple.com/database-query-server/pkg/types"
 )
 
 type PostgresClientMock struct {
@@ -16,26 +21,80 @@ func NewPostgresClientMock(mockSQLTable []map[string]interface{}, simulateFailur
 	}, nil
 }
 
-func (c *PostgresClientMock) ExecQuery(ctx context.Context, query string, params map[string]any) ([]map[string]interface{}, error) {
+func (c *PostgresClientMock) ExecQuery(ctx context.Context, query string, params map[string]any, maxRows int) (*types.ResultSet, error) {
 	//TODO handle multiple rows
-	db := c.mockSQLTable[0]
-	var result []map[string]interface{}
-	result = append(result, db)
-	return result, nil
+	return types.ResultFromMaps(c.mockSQLTable[:1]), nil
 }
 
-func (c *PostgresClientMock) ExecPrepared(ctx context.Context, statement string, params []any) ([]map[string]interface{}, error) {
-	//TODO handle multiple rows
-	db := c.mockSQLTable[0]
-	var result []map[string]interface{}
-	result = append(result, db)
-	return result, nil
+// OpenCursor returns a cursor over every row of the mock table
+func (c *PostgresClientMock) OpenCursor(ctx context.Context, query string, params map[string]any) (Cursor, error) {
+	result := types.ResultFromMaps(c.mockSQLTable)
+	read := func(ctx context.Context, n int) (*types.ResultSet, error) {
+		n = min(n, len(result.Rows))
+		page := &types.ResultSet{Columns: result.Columns, Rows: result.Rows[:n]}
+		result.Rows = result.Rows[n:]
+		return page, nil
+	}
+	return &rowCursor{read: read, done: func() {}, end: func() {}, cancel: func() {}}, nil
 }
 
-func (c *PostgresClientMock) GetSchema(ctx context.Context, tables []string) ([]map[string]interface{}, error) {
+func (c *PostgresClientMock) ExecPrepared(ctx context.Context, statement string, params []any) (*types.ResultSet, error) {
 	//TODO handle multiple rows
-	db := c.mockSQLTable[0]
-	var result []map[string]interface{}
-	result = append(result, db)
-	return result, nil
+	return types.ResultFromMaps(c.mockSQLTable[:1]), nil
+}
+
+// GetSchema describes every table as public.<table> with a column per row of the mock table, the rows hold the
+// column_name, data_type and character_maximum_length of the column
+func (c *PostgresClientMock) GetSchema(ctx context.Context, tables []string) ([]types.TableSchema, error) {
+	var described []types.TableSchema
+	for _, table := range tables {
+		t := types.TableSchema{Schema: "public", Name: table}
+		for i, row := range c.mockSQLTable {
+			column := types.ColumnSchema{Nullable: true, Position: i + 1}
+			column.Name, _ = row["column_name"].(string)
+			column.DataType, _ = row["data_type"].(string)
+			if length, ok := row["character_maximum_length"].(int64); ok {
+				column.MaxLength = &length
+			}
+			t.Columns = append(t.Columns, column)
+		}
+		described = append(described, t)
+	}
+	return described, nil
+}
+
+// DescribeTables describes the tables like GetSchema, without keys
+func (c *PostgresClientMock) DescribeTables(ctx context.Context, tables []string) ([]types.TableSchema, error) {
+	return c.GetSchema(ctx, tables)
+}
+
+// TableNames returns no tables, the mock has no catalog
+func (c *PostgresClientMock) TableNames(ctx context.Context, schema string) ([]types.TableName, error) {
+	return nil, nil
+}
+
+// ListSchemas returns the public schema, or a connection error when simulateFailure is set
+func (c *PostgresClientMock) ListSchemas(ctx context.Context, filter CatalogFilter) ([]types.SchemaInfo, error) {
+	if c.simulateFailure {
+		return nil, fmt.Errorf("dial tcp 127.0.0.1:5432: connect: connection refused")
+	}
+	return []types.SchemaInfo{{Name: "public", Owner: "postgres"}}, nil
+}
+
+// ListTables returns no tables, the mock has no catalog
+func (c *PostgresClientMock) ListTables(ctx context.Context, schema string, filter CatalogFilter) ([]types.TableInfo, error) {
+	return nil, nil
+}
+
+// Status returns fixed pool statistics, or a connection error when simulateFailure is set
+func (c *PostgresClientMock) Status(ctx context.Context) (*Status, error) {
+	if c.simulateFailure {
+		return nil, fmt.Errorf("dial tcp 127.0.0.1:5432: connect: connection refused")
+	}
+	return &Status{
+		Pool:          sql.DBStats{MaxOpenConnections: 10, OpenConnections: 2, InUse: 1, Idle: 1},
+		PingLatency:   1500 * time.Microsecond,
+		PingedAt:      time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		ServerVersion: "16.4",
+	}, nil
 }
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/lib/pq"
+)
+
+// pgFKActions names the confupdtype and confdeltype codes of pg_constraint
+var pgFKActions = map[string]string{"a": "NO ACTION", "r": "RESTRICT", "c": "CASCADE", "n": "SET NULL", "d": "SET DEFAULT"}
+
+// DescribeTables returns the columns, constraints, indexes and comments of the specified tables or views, a name
+// is looked up in the search_path unless it's qualified with a schema ie. sales.orders. Unknown tables are left out.
+func (s *Postgress) DescribeTables(ctx context.Context, tables []string) ([]types.TableSchema, error) {
+	var described []types.TableSchema
+	for _, table := range tables {
+		oid, t, err := s.lookupTable(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			continue
+		}
+
+		if t.Columns, err = s.describeColumns(ctx, oid); err != nil {
+			return nil, fmt.Errorf("failed to describe columns of %q: %w", table, err)
+		}
+		if err := s.describeConstraints(ctx, oid, t); err != nil {
+			return nil, fmt.Errorf("failed to describe constraints of %q: %w", table, err)
+		}
+		if t.Indexes, err = s.describeIndexes(ctx, oid); err != nil {
+			return nil, fmt.Errorf("failed to describe indexes of %q: %w", table, err)
+		}
+		described = append(described, *t)
+	}
+	return described, nil
+}
+
+// lookupTable returns the oid, schema, name and comment of a table or view, the table is nil when it doesn't exist
+func (s *Postgress) lookupTable(ctx context.Context, table string) (int64, *types.TableSchema, error) {
+	schema, name := splitTable(table)
+	var oid int64
+	t := &types.TableSchema{}
+	err := s.Pg.QueryRowContext(ctx, `SELECT c.oid, n.nspname, c.relname, COALESCE(obj_description(c.oid, 'pg_class'), '')
+	FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+	WHERE c.relname = $2 AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+	AND (n.nspname = $1 OR ($1 = '' AND pg_table_is_visible(c.oid)))`, schema, name).Scan(&oid, &t.Schema, &t.Name, &t.Comment)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to look up table %q: %w", table, err)
+	}
+	return oid, t, nil
+}
+
+// TableNames returns the tables and views of a schema, or the ones in the search_path apart from the system catalogs
+func (s *Postgress) TableNames(ctx context.Context, schema string) ([]types.TableName, error) {
+	rows, err := s.Pg.QueryContext(ctx, `SELECT n.nspname, c.relname
+	FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+	WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f') AND (n.nspname = $1 OR ($1 = '' AND pg_table_is_visible(c.oid)
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')))
+	ORDER BY n.nspname, c.relname`, schema)
+	if err != nil {
+		return nil, err
+	}
+	return scanTableNames(rows)
+}
+
+// ListSchemas returns the schemas apart from the system catalogs, toast and temporary schemas
+func (s *Postgress) ListSchemas(ctx context.Context, filter CatalogFilter) ([]types.SchemaInfo, error) {
+	pattern, err := filter.patternRegexp()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.Pg.QueryContext(ctx, `SELECT n.nspname, pg_get_userbyid(n.nspowner),
+		(SELECT count(*) FROM pg_class c WHERE c.relnamespace = n.oid AND c.relkind IN ('r', 'p', 'v', 'm', 'f')),
+		COALESCE(obj_description(n.oid, 'pg_namespace'), '')
+	FROM pg_namespace n
+	WHERE n.nspname NOT LIKE 'pg\_%' AND n.nspname <> 'information_schema' AND ($1 = '' OR n.nspname ~ $1)
+	ORDER BY n.nspname`+filter.limitClause(), pattern)
+	if err != nil {
+		return nil, err
+	}
+	return scanSchemaInfos(rows)
+}
+
+// pgRelationType is the ListTables type of a pg_class row
+const pgRelationType = `CASE c.relkind WHEN 'v' THEN 'view' WHEN 'm' THEN 'materialized view' WHEN 'f' THEN 'foreign table' ELSE 'table' END`
+
+// ListTables returns the tables and views of a schema, or the ones in the search_path apart from the system catalogs.
+// The rows are the planner's reltuples, -1 until the table is first vacuumed or analyzed. The sizes are only
+// computed for the rows of the page.
+func (s *Postgress) ListTables(ctx context.Context, schema string, filter CatalogFilter) ([]types.TableInfo, error) {
+	pattern, err := filter.patternRegexp()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.Pg.QueryContext(ctx, `SELECT n.nspname, c.relname, `+pgRelationType+`,
+		CASE WHEN c.relkind IN ('r', 'm') AND c.reltuples >= 0 THEN c.reltuples::bigint END,
+		CASE WHEN c.relkind IN ('r', 'p', 'm') THEN pg_total_relation_size(c.oid) END,
+		COALESCE(obj_description(c.oid, 'pg_class'), '')
+	FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+	WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f') AND (n.nspname = $1 OR ($1 = '' AND pg_table_is_visible(c.oid)
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')))
+		AND ($2 = '' OR c.relname ~ $2) AND ($3 = '' OR `+pgRelationType+` = $3)
+	ORDER BY n.nspname, c.relname`+filter.limitClause(), schema, pattern, filter.Type)
+	if err != nil {
+		return nil, err
+	}
+	return scanTableInfos(rows)
+}
+
+// describeColumns returns the columns of a table, the length of varchar and char columns comes from their typmod
+func (s *Postgress) describeColumns(ctx context.Context, oid int64) ([]types.ColumnSchema, error) {
+	rows, err := s.Pg.QueryContext(ctx, `SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+		pg_get_expr(d.adbin, d.adrelid), a.attnum, COALESCE(col_description(a.attrelid, a.attnum), ''),
+		CASE WHEN a.atttypid IN ('bpchar'::regtype, 'varchar'::regtype) AND a.atttypmod > 0 THEN a.atttypmod - 4 END
+	FROM pg_attribute a LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+	WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped
+	ORDER BY a.attnum`, oid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []types.ColumnSchema
+	for rows.Next() {
+		var c types.ColumnSchema
+		var def sql.NullString
+		var length sql.NullInt64
+		if err := rows.Scan(&c.Name, &c.DataType, &c.Nullable, &def, &c.Position, &c.Comment, &length); err != nil {
+			return nil, err
+		}
+		if def.Valid {
+			c.Default = &def.String
+		}
+		if length.Valid {
+			c.MaxLength = &length.Int64
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// describeConstraints adds the primary key, foreign keys, unique and check constraints of a table
+func (s *Postgress) describeConstraints(ctx context.Context, oid int64, t *types.TableSchema) error {
+	// conkey and confkey are in key order, their column names keep it
+	rows, err := s.Pg.QueryContext(ctx, `SELECT con.conname, con.contype, pg_get_constraintdef(con.oid, true),
+		ARRAY(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, i)
+			JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum ORDER BY k.i)::text[],
+		COALESCE(fn.nspname, ''), COALESCE(fc.relname, ''),
+		ARRAY(SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, i)
+			JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum ORDER BY k.i)::text[],
+		con.confupdtype, con.confdeltype
+	FROM pg_constraint con
+	LEFT JOIN pg_class fc ON fc.oid = con.confrelid
+	LEFT JOIN pg_namespace fn ON fn.oid = fc.relnamespace
+	WHERE con.conrelid = $1 AND con.contype IN ('p', 'f', 'u', 'c')
+	ORDER BY con.conname`, oid)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, kind, definition, refSchema, refTable, onUpdate, onDelete string
+		var columns, refColumns pq.StringArray
+		if err := rows.Scan(&name, &kind, &definition, &columns, &refSchema, &refTable, &refColumns, &onUpdate, &onDelete); err != nil {
+			return err
+		}
+		switch kind {
+		case "p":
+			t.PrimaryKey = columns
+		case "f":
+			t.ForeignKeys = append(t.ForeignKeys, types.ForeignKey{
+				Name:       name,
+				Columns:    columns,
+				RefSchema:  refSchema,
+				RefTable:   refTable,
+				RefColumns: refColumns,
+				OnUpdate:   pgFKActions[onUpdate],
+				OnDelete:   pgFKActions[onDelete],
+			})
+		case "u":
+			t.Uniques = append(t.Uniques, types.UniqueConstraint{Name: name, Columns: columns})
+		case "c":
+			t.Checks = append(t.Checks, types.CheckConstraint{Name: name, Definition: definition})
+		}
+	}
+	return rows.Err()
+}
+
+// describeIndexes returns the indexes of a table with their CREATE INDEX statements, expression keys are written
+// as their expression
+func (s *Postgress) describeIndexes(ctx context.Context, oid int64) ([]types.Index, error) {
+	rows, err := s.Pg.QueryContext(ctx, `SELECT i.relname, x.indisunique, x.indisprimary, pg_get_indexdef(x.indexrelid),
+		ARRAY(SELECT pg_get_indexdef(x.indexrelid, k, true) FROM generate_series(1, x.indnkeyatts) AS k ORDER BY k)::text[]
+	FROM pg_index x JOIN pg_class i ON i.oid = x.indexrelid
+	WHERE x.indrelid = $1
+	ORDER BY i.relname`, oid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []types.Index
+	for rows.Next() {
+		var idx types.Index
+		var columns pq.StringArray
+		if err := rows.Scan(&idx.Name, &idx.Unique, &idx.Primary, &idx.Definition, &columns); err != nil {
+			return nil, err
+		}
+		idx.Columns = columns
+		indexes = append(indexes, idx)
+	}
+	return indexes, rows.Err()
+}
@@ -2,12 +2,16 @@ package database_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"testing"
+	"time"
 
 	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/pkg/types"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,32 +28,86 @@ func TestExecQuery_Happy_Path_Select_All(t *testing.T) {
 	pg := &database.Postgress{Pg: db}
 
 	ctx := context.Background()
-	query := "SELECT * FROM users;"
+	query := "SELECT * FROM users WHERE name IN ($1, $2);"
 
-	// expected rows to return
-	rows := sqlmock.NewRows([]string{"id", "name"}).
-		AddRow(1, "Alice").
-		AddRow(2, "Bob")
+	// expected rows to return, the columns aren't in alphabetical order
+	rows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("name").OfType("TEXT", ""),
+		sqlmock.NewColumn("id").OfType("INT4", int64(0)),
+	).
+		AddRow("Alice", 1).
+		AddRow("Bob", 2)
 
 	// query params
 	par := make(map[string]any)
 	par["1"] = "Alice"
 	par["2"] = "Bob"
 
-	var expected []map[string]interface{}
-	row := make(map[string]interface{})
-	row["id"] = int64(1)
-	row["name"] = "Alice"
+	expected := &types.ResultSet{
+		Columns: []types.Column{{Name: "name", Type: "TEXT"}, {Name: "id", Type: "INT4"}},
+		Rows:    [][]any{{"Alice", int64(1)}, {"Bob", int64(2)}},
+	}
+
+	// queries run in a READ ONLY transaction that is always rolled back
+	mock.ExpectBegin()
+	// parameters are bound in the order of their numeric keys
+	mock.ExpectPrepare(regexp.QuoteMeta(query)).ExpectQuery().WithArgs("Alice", "Bob").WillReturnRows(rows)
+	mock.ExpectRollback()
 
-	row2 := make(map[string]interface{})
-	row2["id"] = int64(2)
-	row2["name"] = "Bob"
+	result, err := pg.ExecQuery(ctx, query, par, 0)
+	if err != nil {
+		t.Errorf("ExecQuery() failed: %v", err)
+		return
+	}
 
-	expected = append(expected, row, row2)
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	assert.EqualValues(t, expected, result)
+}
 
-	mock.ExpectPrepare(regexp.QuoteMeta(query)).ExpectQuery().WithArgs().WillReturnRows(rows)
+func TestExecQuery_Happy_Path_Decodes_Types(t *testing.T) {
 
-	result, err := pg.ExecQuery(ctx, query, par)
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("ExecQuery() failed: %v", err)
+		return
+	}
+	defer db.Close()
+
+	pg := &database.Postgress{Pg: db}
+
+	ctx := context.Background()
+	query := "SELECT price, attrs, tags, id, photo, wait FROM products"
+
+	// lib/pq returns the text of these types as raw bytes, bytea is already decoded
+	rows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("price").OfType("NUMERIC", []byte{}),
+		sqlmock.NewColumn("attrs").OfType("JSONB", []byte{}),
+		sqlmock.NewColumn("tags").OfType("_INT4", []byte{}),
+		sqlmock.NewColumn("id").OfType("UUID", []byte{}),
+		sqlmock.NewColumn("photo").OfType("BYTEA", []byte{}),
+		sqlmock.NewColumn("wait").OfType("INTERVAL", []byte{}),
+	).
+		AddRow([]byte("10.50"), []byte(`{"color":"red"}`), []byte("{1,2}"), []byte("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"), []byte{1, 2}, []byte("01:30:00"))
+
+	expected := &types.ResultSet{
+		Columns: []types.Column{
+			{Name: "price", Type: "NUMERIC"}, {Name: "attrs", Type: "JSONB"}, {Name: "tags", Type: "_INT4"},
+			{Name: "id", Type: "UUID"}, {Name: "photo", Type: "BYTEA"}, {Name: "wait", Type: "INTERVAL"},
+		},
+		Rows: [][]any{{
+			json.Number("10.50"), json.RawMessage(`{"color":"red"}`), []any{int64(1), int64(2)},
+			"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", types.Binary{1, 2}, "01:30:00",
+		}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(regexp.QuoteMeta(query)).ExpectQuery().WillReturnRows(rows)
+	mock.ExpectRollback()
+
+	result, err := pg.ExecQuery(ctx, query, nil, 0)
 	if err != nil {
 		t.Errorf("ExecQuery() failed: %v", err)
 		return
@@ -62,6 +120,51 @@ func TestExecQuery_Happy_Path_Select_All(t *testing.T) {
 	assert.EqualValues(t, expected, result)
 }
 
+func TestExecQuery_Happy_Path_Fetches_Limit(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("ExecQuery() failed: %v", err)
+		return
+	}
+	defer db.Close()
+
+	pg := &database.Postgress{Pg: db}
+
+	ctx := context.Background()
+	columns := []*sqlmock.Column{sqlmock.NewColumn("id").OfType("INT4", int64(0))}
+
+	// the rows are fetched from a cursor, Postgres only produces the rows asked for however large the table is
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DECLARE mcp_cursor NO SCROLL CURSOR FOR SELECT id FROM events WHERE kind = $1")).WithArgs("click").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FETCH FORWARD 3 FROM mcp_cursor")).WillReturnRows(sqlmock.NewRowsWithColumnDefinition(columns...).AddRow(1).AddRow(2).AddRow(3))
+	mock.ExpectRollback()
+
+	// EXPLAIN can't be declared as a cursor, it runs as a plain query
+	mock.ExpectBegin()
+	mock.ExpectPrepare(regexp.QuoteMeta("EXPLAIN SELECT id FROM events")).ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).AddRow("Seq Scan on events"))
+	mock.ExpectRollback()
+
+	result, err := pg.ExecQuery(ctx, "SELECT id FROM events WHERE kind = :kind", map[string]any{"kind": "click"}, 3)
+	if err != nil {
+		t.Errorf("ExecQuery() failed: %v", err)
+		return
+	}
+	assert.EqualValues(t, &types.ResultSet{Columns: []types.Column{{Name: "id", Type: "INT4"}}, Rows: [][]any{{int64(1)}, {int64(2)}, {int64(3)}}}, result)
+
+	plan, err := pg.ExecQuery(ctx, "EXPLAIN SELECT id FROM events", nil, 3)
+	if err != nil {
+		t.Errorf("ExecQuery() failed: %v", err)
+		return
+	}
+	assert.EqualValues(t, [][]any{{"Seq Scan on events"}}, plan.Rows)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func TestExecQuery_Sad_Path(t *testing.T) {
 
 	db, mock, err := sqlmock.New()
@@ -74,7 +177,7 @@ func TestExecQuery_Sad_Path(t *testing.T) {
 	pg := &database.Postgress{Pg: db}
 
 	ctx := context.Background()
-	query := "SELECT * FROM users"
+	query := "SELECT * FROM users WHERE name = $1"
 
 	expected := fmt.Errorf("some error")
 
@@ -82,9 +185,11 @@ func TestExecQuery_Sad_Path(t *testing.T) {
 	par := make(map[string]any)
 	par["1"] = "Alice"
 
-	mock.ExpectPrepare(regexp.QuoteMeta(query)).ExpectQuery().WithArgs().WillReturnError(fmt.Errorf("some error"))
+	mock.ExpectBegin()
+	mock.ExpectPrepare(regexp.QuoteMeta(query)).ExpectQuery().WithArgs("Alice").WillReturnError(fmt.Errorf("some error"))
+	mock.ExpectRollback()
 
-	_, err = pg.ExecQuery(ctx, query, par)
+	_, err = pg.ExecQuery(ctx, query, par, 0)
 	if err != nil {
 		assert.EqualValues(t, expected, err)
 		return
@@ -107,17 +212,18 @@ func TestExecQuery_Happy_Path_Query_Will_Not_Return_Rows(t *testing.T) {
 
 	rows := sqlmock.NewRows([]string{})
 
-	var expected []map[string]interface{}
-	row := make(map[string]interface{})
-	row["message"] = "success"
-
-	expected = append(expected, row)
+	expected := &types.ResultSet{
+		Columns: []types.Column{{Name: "message"}},
+		Rows:    [][]any{{"success"}},
+	}
 
 	// query params
 	par := make(map[string]any)
+	mock.ExpectBegin()
 	mock.ExpectPrepare(regexp.QuoteMeta(query)).ExpectQuery().WillReturnRows(rows)
+	mock.ExpectRollback()
 
-	result, err := pg.ExecQuery(ctx, query, par)
+	result, err := pg.ExecQuery(ctx, query, par, 0)
 	if err != nil {
 		t.Errorf("ExecQuery() failed: %v", err)
 		return
@@ -129,6 +235,167 @@ func TestExecQuery_Happy_Path_Query_Will_Not_Return_Rows(t *testing.T) {
 	assert.EqualValues(t, expected, result)
 }
 
+func TestExecQuery_Statement_Timeout(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("ExecQuery() failed: %v", err)
+		return
+	}
+	defer db.Close()
+
+	pg := &database.Postgress{Pg: db}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	query := "SELECT pg_sleep(120)"
+
+	// the deadline is set as statement_timeout in the query's transaction
+	mock.ExpectBegin()
+	mock.ExpectExec(`SET LOCAL statement_timeout = \d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(regexp.QuoteMeta(query)).ExpectQuery().WillReturnError(&pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"})
+	mock.ExpectRollback()
+
+	_, err = pg.ExecQuery(ctx, query, nil, 0)
+	assert.ErrorIs(t, err, database.ErrQueryTimeout)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestExecQuery_Happy_Path_With_Deadline(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("ExecQuery() failed: %v", err)
+		return
+	}
+	defer db.Close()
+
+	pg := &database.Postgress{Pg: db}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	query := "SELECT id FROM users"
+
+	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+
+	expected := &types.ResultSet{
+		Columns: []types.Column{{Name: "id"}},
+		Rows:    [][]any{{int64(1)}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SET LOCAL statement_timeout = \d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(regexp.QuoteMeta(query)).ExpectQuery().WillReturnRows(rows)
+	mock.ExpectRollback()
+
+	result, err := pg.ExecQuery(ctx, query, nil, 0)
+	if err != nil {
+		t.Errorf("ExecQuery() failed: %v", err)
+		return
+	}
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	assert.EqualValues(t, expected, result)
+}
+
+func TestOpenCursor_Happy_Path(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("OpenCursor() failed: %v", err)
+		return
+	}
+	defer db.Close()
+
+	pg := &database.Postgress{Pg: db}
+
+	ctx := context.Background()
+	query := "SELECT id FROM users WHERE name = :name"
+
+	columns := []*sqlmock.Column{sqlmock.NewColumn("id").OfType("INT4", int64(0))}
+
+	// a server side cursor is declared in the READ ONLY transaction, every page is a FETCH with one row read ahead
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DECLARE mcp_cursor NO SCROLL CURSOR FOR SELECT id FROM users WHERE name = $1")).WithArgs("Alice").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FETCH FORWARD 3 FROM mcp_cursor")).WillReturnRows(sqlmock.NewRowsWithColumnDefinition(columns...).AddRow(1).AddRow(2).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta("FETCH FORWARD 2 FROM mcp_cursor")).WillReturnRows(sqlmock.NewRowsWithColumnDefinition(columns...).AddRow(4))
+	mock.ExpectRollback()
+
+	cursor, err := pg.OpenCursor(ctx, query, map[string]any{"name": "Alice"})
+	if err != nil {
+		t.Errorf("OpenCursor() failed: %v", err)
+		return
+	}
+
+	first, more, err := cursor.Fetch(ctx, 2)
+	if err != nil {
+		t.Errorf("Fetch() failed: %v", err)
+		return
+	}
+	assert.EqualValues(t, [][]any{{int64(1)}, {int64(2)}}, first.Rows)
+	assert.True(t, more)
+
+	second, more, err := cursor.Fetch(ctx, 2)
+	if err != nil {
+		t.Errorf("Fetch() failed: %v", err)
+		return
+	}
+	assert.Equal(t, []types.Column{{Name: "id", Type: "INT4"}}, second.Columns)
+	assert.EqualValues(t, [][]any{{int64(3)}, {int64(4)}}, second.Rows)
+	assert.False(t, more)
+
+	cursor.Close()
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestOpenCursor_Statement_Timeout(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("OpenCursor() failed: %v", err)
+		return
+	}
+	defer db.Close()
+
+	pg := &database.Postgress{Pg: db}
+
+	ctx := context.Background()
+	query := "SELECT pg_sleep(120)"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DECLARE mcp_cursor NO SCROLL CURSOR FOR " + query)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FETCH FORWARD 2 FROM mcp_cursor")).WillReturnError(&pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"})
+	mock.ExpectRollback()
+
+	cursor, err := pg.OpenCursor(ctx, query, nil)
+	if err != nil {
+		t.Errorf("OpenCursor() failed: %v", err)
+		return
+	}
+
+	// a failed page closes the cursor
+	_, _, err = cursor.Fetch(ctx, 1)
+	assert.ErrorIs(t, err, database.ErrQueryTimeout)
+	_, _, err = cursor.Fetch(ctx, 1)
+	assert.ErrorIs(t, err, database.ErrCursorClosed)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func TestExecPrepared_Happy_Path(t *testing.T) {
 
 	db, mock, err := sqlmock.New()
@@ -145,11 +412,10 @@ func TestExecPrepared_Happy_Path(t *testing.T) {
 
 	resultMock := sqlmock.NewResult(int64(1), int64(1))
 
-	var expected []map[string]interface{}
-	row := make(map[string]interface{})
-	row["message"] = "success"
-	row["rowsAffected"] = int64(1)
-	expected = append(expected, row)
+	expected := &types.ResultSet{
+		Columns: []types.Column{{Name: "message"}, {Name: "rowsAffected"}},
+		Rows:    [][]any{{"success", int64(1)}},
+	}
 
 	params := []any{
 		1,
@@ -183,44 +449,217 @@ func TestGetSchema_Happy_Path(t *testing.T) {
 	pg := &database.Postgress{Pg: db}
 
 	ctx := context.Background()
-	query := `select column_name, data_type, character_maximum_length from INFORMATION_SCHEMA.COLUMNS where table_name =$1;`
+	query := `select column_name, data_type, is_nullable = 'YES', column_default, ordinal_position, character_maximum_length
+	from INFORMATION_SCHEMA.COLUMNS where table_schema = $1 and table_name = $2 order by ordinal_position;`
+	lookup := `FROM pg_class c JOIN pg_namespace n`
+
+	// customers is in the search_path, sales.customers is another table of the same name and invoices doesn't exist
+	columns := []string{"column_name", "data_type", "nullable", "column_default", "ordinal_position", "character_maximum_length"}
+	prepared := mock.ExpectPrepare(regexp.QuoteMeta(query))
+	mock.ExpectQuery(lookup).WithArgs("", "customers").
+		WillReturnRows(sqlmock.NewRows([]string{"oid", "nspname", "relname", "comment"}).AddRow(16384, "public", "customers", ""))
+	prepared.ExpectQuery().WithArgs("public", "customers").WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("id", "integer", false, "nextval('customers_id_seq'::regclass)", 1, nil).
+		AddRow("customername", "character varying", true, nil, 2, 200))
+	mock.ExpectQuery(lookup).WithArgs("sales", "customers").
+		WillReturnRows(sqlmock.NewRows([]string{"oid", "nspname", "relname", "comment"}).AddRow(16390, "sales", "customers", "sales leads"))
+	prepared.ExpectQuery().WithArgs("sales", "customers").WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("contactname", "character varying", true, nil, 1, 250))
+	mock.ExpectQuery(lookup).WithArgs("", "invoices").
+		WillReturnRows(sqlmock.NewRows([]string{"oid", "nspname", "relname", "comment"}))
+
+	seq, length, length2 := "nextval('customers_id_seq'::regclass)", int64(200), int64(250)
+	expected := []types.TableSchema{
+		{Schema: "public", Name: "customers", Columns: []types.ColumnSchema{
+			{Name: "id", DataType: "integer", Default: &seq, Position: 1},
+			{Name: "customername", DataType: "character varying", Nullable: true, Position: 2, MaxLength: &length},
+		}},
+		{Schema: "sales", Name: "customers", Comment: "sales leads", Columns: []types.ColumnSchema{
+			{Name: "contactname", DataType: "character varying", Nullable: true, Position: 1, MaxLength: &length2},
+		}},
+	}
+
+	result, err := pg.GetSchema(ctx, []string{"customers", "sales.customers", "invoices"})
+	if err != nil {
+		t.Errorf("ExecQuery() failed: %v", err)
+		return
+	}
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	assert.Equal(t, expected, result)
+}
+
+func TestTableNames_Happy_Path(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("TableNames() failed: %v", err)
+		return
+	}
+	defer db.Close()
+
+	pg := &database.Postgress{Pg: db}
+
+	mock.ExpectQuery(`SELECT n.nspname, c.relname`).WithArgs("sales").
+		WillReturnRows(sqlmock.NewRows([]string{"nspname", "relname"}).AddRow("sales", "customers").AddRow("sales", "orders"))
+
+	result, err := pg.TableNames(context.Background(), "sales")
+	if err != nil {
+		t.Errorf("TableNames() failed: %v", err)
+		return
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	assert.Equal(t, []types.TableName{{Schema: "sales", Name: "customers"}, {Schema: "sales", Name: "orders"}}, result)
+}
 
-	// expected rows to return
-	rows := sqlmock.NewRows([]string{"column_name", "data_type", "character_maximum_length"}).
-		AddRow("id", "integer", "null").
-		AddRow("customername", "character varying", 200).
-		AddRow("contactname", "character varying", 250)
+func TestDescribeTables_Happy_Path(t *testing.T) {
 
-	var expected []map[string]interface{}
-	row := make(map[string]interface{})
-	row["column_name"] = "id"
-	row["data_type"] = "integer"
-	row["character_maximum_length"] = "null"
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("DescribeTables() failed: %v", err)
+		return
+	}
+	defer db.Close()
 
-	row2 := make(map[string]interface{})
-	row2["column_name"] = "customername"
-	row2["data_type"] = "character varying"
-	row2["character_maximum_length"] = int64(200)
+	pg := &database.Postgress{Pg: db}
 
-	row3 := make(map[string]interface{})
-	row3["column_name"] = "contactname"
-	row3["data_type"] = "character varying"
-	row3["character_maximum_length"] = int64(250)
-	expected = append(expected, row, row2, row3)
+	// sales.orders is qualified, customers_x doesn't exist
+	mock.ExpectQuery(`FROM pg_class c JOIN pg_namespace n`).WithArgs("sales", "orders").
+		WillReturnRows(sqlmock.NewRows([]string{"oid", "nspname", "relname", "comment"}).AddRow(16384, "sales", "orders", "customer orders"))
+	mock.ExpectQuery(`FROM pg_attribute a`).WithArgs(16384).
+		WillReturnRows(sqlmock.NewRows([]string{"attname", "type", "nullable", "default", "attnum", "comment", "length"}).
+			AddRow("id", "integer", false, "nextval('sales.orders_id_seq'::regclass)", 1, "", nil).
+			AddRow("customer_id", "integer", false, nil, 2, "", nil).
+			AddRow("code", "character varying(20)", true, nil, 3, "order code", 20))
+	mock.ExpectQuery(`FROM pg_constraint con`).WithArgs(16384).
+		WillReturnRows(sqlmock.NewRows([]string{"conname", "contype", "def", "columns", "nspname", "relname", "refcolumns", "upd", "del"}).
+			AddRow("orders_code_key", "u", "UNIQUE (code)", "{code}", "", "", "{}", " ", " ").
+			AddRow("orders_customer_id_fkey", "f", "FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE", "{customer_id}", "public", "customers", "{id}", "a", "c").
+			AddRow("orders_pkey", "p", "PRIMARY KEY (id)", "{id}", "", "", "{}", " ", " ").
+			AddRow("positive", "c", "CHECK (id > 0)", "{id}", "", "", "{}", " ", " "))
+	mock.ExpectQuery(`FROM pg_index x`).WithArgs(16384).
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "indisunique", "indisprimary", "def", "columns"}).
+			AddRow("orders_lower_code", false, false, "CREATE INDEX orders_lower_code ON sales.orders USING btree (lower((code)::text))", `{"lower(code::text)"}`).
+			AddRow("orders_pkey", true, true, "CREATE UNIQUE INDEX orders_pkey ON sales.orders USING btree (id)", "{id}"))
+	mock.ExpectQuery(`FROM pg_class c JOIN pg_namespace n`).WithArgs("", "customers_x").
+		WillReturnRows(sqlmock.NewRows([]string{"oid", "nspname", "relname", "comment"}))
+
+	seq, length := "nextval('sales.orders_id_seq'::regclass)", int64(20)
+	expected := []types.TableSchema{{
+		Schema:  "sales",
+		Name:    "orders",
+		Comment: "customer orders",
+		Columns: []types.ColumnSchema{
+			{Name: "id", DataType: "integer", Default: &seq, Position: 1},
+			{Name: "customer_id", DataType: "integer", Position: 2},
+			{Name: "code", DataType: "character varying(20)", Nullable: true, Position: 3, Comment: "order code", MaxLength: &length},
+		},
+		PrimaryKey: []string{"id"},
+		ForeignKeys: []types.ForeignKey{{Name: "orders_customer_id_fkey", Columns: []string{"customer_id"}, RefSchema: "public",
+			RefTable: "customers", RefColumns: []string{"id"}, OnUpdate: "NO ACTION", OnDelete: "CASCADE"}},
+		Uniques: []types.UniqueConstraint{{Name: "orders_code_key", Columns: []string{"code"}}},
+		Checks:  []types.CheckConstraint{{Name: "positive", Definition: "CHECK (id > 0)"}},
+		Indexes: []types.Index{
+			{Name: "orders_lower_code", Columns: []string{"lower(code::text)"}, Definition: "CREATE INDEX orders_lower_code ON sales.orders USING btree (lower((code)::text))"},
+			{Name: "orders_pkey", Columns: []string{"id"}, Unique: true, Primary: true, Definition: "CREATE UNIQUE INDEX orders_pkey ON sales.orders USING btree (id)"},
+		},
+	}}
+
+	result, err := pg.DescribeTables(context.Background(), []string{"sales.orders", "customers_x"})
+	if err != nil {
+		t.Errorf("DescribeTables() failed: %v", err)
+		return
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	assert.Equal(t, expected, result)
+}
 
-	var tables []string
-	tbl := append(tables, "customers")
+func TestDescribeTables_Sad_Path(t *testing.T) {
 
-	mock.ExpectPrepare(regexp.QuoteMeta(query)).ExpectQuery().WithArgs(tbl[0]).WillReturnRows(rows)
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("DescribeTables() failed: %v", err)
+		return
+	}
+	defer db.Close()
 
-	result, err := pg.GetSchema(ctx, tbl)
+	pg := &database.Postgress{Pg: db}
+
+	mock.ExpectQuery(`FROM pg_class c JOIN pg_namespace n`).WithArgs("", "orders").
+		WillReturnRows(sqlmock.NewRows([]string{"oid", "nspname", "relname", "comment"}).AddRow(16384, "public", "orders", ""))
+	mock.ExpectQuery(`FROM pg_attribute a`).WithArgs(16384).WillReturnError(fmt.Errorf("permission denied"))
+
+	_, err = pg.DescribeTables(context.Background(), []string{"orders"})
+	assert.EqualError(t, err, `failed to describe columns of "orders": permission denied`)
+}
+
+func TestListTables_Happy_Path(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
 	if err != nil {
-		t.Errorf("ExecQuery() failed: %v", err)
+		t.Errorf("ListTables() failed: %v", err)
+		return
+	}
+	defer db.Close()
+
+	pg := &database.Postgress{Pg: db}
+
+	// orders was analyzed, the view has no statistics
+	mock.ExpectQuery(`pg_total_relation_size\(c.oid\)`).WithArgs("sales", "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"nspname", "relname", "type", "rows", "size", "comment"}).
+			AddRow("sales", "big_orders", "view", nil, nil, "").
+			AddRow("sales", "orders", "table", 1200, 245760, "customer orders"))
+
+	rows, size := int64(1200), int64(245760)
+	expected := []types.TableInfo{
+		{Schema: "sales", Name: "big_orders", Type: "view"},
+		{Schema: "sales", Name: "orders", Type: "table", EstimatedRows: &rows, SizeBytes: &size, Comment: "customer orders"},
+	}
+
+	result, err := pg.ListTables(context.Background(), "sales", database.CatalogFilter{})
+	if err != nil {
+		t.Errorf("ListTables() failed: %v", err)
 		return
 	}
-	// we make sure that all expectations were met
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
-	assert.EqualValues(t, expected, result)
+	assert.Equal(t, expected, result)
+}
+
+func TestListSchemas_Happy_Path(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Errorf("ListSchemas() failed: %v", err)
+		return
+	}
+	defer db.Close()
+
+	pg := &database.Postgress{Pg: db}
+
+	// the pattern and the page are applied by the query
+	mock.ExpectQuery(`FROM pg_namespace n .* n.nspname ~ \$1\) ORDER BY n.nspname LIMIT 3 OFFSET 2$`).WithArgs(`^s[^/]*$`).
+		WillReturnRows(sqlmock.NewRows([]string{"nspname", "owner", "tables", "comment"}).
+			AddRow("public", "pg_database_owner", 3, "standard public schema").
+			AddRow("sales", "app", 12, ""))
+
+	result, err := pg.ListSchemas(context.Background(), database.CatalogFilter{Pattern: "s*", Limit: 3, Offset: 2})
+	if err != nil {
+		t.Errorf("ListSchemas() failed: %v", err)
+		return
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	assert.Equal(t, []types.SchemaInfo{
+		{Name: "public", Owner: "pg_database_owner", Tables: 3, Comment: "standard public schema"},
+		{Name: "sales", Owner: "app", Tables: 12},
+	}, result)
 }
@@ -0,0 +1,323 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/pkg/types"
+)
+
+// Shared helpers for the clients built on database/sql drivers
+
+// statusTimeout bounds the ping and version queries of get_connection_status so a hung database can be reported
+const statusTimeout = 5 * time.Second
+
+// ErrQueryTimeout is returned when a query is cancelled because its timeout passed
+var ErrQueryTimeout = errors.New("query timeout")
+
+// preparer is implemented by *sql.DB, *sql.Conn and *sql.Tx, so queries can run inside a transaction
+type preparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// ProgressFunc receives the number of rows scanned so far by the query of a context, it's called for every row
+type ProgressFunc func(rows int)
+
+type progressKey struct{}
+
+// WithProgress returns a context whose queries report the rows they scanned to fn
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// progressFrom returns the ProgressFunc of the context, or nil
+func progressFrom(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return fn
+}
+
+// querier is implemented by *sql.Tx and *sql.Conn
+type querier interface {
+	preparer
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// valueDecoder converts a scanned driver value into a JSON friendly value based on its column type
+type valueDecoder func(column *sql.ColumnType, value interface{}) interface{}
+
+// execQuery executes a query with args in placeholder order and returns up to maxRows rows, decode may be nil
+func execQuery(ctx context.Context, db preparer, query string, args []any, maxRows int, decode valueDecoder) (*types.ResultSet, error) {
+	result, err := queryRows(ctx, db, query, args, maxRows, decode)
+	if err != nil {
+		return nil, timeoutError(ctx, err)
+	}
+	return result, nil
+}
+
+// readOnlyQuery runs the query in a READ ONLY transaction so the database rejects writes however the SQL is written.
+// setup runs first inside the transaction and may be nil, the transaction is always rolled back.
+func readOnlyQuery(ctx context.Context, db *sql.DB, setup func(tx *sql.Tx) error, query string, args []any, maxRows int, decode valueDecoder) (*types.ResultSet, error) {
+	tx, end, err := readOnlyTx(db, setup)(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	return execQuery(ctx, tx, query, args, maxRows, decode)
+}
+
+// beginFunc opens the read only scope a query runs in, ie. a transaction, and returns the function ending it
+type beginFunc func(ctx context.Context) (querier, func(), error)
+
+// readOnlyTx returns the beginFunc of a READ ONLY transaction, setup runs first inside it and may be nil.
+// The transaction is always rolled back.
+func readOnlyTx(db *sql.DB, setup func(tx *sql.Tx) error) beginFunc {
+	return func(ctx context.Context) (querier, func(), error) {
+		tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return nil, nil, timeoutError(ctx, err)
+		}
+		end := func() { tx.Rollback() }
+
+		if setup != nil {
+			if err := setup(tx); err != nil {
+				end()
+				return nil, nil, timeoutError(ctx, err)
+			}
+		}
+		return tx, end, nil
+	}
+}
+
+func queryRows(ctx context.Context, db preparer, query string, args []any, maxRows int, decode valueDecoder) (*types.ResultSet, error) {
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		// Default response for operations that don't return rows
+		// ie. CREATE SCHEMA schema_name OR INSERT INTO schema_name.table etc
+		return &types.ResultSet{
+			Columns: []types.Column{{Name: "message"}},
+			Rows:    [][]any{{"success"}},
+		}, nil
+	}
+	return itterateRows(rows, maxRows, decode, progressFrom(ctx))
+}
+
+// execPrepared executes a statement with the given parameters and returns the number of affected rows
+func execPrepared(ctx context.Context, db *sql.DB, statement string, params []any) (*types.ResultSet, error) {
+	stmt, err := db.PrepareContext(ctx, statement)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.ResultSet{
+		Columns: []types.Column{{Name: "message"}, {Name: "rowsAffected"}},
+		Rows:    [][]any{{"success", rows}},
+	}, nil
+}
+
+// itterateRows scans up to maxRows rows, 0 scans every row, the columns keep the select order and the database type names.
+// Closing the rows early doesn't stop the server, lib/pq and go-sql-driver/mysql read and discard the rest of the result,
+// so the clients cap the rows on the server side as well. progress may be nil.
+func itterateRows(rows *sql.Rows, maxRows int, decode valueDecoder, progress ProgressFunc) (*types.ResultSet, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	result := &types.ResultSet{
+		Columns: resultColumns(columnTypes),
+	}
+	result.Rows, err = scanRows(rows, columnTypes, maxRows, decode, progress)
+	if err != nil {
+		// Query rows will be closed with defer.
+		return nil, err
+	}
+	// If the database is being written to ensure to check for Close
+	// errors that may be returned from the driver. The query may
+	// encounter an auto-commit error and be forced to rollback changes.
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	// Rows.Err will report the last error encountered by Rows.Scan.
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// resultColumns returns the names and database type names of the columns
+func resultColumns(columnTypes []*sql.ColumnType) []types.Column {
+	columns := make([]types.Column, len(columnTypes))
+	for i, ct := range columnTypes {
+		columns[i] = types.Column{Name: ct.Name(), Type: ct.DatabaseTypeName()}
+		if precision, scale, ok := ct.DecimalSize(); ok {
+			columns[i].Precision, columns[i].Scale = int(precision), int(scale)
+		}
+	}
+	return columns
+}
+
+// scanRows scans up to maxRows rows, 0 scans every row, the rows are left open. progress may be nil.
+func scanRows(rows *sql.Rows, columnTypes []*sql.ColumnType, maxRows int, decode valueDecoder, progress ProgressFunc) ([][]any, error) {
+	result := [][]any{}
+	// The system handles dynamic queries, so the results are scanned into a slice of pointers to interface{} variables.
+	for (maxRows <= 0 || len(result) < maxRows) && rows.Next() {
+		values := make([]interface{}, len(columnTypes))
+		pointers := make([]interface{}, len(columnTypes))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			// Check for a scan error.
+			return nil, err
+		}
+		if decode != nil {
+			for i, val := range values {
+				if val != nil {
+					values[i] = decode(columnTypes[i], val)
+				}
+			}
+		}
+		result = append(result, values)
+		if progress != nil {
+			progress(len(result))
+		}
+	}
+	return result, nil
+}
+
+// timeoutError wraps err with ErrQueryTimeout when the context deadline passed, drivers report the cancellation differently
+func timeoutError(ctx context.Context, err error) error {
+	if errors.Is(err, ErrQueryTimeout) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %v", ErrQueryTimeout, err)
+	}
+	return err
+}
+
+// applyPool sets the configured connection pool limits, zero values keep the database/sql defaults
+func applyPool(db *sql.DB, pool config.PoolConfig) {
+	if pool.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+	if pool.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	}
+}
+
+// dbStatus pings the database, then reads the server version with versionQuery and the pool statistics
+func dbStatus(ctx context.Context, db *sql.DB, versionQuery string) (*Status, error) {
+	ctx, cancel := context.WithTimeout(ctx, statusTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := db.PingContext(ctx); err != nil {
+		return nil, err
+	}
+	st := &Status{
+		PingLatency: time.Since(start),
+		PingedAt:    time.Now(),
+	}
+
+	if err := db.QueryRowContext(ctx, versionQuery).Scan(&st.ServerVersion); err != nil {
+		return nil, err
+	}
+	st.Pool = db.Stats()
+	return st, nil
+}
+
+// splitTable splits a schema.table name, schema is empty when the name isn't qualified
+func splitTable(table string) (schema, name string) {
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		return schema, name
+	}
+	return "", table
+}
+
+// scanTableNames reads the schema and name rows of a table listing and closes them
+func scanTableNames(rows *sql.Rows) ([]types.TableName, error) {
+	defer rows.Close()
+	var names []types.TableName
+	for rows.Next() {
+		var t types.TableName
+		if err := rows.Scan(&t.Schema, &t.Name); err != nil {
+			return nil, err
+		}
+		names = append(names, t)
+	}
+	return names, rows.Err()
+}
+
+// scanTableInfos reads the schema, name, type, estimated rows, size and comment rows of a table listing and closes them
+func scanTableInfos(rows *sql.Rows) ([]types.TableInfo, error) {
+	defer rows.Close()
+	var tables []types.TableInfo
+	for rows.Next() {
+		var t types.TableInfo
+		var estimate, size sql.NullInt64
+		if err := rows.Scan(&t.Schema, &t.Name, &t.Type, &estimate, &size, &t.Comment); err != nil {
+			return nil, err
+		}
+		if estimate.Valid {
+			t.EstimatedRows = &estimate.Int64
+		}
+		if size.Valid {
+			t.SizeBytes = &size.Int64
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// scanSchemaInfos reads the name, owner, tables and comment rows of a schema listing and closes them
+func scanSchemaInfos(rows *sql.Rows) ([]types.SchemaInfo, error) {
+	defer rows.Close()
+	var schemas []types.SchemaInfo
+	for rows.Next() {
+		var s types.SchemaInfo
+		if err := rows.Scan(&s.Name, &s.Owner, &s.Tables, &s.Comment); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, rows.Err()
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/internal/sqlparser"
+	"exmple.com/database-query-server/pkg/types"
+	_ "modernc.org/sqlite"
+)
+
+// typeLength matches the length of declared types like VARCHAR(255)
+var typeLength = regexp.MustCompile(`\(\s*(\d+)\s*\)`)
+
+type SQLite struct {
+	DB *sql.DB
+}
+
+// NewSQLiteClient creates a new SQLite client, cfg.DBName is the path of the database file
+func NewSQLiteClient(cfg config.DatabaseConfig) (ClientInterface, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database %q: %w", cfg.Name, err)
+	}
+	applyPool(db, cfg.Pool)
+
+	return &SQLite{
+		DB: db,
+	}, nil
+}
+
+// sqliteDSN returns cfg.DSN when set, otherwise the database file path with options as query parameters ie. _pragma=busy_timeout(5000)
+func sqliteDSN(cfg config.DatabaseConfig) string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
+	if len(cfg.Options) == 0 {
+		return cfg.DBName
+	}
+
+	keys := make([]string, 0, len(cfg.Options))
+	for k := range cfg.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	params := url.Values{}
+	for _, k := range keys {
+		params.Add(k, cfg.Options[k])
+	}
+	return cfg.DBName + "?" + params.Encode()
+}
+
+// ExecQuery executes a query on the SQLite database and returns the result set.
+// SQLite has no READ ONLY transactions, the connection is switched to PRAGMA query_only for the query instead.
+func (s *SQLite) ExecQuery(ctx context.Context, query string, params map[string]any, maxRows int) (*types.ResultSet, error) {
+	query, args, err := sqlparser.Bind(query, params, sqlparser.SQLite)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, end, err := s.readOnly(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+
+	return execQuery(ctx, conn, query, args, maxRows, decodeSQLiteValue)
+}
+
+// OpenCursor starts a query on a query_only connection that is held until the cursor is closed
+func (s *SQLite) OpenCursor(ctx context.Context, query string, params map[string]any) (Cursor, error) {
+	query, args, err := sqlparser.Bind(query, params, sqlparser.SQLite)
+	if err != nil {
+		return nil, err
+	}
+	return openCursor(ctx, s.readOnly, heldRows(query, args, decodeSQLiteValue))
+}
+
+// readOnly is the beginFunc of SQLite queries, a connection is taken from the pool and switched to PRAGMA query_only
+// until it's given back
+func (s *SQLite) readOnly(ctx context.Context) (querier, func(), error) {
+	conn, err := s.DB.Conn(ctx)
+	if err != nil {
+		return nil, nil, timeoutError(ctx, err)
+	}
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+		conn.Close()
+		return nil, nil, timeoutError(ctx, err)
+	}
+	end := func() {
+		// query_only belongs to the connection, it's reset before the connection goes back to the pool
+		if _, err := conn.ExecContext(context.Background(), "PRAGMA query_only = OFF"); err != nil {
+			log.Printf("SQLite failed to reset query_only, discarding the connection: %v", err)
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return conn, end, nil
+}
+
+// decodeSQLiteValue returns BLOB values as types.Binary, the driver returns text as strings
+func decodeSQLiteValue(_ *sql.ColumnType, value interface{}) interface{} {
+	if b, ok := value.([]byte); ok {
+		return types.Binary(b)
+	}
+	return value
+}
+
+// ExecPrepared executes a prepared statement with the given parameters and returns the number of affected rows.
+func (s *SQLite) ExecPrepared(ctx context.Context, statement string, params []any) (*types.ResultSet, error) {
+	return execPrepared(ctx, s.DB, statement, params)
+}
+
+// GetSchema returns the columns of the tables, a name is looked up like SQLite does unless it's qualified with an
+// attached database. SQLite only keeps the declared type, the length is read from it ie. VARCHAR(200).
+func (s *SQLite) GetSchema(ctx context.Context, tables []string) ([]types.TableSchema, error) {
+	var schemas []types.TableSchema
+	for _, table := range tables {
+		t, err := s.lookupTable(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			continue
+		}
+		if err := s.describeColumns(ctx, t); err != nil {
+			return nil, err
+		}
+		t.PrimaryKey = nil
+		schemas = append(schemas, *t)
+	}
+	return schemas, nil
+}
+
+// sqliteTypeLength returns the declared length of types like VARCHAR(255), or nil
+func sqliteTypeLength(dataType string) interface{} {
+	m := typeLength.FindStringSubmatch(dataType)
+	if m == nil || strings.Contains(dataType, ",") {
+		return nil
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return nil
+	}
+	return n
+}
+
+// Status pings the database file and returns the pool statistics and SQLite library version
+func (s *SQLite) Status(ctx context.Context) (*Status, error) {
+	return dbStatus(ctx, s.DB, "SELECT sqlite_version()")
+}
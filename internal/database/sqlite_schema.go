@@ -0,0 +1,362 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"exmple.com/database-query-server/pkg/types"
+)
+
+// checkKeyword matches the CHECK keyword of a constraint and the name of a named one
+var checkKeyword = regexp.MustCompile(`(?i)(?:\bCONSTRAINT\s+("[^"]+"|\S+)\s+)?\bCHECK\s*\(`)
+
+// DescribeTables returns the columns, keys, indexes and check constraints of the specified tables or views, a name
+// is looked up like SQLite does unless it's qualified with an attached database ie. main.orders. SQLite has no
+// comments and only keeps check constraints in the CREATE TABLE statement, they are read from it.
+// Unknown tables are left out.
+func (s *SQLite) DescribeTables(ctx context.Context, tables []string) ([]types.TableSchema, error) {
+	var described []types.TableSchema
+	for _, table := range tables {
+		t, err := s.lookupTable(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		if t == nil {
+			continue
+		}
+
+		var create sql.NullString
+		err = s.DB.QueryRowContext(ctx, `SELECT sql FROM `+sqliteIdent(t.Schema)+`.sqlite_master WHERE name = ?`, t.Name).Scan(&create)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the statement of %q: %w", table, err)
+		}
+		if err := s.describeColumns(ctx, t); err != nil {
+			return nil, fmt.Errorf("failed to describe columns of %q: %w", table, err)
+		}
+		if t.ForeignKeys, err = s.describeForeignKeys(ctx, t.Schema, t.Name); err != nil {
+			return nil, fmt.Errorf("failed to describe foreign keys of %q: %w", table, err)
+		}
+		if err := s.describeIndexes(ctx, t); err != nil {
+			return nil, fmt.Errorf("failed to describe indexes of %q: %w", table, err)
+		}
+		t.Checks = sqliteChecks(create.String)
+		described = append(described, *t)
+	}
+	return described, nil
+}
+
+// lookupTable returns the database and name of a table or view, the table is nil when it doesn't exist.
+// An unqualified name is looked up in temp, main and then the attached databases.
+func (s *SQLite) lookupTable(ctx context.Context, table string) (*types.TableSchema, error) {
+	schema, name := splitTable(table)
+	t := &types.TableSchema{}
+	err := s.DB.QueryRowContext(ctx, `SELECT schema, name FROM pragma_table_list
+	WHERE name = ? AND type IN ('table', 'view') AND (schema = ? OR ? = '')
+	ORDER BY schema <> 'temp', schema <> 'main' LIMIT 1`, name, schema, schema).Scan(&t.Schema, &t.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up table %q: %w", table, err)
+	}
+	return t, nil
+}
+
+// TableNames returns the tables and views of an attached database, main when schema is empty, apart from the
+// sqlite_ internal tables
+func (s *SQLite) TableNames(ctx context.Context, schema string) ([]types.TableName, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT schema, name FROM pragma_table_list
+	WHERE schema = COALESCE(NULLIF(?, ''), 'main') AND type IN ('table', 'view') AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+	ORDER BY name`, schema)
+	if err != nil {
+		return nil, err
+	}
+	return scanTableNames(rows)
+}
+
+// ListSchemas returns the attached databases in the order they were attached, SQLite has no owners or comments
+func (s *SQLite) ListSchemas(ctx context.Context, filter CatalogFilter) ([]types.SchemaInfo, error) {
+	pattern, err := filter.patternGlob()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.QueryContext(ctx, `SELECT d.name, '',
+		(SELECT count(*) FROM pragma_table_list AS t WHERE t.schema = d.name AND t.type IN ('table', 'view')
+			AND t.name NOT LIKE 'sqlite\_%' ESCAPE '\'), ''
+	FROM pragma_database_list AS d
+	WHERE ? = '' OR d.name GLOB ?
+	ORDER BY d.seq`+filter.limitClause(), pattern, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return scanSchemaInfos(rows)
+}
+
+// ListTables returns the tables and views of an attached database, main when schema is empty. The size is read from
+// the dbstat pages of the table and its indexes, the rows are the sqlite_stat1 estimate written by ANALYZE. Both are
+// only read for the rows of the page.
+func (s *SQLite) ListTables(ctx context.Context, schema string, filter CatalogFilter) ([]types.TableInfo, error) {
+	if schema == "" {
+		schema = "main"
+	}
+	pattern, err := filter.patternGlob()
+	if err != nil {
+		return nil, err
+	}
+	stat := "NULL"
+	var analyzed int
+	err = s.DB.QueryRowContext(ctx, `SELECT count(*) FROM pragma_table_list WHERE schema = ? AND name = 'sqlite_stat1'`, schema).Scan(&analyzed)
+	if err != nil {
+		return nil, err
+	}
+	if analyzed > 0 {
+		stat = `(SELECT max(CAST(st.stat AS INTEGER)) FROM ` + sqliteIdent(schema) + `.sqlite_stat1 AS st WHERE st.tbl = t.name)`
+	}
+	rows, err := s.DB.QueryContext(ctx, `SELECT t.schema, t.name, t.type,
+		CASE WHEN t.type = 'table' THEN `+stat+` END,
+		CASE WHEN t.type = 'table' THEN (SELECT sum(d.pgsize) FROM dbstat(t.schema) AS d
+			JOIN `+sqliteIdent(schema)+`.sqlite_master AS m ON m.name = d.name WHERE m.tbl_name = t.name) END,
+		''
+	FROM (SELECT schema, name, type FROM pragma_table_list
+		WHERE schema = ? AND type IN ('table', 'view') AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+			AND (? = '' OR name GLOB ?) AND (? = '' OR type = ?)
+		ORDER BY name`+filter.limitClause()+`) AS t
+	ORDER BY t.name`, schema, pattern, pattern, filter.Type, filter.Type)
+	if err != nil {
+		return nil, err
+	}
+	return scanTableInfos(rows)
+}
+
+// sqliteIdent quotes the name of an attached database
+func sqliteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// describeColumns adds the columns and the primary key of a table, pk is the position of a column in the key
+func (s *SQLite) describeColumns(ctx context.Context, t *types.TableSchema) error {
+	rows, err := s.DB.QueryContext(ctx, `SELECT cid, name, type, "notnull", dflt_value, pk FROM pragma_table_info(?, ?) ORDER BY cid`, t.Name, t.Schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	keys := map[int]string{}
+	for rows.Next() {
+		var c types.ColumnSchema
+		var notNull bool
+		var def sql.NullString
+		var pk int
+		if err := rows.Scan(&c.Position, &c.Name, &c.DataType, &notNull, &def, &pk); err != nil {
+			return err
+		}
+		c.Position++
+		// primary key columns are reported nullable, a rowid alias never is and other keys only by a legacy quirk
+		c.Nullable = !notNull && pk == 0
+		if def.Valid {
+			c.Default = &def.String
+		}
+		if n, ok := sqliteTypeLength(c.DataType).(int64); ok {
+			c.MaxLength = &n
+		}
+		if pk > 0 {
+			keys[pk] = c.Name
+		}
+		t.Columns = append(t.Columns, c)
+	}
+	for i := 1; i <= len(keys); i++ {
+		t.PrimaryKey = append(t.PrimaryKey, keys[i])
+	}
+	return rows.Err()
+}
+
+// describeForeignKeys returns the foreign keys of a table, a reference without columns is to the primary key
+// of the referenced table
+func (s *SQLite) describeForeignKeys(ctx context.Context, schema, table string) ([]types.ForeignKey, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT id, "table", "from", "to", on_update, on_delete
+	FROM pragma_foreign_key_list(?, ?) ORDER BY id, seq`, table, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []types.ForeignKey
+	var implicit []int // foreign keys referencing the primary key
+	last := -1
+	for rows.Next() {
+		var id int
+		var refTable, from, onUpdate, onDelete string
+		var to sql.NullString
+		if err := rows.Scan(&id, &refTable, &from, &to, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+		if id != last {
+			fks = append(fks, types.ForeignKey{RefTable: refTable, OnUpdate: onUpdate, OnDelete: onDelete})
+			last = id
+			if !to.Valid {
+				implicit = append(implicit, len(fks)-1)
+			}
+		}
+		fk := &fks[len(fks)-1]
+		fk.Columns = append(fk.Columns, from)
+		if to.Valid {
+			fk.RefColumns = append(fk.RefColumns, to.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, i := range implicit {
+		ref := types.TableSchema{Schema: schema, Name: fks[i].RefTable}
+		if err := s.describeColumns(ctx, &ref); err != nil {
+			return nil, err
+		}
+		fks[i].RefColumns = ref.PrimaryKey
+	}
+	return fks, nil
+}
+
+// describeIndexes adds the indexes of a table and the unique constraints they back, the indexes SQLite creates
+// for constraints have no CREATE INDEX statement
+func (s *SQLite) describeIndexes(ctx context.Context, t *types.TableSchema) error {
+	rows, err := s.DB.QueryContext(ctx, `SELECT il.name, il."unique", il.origin, m.sql FROM pragma_index_list(?, ?) AS il
+	LEFT JOIN `+sqliteIdent(t.Schema)+`.sqlite_master AS m ON m.type = 'index' AND m.name = il.name
+	ORDER BY il.name`, t.Name, t.Schema)
+	if err != nil {
+		return err
+	}
+	var indexes []types.Index
+	var origins []string
+	for rows.Next() {
+		var idx types.Index
+		var origin string
+		var create sql.NullString
+		if err := rows.Scan(&idx.Name, &idx.Unique, &origin, &create); err != nil {
+			rows.Close()
+			return err
+		}
+		idx.Primary = origin == "pk"
+		idx.Definition = create.String
+		indexes = append(indexes, idx)
+		origins = append(origins, origin)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range indexes {
+		if indexes[i].Columns, err = s.indexColumns(ctx, t.Schema, indexes[i].Name); err != nil {
+			return err
+		}
+		if origins[i] == "u" {
+			t.Uniques = append(t.Uniques, types.UniqueConstraint{Columns: indexes[i].Columns})
+		}
+	}
+	t.Indexes = indexes
+	return nil
+}
+
+// indexColumns returns the key columns of an index, an expression key has no column name
+func (s *SQLite) indexColumns(ctx context.Context, schema, index string) ([]string, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT name FROM pragma_index_info(?, ?) ORDER BY seqno`, index, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name sql.NullString
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if !name.Valid {
+			name.String = "<expression>"
+		}
+		columns = append(columns, name.String)
+	}
+	return columns, rows.Err()
+}
+
+// sqliteChecks returns the check constraints of a CREATE TABLE statement, an expression ends at the parenthesis
+// closing the one after CHECK. Parentheses in quoted strings and names are skipped.
+func sqliteChecks(create string) []types.CheckConstraint {
+	var checks []types.CheckConstraint
+	for pos := 0; pos < len(create); {
+		m := checkKeyword.FindStringSubmatchIndex(create[pos:])
+		if m == nil {
+			break
+		}
+		start := pos + m[1] // after the opening parenthesis
+		if quoted(create[:pos+m[0]]) {
+			pos = start
+			continue
+		}
+		end := closingParen(create, start)
+		if end < 0 {
+			break
+		}
+		check := types.CheckConstraint{Definition: "CHECK (" + strings.TrimSpace(create[start:end]) + ")"}
+		if m[2] >= 0 {
+			check.Name = strings.Trim(create[pos+m[2]:pos+m[3]], `"`)
+		}
+		checks = append(checks, check)
+		pos = end + 1
+	}
+	return checks
+}
+
+// closingParen returns the index of the parenthesis closing the one before start, or -1 when it's never closed
+func closingParen(s string, start int) int {
+	depth := 1
+	var quote byte
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// quoted reports whether the end of s is inside a quoted string or name
+func quoted(s string) bool {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		}
+	}
+	return quote != 0
+}
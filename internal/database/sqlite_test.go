@@ -0,0 +1,458 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSQLiteClient returns a client for a new database file populated with the customers table
+func newSQLiteClient(t *testing.T) database.ClientInterface {
+	t.Helper()
+	client, err := database.NewClient(config.DatabaseConfig{
+		Name:   "local",
+		Driver: config.DriverSQLite,
+		DBName: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+
+	stmts := []string{
+		"CREATE TABLE customers (id INTEGER PRIMARY KEY, name VARCHAR(200) NOT NULL, country TEXT, balance REAL)",
+		"INSERT INTO customers (id, name, country, balance) VALUES (1, 'Bob', 'UK', 10.5), (2, 'Anna', 'IE', NULL)",
+	}
+	for _, stmt := range stmts {
+		if _, err := client.ExecPrepared(context.Background(), stmt, nil); err != nil {
+			t.Fatalf("failed to populate database: %v", err)
+		}
+	}
+	return client
+}
+
+func TestSQLite_ExecQuery(t *testing.T) {
+	client := newSQLiteClient(t)
+
+	columns := []types.Column{{Name: "id", Type: "INTEGER"}, {Name: "name", Type: "VARCHAR(200)"}, {Name: "country", Type: "TEXT"}, {Name: "balance", Type: "REAL"}}
+	expectedAll := &types.ResultSet{Columns: columns, Rows: [][]any{{int64(1), "Bob", "UK", 10.5}, {int64(2), "Anna", "IE", nil}}}
+	expectedById := &types.ResultSet{Columns: columns, Rows: [][]any{{int64(2), "Anna", "IE", nil}}}
+	expectedNone := &types.ResultSet{Columns: columns, Rows: [][]any{}}
+
+	paramsById := map[string]any{"1": 2}
+
+	tests := []struct {
+		name    string
+		query   string
+		params  map[string]any
+		want    *types.ResultSet
+		wantErr bool
+	}{
+		{name: "Happy Flow execute_query - SELECT all", query: "SELECT id, name, country, balance FROM customers ORDER BY id", want: expectedAll, wantErr: false},
+		{name: "Happy Flow execute_query - SELECT by id", query: "SELECT id, name, country, balance FROM customers WHERE id = ?", params: paramsById, want: expectedById, wantErr: false},
+		{name: "Happy Flow execute_query - SELECT by named parameter", query: "SELECT id, name, country, balance FROM customers WHERE id = :id", params: map[string]any{"id": 2}, want: expectedById, wantErr: false},
+		{name: "Happy Flow execute_query - no rows keeps the columns", query: "SELECT id, name, country, balance FROM customers WHERE id = 0", want: expectedNone, wantErr: false},
+		{name: "Happy Flow execute_query - BLOB is binary", query: "SELECT X'0102' AS avatar", want: &types.ResultSet{Columns: []types.Column{{Name: "avatar"}}, Rows: [][]any{{types.Binary{1, 2}}}}, wantErr: false},
+		{name: "Sad Flow execute_query - parameter without placeholder", query: "SELECT * FROM customers", params: paramsById, wantErr: true},
+		{name: "Sad Flow execute_query - table doesn't exist", query: "SELECT * FROM orders", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := client.ExecQuery(context.Background(), tt.query, tt.params, 0)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("ExecQuery() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("ExecQuery() succeeded unexpectedly")
+			}
+			assert.EqualValues(t, tt.want, got)
+		})
+	}
+}
+
+func TestSQLite_ExecQuery_ReadOnly(t *testing.T) {
+	client := newSQLiteClient(t)
+
+	_, err := client.ExecQuery(context.Background(), "INSERT INTO customers (id, name) VALUES (3, 'Eve') RETURNING id", nil, 0)
+	assert.ErrorContains(t, err, "attempt to write a readonly database")
+
+	// the write path is unaffected once the query returned its connection to the pool
+	_, err = client.ExecPrepared(context.Background(), "INSERT INTO customers (id, name) VALUES (?, ?)", []any{3, "Eve"})
+	assert.NoError(t, err)
+
+	got, err := client.ExecQuery(context.Background(), "SELECT count(*) AS total FROM customers", nil, 0)
+	if err != nil {
+		t.Fatalf("ExecQuery() failed: %v", err)
+	}
+	assert.EqualValues(t, [][]any{{int64(3)}}, got.Rows)
+}
+
+func TestSQLite_ExecQuery_Timeout(t *testing.T) {
+	client := newSQLiteClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// counts forever, the query is interrupted when the deadline passes
+	_, err := client.ExecQuery(ctx, "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c", nil, 0)
+	assert.ErrorIs(t, err, database.ErrQueryTimeout)
+}
+
+func TestSQLite_ExecQuery_MaxRows(t *testing.T) {
+	client := newSQLiteClient(t)
+
+	// the series never ends, reading stops after maxRows rows
+	got, err := client.ExecQuery(context.Background(), "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT x FROM c", nil, 3)
+	if err != nil {
+		t.Fatalf("ExecQuery() failed: %v", err)
+	}
+	assert.EqualValues(t, [][]any{{int64(1)}, {int64(2)}, {int64(3)}}, got.Rows)
+}
+
+func TestSQLite_ExecQuery_Progress(t *testing.T) {
+	client := newSQLiteClient(t)
+
+	var counts []int
+	ctx := database.WithProgress(context.Background(), func(rows int) { counts = append(counts, rows) })
+	if _, err := client.ExecQuery(ctx, "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT x FROM c", nil, 3); err != nil {
+		t.Fatalf("ExecQuery() failed: %v", err)
+	}
+	assert.Equal(t, []int{1, 2, 3}, counts)
+
+	// a cursor counts the rows of a page with the row read ahead by the previous page, the last page only checks the end
+	cursor, err := client.OpenCursor(context.Background(), "SELECT id FROM customers ORDER BY id", nil)
+	if err != nil {
+		t.Fatalf("OpenCursor() failed: %v", err)
+	}
+	defer cursor.Close()
+	counts = nil
+	if _, _, err := cursor.Fetch(ctx, 1); err != nil {
+		t.Fatalf("Fetch() failed: %v", err)
+	}
+	counts = append(counts, -1)
+	if _, _, err := cursor.Fetch(ctx, 1); err != nil {
+		t.Fatalf("Fetch() failed: %v", err)
+	}
+	assert.Equal(t, []int{1, 2, -1}, counts)
+}
+
+func TestSQLite_OpenCursor(t *testing.T) {
+	client := newSQLiteClient(t)
+	ctx := context.Background()
+
+	// the series never ends, every page reads one row ahead
+	cursor, err := client.OpenCursor(ctx, "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT x FROM c", nil)
+	if err != nil {
+		t.Fatalf("OpenCursor() failed: %v", err)
+	}
+	for _, want := range [][][]any{{{int64(1)}, {int64(2)}}, {{int64(3)}, {int64(4)}}} {
+		got, more, err := cursor.Fetch(ctx, 2)
+		if err != nil {
+			t.Fatalf("Fetch() failed: %v", err)
+		}
+		assert.Equal(t, []types.Column{{Name: "x"}}, got.Columns)
+		assert.EqualValues(t, want, got.Rows)
+		assert.True(t, more)
+	}
+	assert.NoError(t, cursor.Close())
+	_, _, err = cursor.Fetch(ctx, 2)
+	assert.ErrorIs(t, err, database.ErrCursorClosed)
+
+	// the connection is given back with query_only reset
+	_, err = client.ExecPrepared(ctx, "INSERT INTO customers (id, name) VALUES (3, 'Eve')", nil)
+	assert.NoError(t, err)
+}
+
+func TestSQLite_OpenCursor_LastPage(t *testing.T) {
+	client := newSQLiteClient(t)
+	ctx := context.Background()
+
+	cursor, err := client.OpenCursor(ctx, "SELECT id FROM customers WHERE id > :id ORDER BY id", map[string]any{"id": 0})
+	if err != nil {
+		t.Fatalf("OpenCursor() failed: %v", err)
+	}
+	defer cursor.Close()
+
+	got, more, err := cursor.Fetch(ctx, 1)
+	if err != nil {
+		t.Fatalf("Fetch() failed: %v", err)
+	}
+	assert.EqualValues(t, [][]any{{int64(1)}}, got.Rows)
+	assert.True(t, more)
+
+	got, more, err = cursor.Fetch(ctx, 1)
+	if err != nil {
+		t.Fatalf("Fetch() failed: %v", err)
+	}
+	assert.EqualValues(t, [][]any{{int64(2)}}, got.Rows)
+	assert.False(t, more)
+
+	// writes are rejected while the cursor holds the connection
+	_, err = client.OpenCursor(ctx, "INSERT INTO customers (id, name) VALUES (4, 'Eve') RETURNING id", nil)
+	assert.Error(t, err)
+}
+
+func TestSQLite_ExecPrepared(t *testing.T) {
+	client := newSQLiteClient(t)
+
+	columns := []types.Column{{Name: "message"}, {Name: "rowsAffected"}}
+	expected := &types.ResultSet{Columns: columns, Rows: [][]any{{"success", int64(1)}}}
+	expectedUpdateAll := &types.ResultSet{Columns: columns, Rows: [][]any{{"success", int64(3)}}}
+
+	tests := []struct {
+		name      string
+		statement string
+		params    []any
+		want      *types.ResultSet
+		wantErr   bool
+	}{
+		{name: "Happy Flow execute_prepared - INSERT", statement: "INSERT INTO customers (id, name, country) VALUES (?, ?, ?)", params: []any{3, "Joe", "UK"}, want: expected, wantErr: false},
+		{name: "Happy Flow execute_prepared - UPDATE all rows", statement: "UPDATE customers SET balance = 0", want: expectedUpdateAll, wantErr: false},
+		{name: "Sad Flow execute_prepared - NOT NULL constraint", statement: "INSERT INTO customers (id, name) VALUES (?, ?)", params: []any{4, nil}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := client.ExecPrepared(context.Background(), tt.statement, tt.params)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("ExecPrepared() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("ExecPrepared() succeeded unexpectedly")
+			}
+			assert.EqualValues(t, tt.want, got)
+		})
+	}
+}
+
+func TestSQLite_GetSchema(t *testing.T) {
+	client := newSQLiteClient(t)
+	if _, err := client.ExecPrepared(context.Background(), "CREATE TABLE orders (id INTEGER PRIMARY KEY, customer_id INTEGER)", nil); err != nil {
+		t.Fatalf("failed to populate database: %v", err)
+	}
+
+	length := int64(200)
+	customers := types.TableSchema{Schema: "main", Name: "customers", Columns: []types.ColumnSchema{
+		{Name: "id", DataType: "INTEGER", Position: 1},
+		{Name: "name", DataType: "VARCHAR(200)", Position: 2, MaxLength: &length},
+		{Name: "country", DataType: "TEXT", Nullable: true, Position: 3},
+		{Name: "balance", DataType: "REAL", Nullable: true, Position: 4},
+	}}
+	orders := types.TableSchema{Schema: "main", Name: "orders", Columns: []types.ColumnSchema{
+		{Name: "id", DataType: "INTEGER", Position: 1},
+		{Name: "customer_id", DataType: "INTEGER", Nullable: true, Position: 2},
+	}}
+
+	tests := []struct {
+		name    string
+		tables  []string
+		want    []types.TableSchema
+		wantErr bool
+	}{
+		{name: "Happy Flow get_schema - GET customers SCHEMA", tables: []string{"customers"}, want: []types.TableSchema{customers}, wantErr: false},
+		{name: "Happy Flow get_schema - GET schema of several tables", tables: []string{"orders", "customers"}, want: []types.TableSchema{orders, customers}, wantErr: false},
+		{name: "Happy Flow get_schema - GET schema of a qualified table", tables: []string{"main.orders"}, want: []types.TableSchema{orders}, wantErr: false},
+		{name: "Happy Flow get_schema - GET schema for table that doesn't exist", tables: []string{"invoices", "temp.orders"}, want: nil, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := client.GetSchema(context.Background(), tt.tables)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("GetSchema() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("GetSchema() succeeded unexpectedly")
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSQLite_TableNames(t *testing.T) {
+	client := newSQLiteClient(t)
+	if _, err := client.ExecPrepared(context.Background(), "CREATE VIEW uk_customers AS SELECT * FROM customers WHERE country = 'UK'", nil); err != nil {
+		t.Fatalf("failed to populate database: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		schema string
+		want   []types.TableName
+	}{
+		{name: "Happy Flow - main by default", schema: "", want: []types.TableName{{Schema: "main", Name: "customers"}, {Schema: "main", Name: "uk_customers"}}},
+		{name: "Happy Flow - schema without tables", schema: "temp", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.TableNames(context.Background(), tt.schema)
+			if err != nil {
+				t.Fatalf("TableNames() failed: %v", err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSQLite_ListTables(t *testing.T) {
+	client := newSQLiteClient(t)
+	stmts := []string{
+		"CREATE INDEX customers_country ON customers (country)",
+		"CREATE VIEW uk_customers AS SELECT * FROM customers WHERE country = 'UK'",
+	}
+	for _, stmt := range stmts {
+		if _, err := client.ExecPrepared(context.Background(), stmt, nil); err != nil {
+			t.Fatalf("failed to populate database: %v", err)
+		}
+	}
+
+	got, err := client.ListTables(context.Background(), "", database.CatalogFilter{})
+	if err != nil {
+		t.Fatalf("ListTables() failed: %v", err)
+	}
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, types.TableInfo{Schema: "main", Name: "uk_customers", Type: "view"}, got[1])
+		customers := got[0]
+		assert.Equal(t, "customers", customers.Name)
+		assert.Equal(t, "table", customers.Type)
+		assert.Nil(t, customers.EstimatedRows, "never analyzed")
+		if assert.NotNil(t, customers.SizeBytes) {
+			assert.Equal(t, int64(8192), *customers.SizeBytes, "a page for the table and one for its index")
+		}
+	}
+
+	if _, err := client.ExecPrepared(context.Background(), "ANALYZE", nil); err != nil {
+		t.Fatalf("ANALYZE failed: %v", err)
+	}
+	got, err = client.ListTables(context.Background(), "main", database.CatalogFilter{})
+	if err != nil {
+		t.Fatalf("ListTables() failed: %v", err)
+	}
+	if assert.Len(t, got, 2) && assert.NotNil(t, got[0].EstimatedRows) {
+		assert.Equal(t, int64(2), *got[0].EstimatedRows)
+	}
+
+	// the sizes and estimates are read for the rows of the page
+	got, err = client.ListTables(context.Background(), "main", database.CatalogFilter{Pattern: "*customers", Type: "table", Limit: 1})
+	if err != nil {
+		t.Fatalf("ListTables() failed: %v", err)
+	}
+	if assert.Len(t, got, 1) && assert.NotNil(t, got[0].SizeBytes) && assert.NotNil(t, got[0].EstimatedRows) {
+		assert.Equal(t, "customers", got[0].Name)
+		assert.Equal(t, int64(2), *got[0].EstimatedRows)
+	}
+}
+
+func TestSQLite_ListSchemas(t *testing.T) {
+	client := newSQLiteClient(t)
+
+	got, err := client.ListSchemas(context.Background(), database.CatalogFilter{})
+	if err != nil {
+		t.Fatalf("ListSchemas() failed: %v", err)
+	}
+	assert.Equal(t, types.SchemaInfo{Name: "main", Tables: 1}, got[0])
+
+	got, err = client.ListSchemas(context.Background(), database.CatalogFilter{Pattern: "m?in", Limit: 1})
+	if err != nil {
+		t.Fatalf("ListSchemas() failed: %v", err)
+	}
+	assert.Equal(t, []types.SchemaInfo{{Name: "main", Tables: 1}}, got)
+
+	got, err = client.ListSchemas(context.Background(), database.CatalogFilter{Pattern: "other*"})
+	if err != nil {
+		t.Fatalf("ListSchemas() failed: %v", err)
+	}
+	assert.Empty(t, got)
+}
+
+func TestSQLite_Status(t *testing.T) {
+	client := newSQLiteClient(t)
+
+	got, err := client.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+	assert.Regexp(t, `^3\.\d+\.\d+$`, got.ServerVersion)
+	assert.Equal(t, 1, got.Pool.OpenConnections)
+	assert.Equal(t, 1, got.Pool.Idle)
+
+	// the pool limits come from the pool config
+	limited, err := database.NewClient(config.DatabaseConfig{
+		Name:   "limited",
+		Driver: config.DriverSQLite,
+		DBName: filepath.Join(t.TempDir(), "limited.db"),
+		Pool:   config.PoolConfig{MaxOpenConns: 3},
+	})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	got, err = limited.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+	assert.Equal(t, 3, got.Pool.MaxOpenConnections)
+}
+
+func TestSQLite_DescribeTables(t *testing.T) {
+	client := newSQLiteClient(t)
+	stmts := []string{
+		`CREATE TABLE orders (id INTEGER PRIMARY KEY, customer_id INTEGER NOT NULL REFERENCES customers ON DELETE CASCADE,
+			code VARCHAR(20) UNIQUE, total REAL DEFAULT 0, CONSTRAINT positive CHECK (total >= 0 AND code <> ')'))`,
+		"CREATE INDEX orders_customer ON orders (customer_id, total)",
+	}
+	for _, stmt := range stmts {
+		if _, err := client.ExecPrepared(context.Background(), stmt, nil); err != nil {
+			t.Fatalf("failed to populate database: %v", err)
+		}
+	}
+
+	zero, length := "0", int64(20)
+	orders := types.TableSchema{
+		Schema: "main",
+		Name:   "orders",
+		Columns: []types.ColumnSchema{
+			{Name: "id", DataType: "INTEGER", Position: 1},
+			{Name: "customer_id", DataType: "INTEGER", Position: 2},
+			{Name: "code", DataType: "VARCHAR(20)", Nullable: true, Position: 3, MaxLength: &length},
+			{Name: "total", DataType: "REAL", Nullable: true, Default: &zero, Position: 4},
+		},
+		PrimaryKey:  []string{"id"},
+		ForeignKeys: []types.ForeignKey{{Columns: []string{"customer_id"}, RefTable: "customers", RefColumns: []string{"id"}, OnUpdate: "NO ACTION", OnDelete: "CASCADE"}},
+		Uniques:     []types.UniqueConstraint{{Columns: []string{"code"}}},
+		Checks:      []types.CheckConstraint{{Name: "positive", Definition: "CHECK (total >= 0 AND code <> ')')"}},
+		Indexes: []types.Index{
+			{Name: "orders_customer", Columns: []string{"customer_id", "total"}, Definition: "CREATE INDEX orders_customer ON orders (customer_id, total)"},
+			{Name: "sqlite_autoindex_orders_1", Columns: []string{"code"}, Unique: true},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		tables []string
+		want   []types.TableSchema
+	}{
+		{name: "Happy Flow get_schema detailed - keys, constraints and indexes", tables: []string{"orders"}, want: []types.TableSchema{orders}},
+		{name: "Happy Flow get_schema detailed - unknown table is left out", tables: []string{"missing", "orders"}, want: []types.TableSchema{orders}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.DescribeTables(context.Background(), tt.tables)
+			if err != nil {
+				t.Fatalf("DescribeTables() failed: %v", err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package erd
+
+import (
+	"slices"
+
+	"exmple.com/database-query-server/pkg/types"
+)
+
+// Graph is a set of tables and the foreign keys between them. A table referenced by a foreign key but not described,
+// ie. in another schema, is an entity without columns.
+type Graph struct {
+	tables []types.TableSchema
+	byName map[string]int  // index of a table by its schema.name
+	within map[string]bool // the referenced tables kept in a neighbourhood, every one when nil
+}
+
+// Relationship is a foreign key of the child table referencing the parent table
+type Relationship struct {
+	Child  string // schema.name of the table holding the foreign key
+	Parent string // schema.name of the referenced table
+	Key    types.ForeignKey
+	// Optional is set when a child row can have no parent, one of the key columns is nullable
+	Optional bool
+	// One is set when a parent has at most one child, the key columns are the primary key or a unique constraint
+	One bool
+}
+
+// New returns the graph of the described tables, the first description of a table is kept
+func New(tables []types.TableSchema) *Graph {
+	g := &Graph{byName: map[string]int{}}
+	for _, t := range tables {
+		name := qualified(t.Schema, t.Name)
+		if _, ok := g.byName[name]; ok {
+			continue
+		}
+		g.byName[name] = len(g.tables)
+		g.tables = append(g.tables, t)
+	}
+	return g
+}
+
+// Lookup returns the schema.name of a table given by name or schema.name, a name matches the first table with it
+func (g *Graph) Lookup(table string) (string, bool) {
+	if _, ok := g.byName[table]; ok {
+		return table, true
+	}
+	for _, t := range g.tables {
+		if t.Name == table {
+			return qualified(t.Schema, t.Name), true
+		}
+	}
+	return "", false
+}
+
+// Neighbourhood returns the graph of the tables at most hops foreign keys away from table, following the keys in
+// both directions. table is a schema.name returned by Lookup.
+func (g *Graph) Neighbourhood(table string, hops int) *Graph {
+	distance := map[string]int{table: 0}
+	queue := []string{table}
+	relationships := g.Relationships()
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if distance[name] == hops {
+			continue
+		}
+		for _, r := range relationships {
+			var next string
+			switch name {
+			case r.Child:
+				next = r.Parent
+			case r.Parent:
+				next = r.Child
+			default:
+				continue
+			}
+			if _, seen := distance[next]; !seen {
+				distance[next] = distance[name] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	var tables []types.TableSchema
+	for _, t := range g.tables {
+		if _, ok := distance[qualified(t.Schema, t.Name)]; ok {
+			tables = append(tables, t)
+		}
+	}
+	n := New(tables)
+	// the foreign keys to tables further away are left out with them
+	n.within = map[string]bool{}
+	for name := range distance {
+		n.within[name] = true
+	}
+	return n
+}
+
+// Tables returns the schema.name of the described tables followed by the referenced ones, in the order they were added
+func (g *Graph) Tables() []string {
+	var names []string
+	for _, t := range g.tables {
+		names = append(names, qualified(t.Schema, t.Name))
+	}
+	for _, r := range g.Relationships() {
+		if _, ok := g.byName[r.Parent]; !ok && !slices.Contains(names, r.Parent) {
+			names = append(names, r.Parent)
+		}
+	}
+	return names
+}
+
+// Relationships returns the foreign keys of the described tables in table order
+func (g *Graph) Relationships() []Relationship {
+	var relationships []Relationship
+	for _, t := range g.tables {
+		child := qualified(t.Schema, t.Name)
+		for _, fk := range t.ForeignKeys {
+			parent := qualified(refSchema(t, fk), fk.RefTable)
+			if g.within != nil && !g.within[parent] {
+				continue
+			}
+			relationships = append(relationships, Relationship{
+				Child:    child,
+				Parent:   parent,
+				Key:      fk,
+				Optional: anyNullable(t.Columns, fk.Columns),
+				One:      isUnique(t, fk.Columns),
+			})
+		}
+	}
+	return relationships
+}
+
+// table returns the description of a table, nil for a referenced table that wasn't described
+func (g *Graph) table(name string) *types.TableSchema {
+	if i, ok := g.byName[name]; ok {
+		return &g.tables[i]
+	}
+	return nil
+}
+
+func qualified(schema, name string) string {
+	if schema == "" {
+		return name
+	}
+	return schema + "." + name
+}
+
+// refSchema returns the schema of the table a foreign key references. SQLite doesn't report it, it's the one of the
+// child table.
+func refSchema(t types.TableSchema, fk types.ForeignKey) string {
+	if fk.RefSchema == "" {
+		return t.Schema
+	}
+	return fk.RefSchema
+}
+
+// anyNullable reports whether one of the named columns is nullable
+func anyNullable(columns []types.ColumnSchema, names []string) bool {
+	for _, c := range columns {
+		if c.Nullable && slices.Contains(names, c.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnique reports whether the columns are the primary key or a unique constraint of the table, in any order
+func isUnique(t types.TableSchema, columns []string) bool {
+	same := func(key []string) bool {
+		if len(key) != len(columns) {
+			return false
+		}
+		for _, c := range columns {
+			if !slices.Contains(key, c) {
+				return false
+			}
+		}
+		return true
+	}
+	if len(t.PrimaryKey) > 0 && same(t.PrimaryKey) {
+		return true
+	}
+	for _, u := range t.Uniques {
+		if same(u.Columns) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package erd_test
+
+import (
+	"testing"
+
+	"exmple.com/database-query-server/internal/erd"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// shop is customers <- orders <- order_lines -> products -> public.suppliers, with users unrelated.
+// An order has at most one invoice.
+func shop() []types.TableSchema {
+	column := func(name string, nullable bool) types.ColumnSchema {
+		return types.ColumnSchema{Name: name, DataType: "integer", Nullable: nullable}
+	}
+	fk := func(name, column, refSchema, refTable string) types.ForeignKey {
+		return types.ForeignKey{Name: name, Columns: []string{column}, RefSchema: refSchema, RefTable: refTable, RefColumns: []string{"id"}}
+	}
+	return []types.TableSchema{
+		{Schema: "sales", Name: "customers", Columns: []types.ColumnSchema{column("id", false)}, PrimaryKey: []string{"id"}},
+		{Schema: "sales", Name: "orders", Columns: []types.ColumnSchema{column("id", false), column("customer_id", true)}, PrimaryKey: []string{"id"},
+			ForeignKeys: []types.ForeignKey{fk("orders_customer_fk", "customer_id", "sales", "customers")}},
+		{Schema: "sales", Name: "invoices", Columns: []types.ColumnSchema{column("id", false), column("order_id", false)}, PrimaryKey: []string{"id"},
+			ForeignKeys: []types.ForeignKey{fk("invoices_order_fk", "order_id", "sales", "orders")},
+			Uniques:     []types.UniqueConstraint{{Name: "invoices_order_key", Columns: []string{"order_id"}}}},
+		{Schema: "sales", Name: "order_lines", Columns: []types.ColumnSchema{column("order_id", false), column("product_id", false)},
+			PrimaryKey: []string{"order_id", "product_id"},
+			ForeignKeys: []types.ForeignKey{
+				fk("lines_order_fk", "order_id", "sales", "orders"),
+				fk("lines_product_fk", "product_id", "", "products"),
+			}},
+		{Schema: "sales", Name: "products", Columns: []types.ColumnSchema{column("id", false), column("supplier_id", false)}, PrimaryKey: []string{"id"},
+			ForeignKeys: []types.ForeignKey{fk("products_supplier_fk", "supplier_id", "public", "suppliers")}},
+		{Schema: "sales", Name: "users", Columns: []types.ColumnSchema{column("id", false)}},
+	}
+}
+
+func TestGraph_Relationships(t *testing.T) {
+	g := erd.New(shop())
+
+	var got []string
+	for _, r := range g.Relationships() {
+		got = append(got, r.Child+" -> "+r.Parent)
+	}
+	assert.Equal(t, []string{
+		"sales.orders -> sales.customers",
+		"sales.invoices -> sales.orders",
+		"sales.order_lines -> sales.orders",
+		"sales.order_lines -> sales.products",
+		"sales.products -> public.suppliers",
+	}, got)
+
+	relationships := g.Relationships()
+	assert.True(t, relationships[0].Optional, "customer_id is nullable")
+	assert.False(t, relationships[0].One)
+	assert.True(t, relationships[1].One, "order_id is a unique key of invoices")
+	assert.False(t, relationships[2].One, "order_id is only part of the order_lines primary key")
+
+	assert.Equal(t, []string{
+		"sales.customers", "sales.orders", "sales.invoices", "sales.order_lines", "sales.products", "sales.users", "public.suppliers",
+	}, g.Tables())
+}
+
+func TestGraph_Neighbourhood(t *testing.T) {
+	tests := []struct {
+		name          string
+		table         string
+		hops          int
+		want          []string
+		relationships int
+	}{
+		{name: "Happy Flow - one hop", table: "orders", hops: 1, want: []string{"sales.customers", "sales.orders", "sales.invoices", "sales.order_lines"}, relationships: 3},
+		{name: "Happy Flow - two hops", table: "sales.orders", hops: 2, want: []string{"sales.customers", "sales.orders", "sales.invoices", "sales.order_lines", "sales.products"}, relationships: 4},
+		{name: "Happy Flow - referenced table of another schema", table: "products", hops: 1, want: []string{"sales.order_lines", "sales.products", "public.suppliers"}, relationships: 2},
+		{name: "Happy Flow - no foreign keys", table: "users", hops: 3, want: []string{"sales.users"}, relationships: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := erd.New(shop())
+			table, ok := g.Lookup(tt.table)
+			if !assert.True(t, ok) {
+				return
+			}
+			n := g.Neighbourhood(table, tt.hops)
+			assert.Equal(t, tt.want, n.Tables())
+			assert.Len(t, n.Relationships(), tt.relationships)
+		})
+	}
+
+	t.Run("Sad Flow - unknown table", func(t *testing.T) {
+		_, ok := erd.New(shop()).Lookup("invoice")
+		assert.False(t, ok)
+	})
+}
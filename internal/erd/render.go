@@ -0,0 +1,155 @@
+package erd
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+
+	"exmple.com/database-query-server/pkg/types"
+)
+
+// mermaidUnsafe matches the characters Mermaid doesn't allow in entity ids, attribute types and names
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_()\[\]-]+`)
+
+// Mermaid renders the graph as a Mermaid erDiagram, columns adds the columns of the described tables with their
+// PK, FK and UK keys. Entities are named by their schema.name alias.
+func (g *Graph) Mermaid(columns bool) string {
+	ids := g.mermaidIDs()
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, name := range g.Tables() {
+		t := g.table(name)
+		if !columns || t == nil || len(t.Columns) == 0 {
+			fmt.Fprintf(&b, "    %s[%q]\n", ids[name], strings.ReplaceAll(name, `"`, "'"))
+			continue
+		}
+		fmt.Fprintf(&b, "    %s[%q] {\n", ids[name], strings.ReplaceAll(name, `"`, "'"))
+		for _, c := range t.Columns {
+			fmt.Fprintf(&b, "        %s %s", mermaidWord(c.DataType, "any"), mermaidWord(c.Name, "column"))
+			if keys := columnKeys(t, c.Name); len(keys) > 0 {
+				fmt.Fprintf(&b, " %s", strings.Join(keys, ", "))
+			}
+			if c.Comment != "" {
+				fmt.Fprintf(&b, " %q", strings.ReplaceAll(c.Comment, `"`, "'"))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+	for _, r := range g.Relationships() {
+		parent, child := "||", "o{"
+		if r.Optional {
+			parent = "|o"
+		}
+		if r.One {
+			child = "o|"
+		}
+		fmt.Fprintf(&b, "    %s %s--%s %s : %q\n", ids[r.Parent], parent, child, ids[r.Child],
+			strings.ReplaceAll(relationshipLabel(r), `"`, "'"))
+	}
+	return b.String()
+}
+
+// DOT renders the graph as a Graphviz digraph with an edge from each child table to its parent, columns renders the
+// described tables as HTML tables of their columns
+func (g *Graph) DOT(columns bool) string {
+	var b strings.Builder
+	b.WriteString("digraph erd {\n\trankdir=LR;\n")
+	if columns {
+		b.WriteString("\tnode [shape=plaintext];\n")
+	} else {
+		b.WriteString("\tnode [shape=box];\n")
+	}
+	for _, name := range g.Tables() {
+		if !columns {
+			fmt.Fprintf(&b, "\t%s;\n", dotID(name))
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">", dotID(name))
+		fmt.Fprintf(&b, "<tr><td bgcolor=\"lightgrey\"><b>%s</b></td></tr>", html.EscapeString(name))
+		if t := g.table(name); t != nil {
+			for _, c := range t.Columns {
+				cell := []string{c.Name}
+				if c.DataType != "" {
+					cell = append(cell, c.DataType)
+				}
+				if keys := columnKeys(t, c.Name); len(keys) > 0 {
+					cell = append(cell, strings.Join(keys, ", "))
+				}
+				fmt.Fprintf(&b, "<tr><td align=\"left\">%s</td></tr>", html.EscapeString(strings.Join(cell, " ")))
+			}
+		}
+		b.WriteString("</table>>];\n")
+	}
+	for _, r := range g.Relationships() {
+		// the tail is the cardinality of the child, the head the one of the parent
+		tail, head := "crowodot", "teetee"
+		if r.One {
+			tail = "teeodot"
+		}
+		if r.Optional {
+			head = "teeodot"
+		}
+		fmt.Fprintf(&b, "\t%s -> %s [label=%s, dir=both, arrowtail=%s, arrowhead=%s];\n",
+			dotID(r.Child), dotID(r.Parent), dotID(relationshipLabel(r)), tail, head)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// mermaidIDs returns a unique Mermaid entity id for every table of the graph
+func (g *Graph) mermaidIDs() map[string]string {
+	ids := map[string]string{}
+	used := map[string]bool{}
+	for _, name := range g.Tables() {
+		id := mermaidWord(name, "table")
+		for i := 2; used[id]; i++ {
+			id = fmt.Sprintf("%s_%d", mermaidWord(name, "table"), i)
+		}
+		used[id] = true
+		ids[name] = id
+	}
+	return ids
+}
+
+// mermaidWord replaces the characters Mermaid doesn't allow with underscores, an empty word is replaced by the empty argument
+func mermaidWord(s, empty string) string {
+	s = mermaidUnsafe.ReplaceAllString(s, "_")
+	if s == "" {
+		return empty
+	}
+	if s[0] >= '0' && s[0] <= '9' || s[0] == '-' || s[0] == '(' || s[0] == '[' {
+		return "_" + s
+	}
+	return s
+}
+
+// dotID quotes a Graphviz id
+func dotID(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// relationshipLabel is the foreign key name, or its columns when it has none
+func relationshipLabel(r Relationship) string {
+	if r.Key.Name != "" {
+		return r.Key.Name
+	}
+	return strings.Join(r.Key.Columns, ", ")
+}
+
+// columnKeys returns the PK, FK and UK markers of a column
+func columnKeys(t *types.TableSchema, column string) []string {
+	var keys []string
+	if slices.Contains(t.PrimaryKey, column) {
+		keys = append(keys, "PK")
+	}
+	if slices.ContainsFunc(t.ForeignKeys, func(fk types.ForeignKey) bool { return slices.Contains(fk.Columns, column) }) {
+		keys = append(keys, "FK")
+	}
+	if slices.ContainsFunc(t.Uniques, func(u types.UniqueConstraint) bool { return slices.Contains(u.Columns, column) }) {
+		keys = append(keys, "UK")
+	}
+	return keys
+}
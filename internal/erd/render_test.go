@@ -0,0 +1,110 @@
+package erd_test
+
+import (
+	"testing"
+
+	"exmple.com/database-query-server/internal/erd"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// orders references customers and an unknown table of another schema, the column types need escaping in Mermaid
+func orders() []types.TableSchema {
+	return []types.TableSchema{
+		{Schema: "sales", Name: "customers", PrimaryKey: []string{"id"}, Columns: []types.ColumnSchema{
+			{Name: "id", DataType: "integer"},
+			{Name: "email", DataType: "character varying(200)", Comment: `login "email"`},
+		}, Uniques: []types.UniqueConstraint{{Name: "customers_email_key", Columns: []string{"email"}}}},
+		{Schema: "sales", Name: "order lines", Columns: []types.ColumnSchema{
+			{Name: "customer_id", DataType: "integer", Nullable: true},
+			{Name: "total", DataType: "numeric(10,2)"},
+			{Name: "2nd note"},
+		}, ForeignKeys: []types.ForeignKey{
+			{Name: "lines_customer_fk", Columns: []string{"customer_id"}, RefSchema: "sales", RefTable: "customers", RefColumns: []string{"id"}},
+			{Columns: []string{"total"}, RefSchema: "billing", RefTable: "totals", RefColumns: []string{"amount"}},
+		}},
+	}
+}
+
+func TestGraph_Mermaid(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns bool
+		want    string
+	}{
+		{
+			name: "Happy Flow - entities",
+			want: `erDiagram
+    sales_customers["sales.customers"]
+    sales_order_lines["sales.order lines"]
+    billing_totals["billing.totals"]
+    sales_customers |o--o{ sales_order_lines : "lines_customer_fk"
+    billing_totals ||--o{ sales_order_lines : "total"
+`,
+		},
+		{
+			name:    "Happy Flow - columns",
+			columns: true,
+			want: `erDiagram
+    sales_customers["sales.customers"] {
+        integer id PK
+        character_varying(200) email UK "login 'email'"
+    }
+    sales_order_lines["sales.order lines"] {
+        integer customer_id FK
+        numeric(10_2) total FK
+        any _2nd_note
+    }
+    billing_totals["billing.totals"]
+    sales_customers |o--o{ sales_order_lines : "lines_customer_fk"
+    billing_totals ||--o{ sales_order_lines : "total"
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, erd.New(orders()).Mermaid(tt.columns))
+		})
+	}
+}
+
+func TestGraph_DOT(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns bool
+		want    string
+	}{
+		{
+			name: "Happy Flow - entities",
+			want: `digraph erd {
+	rankdir=LR;
+	node [shape=box];
+	"sales.customers";
+	"sales.order lines";
+	"billing.totals";
+	"sales.order lines" -> "sales.customers" [label="lines_customer_fk", dir=both, arrowtail=crowodot, arrowhead=teeodot];
+	"sales.order lines" -> "billing.totals" [label="total", dir=both, arrowtail=crowodot, arrowhead=teetee];
+}
+`,
+		},
+		{
+			name:    "Happy Flow - columns",
+			columns: true,
+			want: `digraph erd {
+	rankdir=LR;
+	node [shape=plaintext];
+	"sales.customers" [label=<<table border="0" cellborder="1" cellspacing="0"><tr><td bgcolor="lightgrey"><b>sales.customers</b></td></tr><tr><td align="left">id integer PK</td></tr><tr><td align="left">email character varying(200) UK</td></tr></table>>];
+	"sales.order lines" [label=<<table border="0" cellborder="1" cellspacing="0"><tr><td bgcolor="lightgrey"><b>sales.order lines</b></td></tr><tr><td align="left">customer_id integer FK</td></tr><tr><td align="left">total numeric(10,2) FK</td></tr><tr><td align="left">2nd note</td></tr></table>>];
+	"billing.totals" [label=<<table border="0" cellborder="1" cellspacing="0"><tr><td bgcolor="lightgrey"><b>billing.totals</b></td></tr></table>>];
+	"sales.order lines" -> "sales.customers" [label="lines_customer_fk", dir=both, arrowtail=crowodot, arrowhead=teeodot];
+	"sales.order lines" -> "billing.totals" [label="total", dir=both, arrowtail=crowodot, arrowhead=teetee];
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, erd.New(orders()).DOT(tt.columns))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+
+	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultListLimit is the page size of list_schemas and list_tables when the request has no limit
+	defaultListLimit = 100
+	// maxListLimit caps the page size of list_schemas and list_tables
+	maxListLimit = 1000
+)
+
+// ListDatabases returns the configured databases, their names are the database argument of the other tools
+func (qh *QueryHandler) ListDatabases(ctx context.Context, req mcp.CallToolRequest, args types.ListDatabasesRequest) (*types.ListDatabasesResponse, error) {
+	log.Printf("execute ListDatabases")
+	defaultConn, err := qh.repository.Get("")
+	if err != nil {
+		return nil, err
+	}
+	resp := &types.ListDatabasesResponse{Databases: []types.DatabaseInfo{}}
+	for _, name := range qh.repository.Names() {
+		conn, err := qh.repository.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		resp.Databases = append(resp.Databases, types.DatabaseInfo{
+			Name:     conn.Name,
+			Driver:   conn.Driver,
+			Default:  conn == defaultConn,
+			ReadOnly: conn.Policy.ReadOnly,
+		})
+	}
+	return resp, nil
+}
+
+// ListSchemas returns a page of the schemas of a database whose name matches the pattern
+func (qh *QueryHandler) ListSchemas(ctx context.Context, req mcp.CallToolRequest, args types.ListSchemasRequest) (*types.ListSchemasResponse, error) {
+	log.Printf("execute ListSchemas for DB: %v pattern: %v", args.Database, args.Pattern)
+	conn, err := qh.repository.Get(args.Database)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := catalogFilter(args.Pattern, "", args.Limit, args.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("list_schemas: %w", err)
+	}
+	schemas, err := conn.Client.ListSchemas(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("list_schemas failed %v", err)
+	}
+	page, next := catalogPage(schemas, filter)
+	return &types.ListSchemasResponse{Database: conn.Name, Schemas: page, NextOffset: next}, nil
+}
+
+// ListTables returns a page of the tables and views of a schema whose name matches the pattern and type the filter
+func (qh *QueryHandler) ListTables(ctx context.Context, req mcp.CallToolRequest, args types.ListTablesRequest) (*types.ListTablesResponse, error) {
+	log.Printf("execute ListTables for DB: %v schema: %v pattern: %v type: %v", args.Database, args.Schema, args.Pattern, args.Type)
+	conn, err := qh.repository.Get(args.Database)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := catalogFilter(args.Pattern, args.Type, args.Limit, args.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("list_tables: %w", err)
+	}
+	tables, err := conn.Client.ListTables(ctx, args.Schema, filter)
+	if err != nil {
+		return nil, fmt.Errorf("list_tables for schema %q failed %v", args.Schema, err)
+	}
+	page, next := catalogPage(tables, filter)
+	return &types.ListTablesResponse{Database: conn.Name, Tables: page, NextOffset: next}, nil
+}
+
+// catalogFilter returns the filter of a listing page, the database reads one entry more than the page to tell whether
+// there's a next one
+func catalogFilter(pattern, tableType string, limit, offset int) (database.CatalogFilter, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return database.CatalogFilter{}, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	limit = min(limit, maxListLimit)
+	return database.CatalogFilter{Pattern: pattern, Type: tableType, Limit: limit + 1, Offset: max(offset, 0)}, nil
+}
+
+// catalogPage returns the entries of the page read with filter and the offset of the next page, 0 when it's the last one
+func catalogPage[T any](entries []T, filter database.CatalogFilter) ([]T, int) {
+	limit := filter.Limit - 1
+	if len(entries) <= limit {
+		return append([]T{}, entries...), 0
+	}
+	return entries[:limit], filter.Offset + limit
+}
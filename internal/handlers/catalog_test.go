@@ -0,0 +1,109 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/internal/handlers"
+	"exmple.com/database-query-server/internal/repository"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryHandler_ListDatabases(t *testing.T) {
+	primary, _ := database.NewPostgresClientMock(nil, false)
+	reporting, _ := database.NewPostgresClientMock(nil, false)
+	repo := repository.NewRepository()
+	if err := repo.Register(config.DatabaseConfig{Name: "primary", Driver: config.DriverPostgres}, primary); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	readOnly := config.DatabaseConfig{Name: "reporting", Driver: config.DriverMySQL, Policy: config.PolicyConfig{ReadOnly: true}}
+	if err := repo.Register(readOnly, reporting); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	qh := handlers.NewQueryHandler(repo)
+
+	got, err := qh.ListDatabases(context.Background(), mcp.CallToolRequest{}, types.ListDatabasesRequest{})
+	if err != nil {
+		t.Fatalf("ListDatabases() failed: %v", err)
+	}
+	assert.Equal(t, &types.ListDatabasesResponse{Databases: []types.DatabaseInfo{
+		{Name: "primary", Driver: config.DriverPostgres, Default: true},
+		{Name: "reporting", Driver: config.DriverMySQL, ReadOnly: true},
+	}}, got)
+}
+
+func TestQueryHandler_ListTables(t *testing.T) {
+	client, err := database.NewClient(config.DatabaseConfig{
+		Name:   "local",
+		Driver: config.DriverSQLite,
+		DBName: filepath.Join(t.TempDir(), "catalog.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	// order_01 .. order_05, customers and the customer_names view
+	statements := []string{"CREATE TABLE customers (id INTEGER PRIMARY KEY, name TEXT)", "CREATE VIEW customer_names AS SELECT name FROM customers"}
+	for i := 1; i <= 5; i++ {
+		statements = append(statements, fmt.Sprintf("CREATE TABLE order_%02d (id INTEGER PRIMARY KEY)", i))
+	}
+	for _, stmt := range statements {
+		if _, err := client.ExecPrepared(context.Background(), stmt, nil); err != nil {
+			t.Fatalf("failed to populate database: %v", err)
+		}
+	}
+	qh := handlers.NewQueryHandler(newTestRepository(t, "local", client))
+
+	tests := []struct {
+		name     string
+		args     types.ListTablesRequest
+		want     []string
+		wantNext int
+		wantErr  string
+	}{
+		{name: "Happy Flow - every table", args: types.ListTablesRequest{}, want: []string{"customer_names", "customers", "order_01", "order_02", "order_03", "order_04", "order_05"}},
+		{name: "Happy Flow - pattern", args: types.ListTablesRequest{Pattern: "cust*"}, want: []string{"customer_names", "customers"}},
+		{name: "Happy Flow - views", args: types.ListTablesRequest{Type: "view"}, want: []string{"customer_names"}},
+		{name: "Happy Flow - first page", args: types.ListTablesRequest{Pattern: "order_*", Limit: 2}, want: []string{"order_01", "order_02"}, wantNext: 2},
+		{name: "Happy Flow - page of views", args: types.ListTablesRequest{Type: "view", Limit: 1}, want: []string{"customer_names"}},
+		{name: "Happy Flow - middle page", args: types.ListTablesRequest{Pattern: "order_0?", Limit: 2, Offset: 2}, want: []string{"order_03", "order_04"}, wantNext: 4},
+		{name: "Happy Flow - class pattern", args: types.ListTablesRequest{Pattern: "order_0[13]"}, want: []string{"order_01", "order_03"}},
+		{name: "Happy Flow - last page", args: types.ListTablesRequest{Pattern: "order_*", Limit: 2, Offset: 4}, want: []string{"order_05"}},
+		{name: "Happy Flow - offset past the end", args: types.ListTablesRequest{Offset: 10}, want: nil},
+		{name: "Happy Flow - schema without tables", args: types.ListTablesRequest{Schema: "temp"}, want: nil},
+		{name: "Sad Flow - invalid pattern", args: types.ListTablesRequest{Pattern: "order_[0"}, wantErr: `list_tables: invalid pattern "order_[0"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args.Database = "local"
+			got, err := qh.ListTables(context.Background(), mcp.CallToolRequest{}, tt.args)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListTables() failed: %v", err)
+			}
+			var names []string
+			for _, table := range got.Tables {
+				names = append(names, table.Name)
+			}
+			assert.Equal(t, tt.want, names)
+			assert.NotNil(t, got.Tables)
+			assert.Equal(t, tt.wantNext, got.NextOffset)
+		})
+	}
+
+	t.Run("Happy Flow - schemas", func(t *testing.T) {
+		got, err := qh.ListSchemas(context.Background(), mcp.CallToolRequest{}, types.ListSchemasRequest{Database: "local", Pattern: "ma*"})
+		if err != nil {
+			t.Fatalf("ListSchemas() failed: %v", err)
+		}
+		assert.Equal(t, &types.ListSchemasResponse{Database: "local", Schemas: []types.SchemaInfo{{Name: "main", Tables: 7}}}, got)
+	})
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/internal/repository"
+	"exmple.com/database-query-server/internal/store"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// heldCursor is a cursor kept between execute_query and fetch_more calls, only the session that opened it can fetch
+type heldCursor struct {
+	cursor  database.Cursor
+	conn    *repository.Connection
+	query   string
+	session string
+}
+
+// FetchMore returns the next rows of an execute_query cursor, the cursor is closed with the last page
+func (qh *QueryHandler) FetchMore(ctx context.Context, req mcp.CallToolRequest, args types.FetchMoreRequest) (*types.QueryResponse, error) {
+	held, ok := qh.cursors.Get(args.Cursor)
+	if !ok || held.session != sessionID(ctx) {
+		return nil, fmt.Errorf("fetch_more: cursor %q is unknown or expired, run execute_query again", args.Cursor)
+	}
+
+	// a page read for a response that can't be rendered would be lost, the cursor is already past it
+	if args.Format != "" {
+		if _, err := lookupFormat(args.Format); err != nil {
+			return nil, fmt.Errorf("fetch_more: %w", err)
+		}
+	}
+
+	limit := rowLimit(held.conn.Policy, args.Limit)
+
+	timeout := qh.timeout(args.Timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ctx, stopProgress := qh.trackProgress(ctx, req)
+	defer stopProgress()
+
+	start := time.Now()
+	result, more, err := held.cursor.Fetch(ctx, limit)
+	if err != nil {
+		// a failed cursor is closed by the client
+		qh.cursors.Delete(args.Cursor)
+	}
+	if errors.Is(err, database.ErrQueryTimeout) {
+		return nil, fmt.Errorf("%w: fetch_more was cancelled after %s", database.ErrQueryTimeout, timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch_more %v failed %v", held.query, err)
+	}
+	elapsed := time.Since(start)
+
+	response, err := qh.newQueryResponse(ctx, held.conn, held.query, result, elapsed, args.Format)
+	if err != nil {
+		return nil, err
+	}
+	if !more {
+		qh.closeCursor(args.Cursor)
+		return response, nil
+	}
+	response.Truncated = true
+	response.Cursor = args.Cursor
+	return response, nil
+}
+
+// queryWithCursor runs an execute_query that asked for pagination, the cursor is kept for fetch_more when there are
+// more rows than the limit
+func (qh *QueryHandler) queryWithCursor(ctx context.Context, conn *repository.Connection, args types.QueryRequest, query string, limit int, timeout time.Duration) (*types.QueryResponse, error) {
+	start := time.Now()
+	cursor, err := conn.Client.OpenCursor(ctx, query, args.Parameters)
+	var result *types.ResultSet
+	var more bool
+	if err == nil {
+		result, more, err = cursor.Fetch(ctx, limit)
+	}
+	if errors.Is(err, database.ErrQueryTimeout) {
+		return nil, fmt.Errorf("%w: execute_query was cancelled after %s", database.ErrQueryTimeout, timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("execute_query %v failed %v", args.Query, err)
+	}
+	elapsed := time.Since(start)
+
+	response, err := qh.newQueryResponse(ctx, conn, args.Query, result, elapsed, args.Format)
+	if err != nil {
+		cursor.Close()
+		return nil, err
+	}
+	if !more {
+		cursor.Close()
+		return response, nil
+	}
+
+	id, err := qh.cursors.Put(&heldCursor{cursor: cursor, conn: conn, query: args.Query, session: sessionID(ctx)})
+	if err != nil {
+		cursor.Close()
+		if errors.Is(err, store.ErrFull) {
+			return nil, fmt.Errorf("execute_query: %d cursors are open, fetch them to the end or wait %s for idle ones to close", qh.maxCursors, qh.cursorIdleTimeout)
+		}
+		return nil, err
+	}
+	response.Truncated = true
+	response.Cursor = id
+	return response, nil
+}
+
+// closeCursor removes a cursor from the store and closes it
+func (qh *QueryHandler) closeCursor(id string) {
+	if held, ok := qh.cursors.Delete(id); ok {
+		held.cursor.Close()
+	}
+}
+
+// sessionID returns the id of the MCP session of the request, stdio and stateless requests have none
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"exmple.com/database-query-server/internal/erd"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// DiagramMermaid and DiagramDOT are the formats of get_er_diagram
+	DiagramMermaid = "mermaid"
+	DiagramDOT     = "dot"
+	// defaultDiagramHops is the neighbourhood of a table when the request has no hops
+	defaultDiagramHops = 1
+)
+
+// GetERDiagram renders the foreign keys between the tables of a schema, or the ones around a table, as a Mermaid
+// erDiagram or a Graphviz digraph. Tables of other schemas referenced by a foreign key are drawn without columns.
+func (qh *QueryHandler) GetERDiagram(ctx context.Context, req mcp.CallToolRequest, args types.ERDiagramRequest) (*types.ERDiagramResponse, error) {
+	log.Printf("execute GetERDiagram for DB: %v schema: %v table: %v hops: %v", args.Database, args.Schema, args.Table, args.Hops)
+	format := args.Format
+	if format == "" {
+		format = DiagramMermaid
+	}
+	if format != DiagramMermaid && format != DiagramDOT {
+		return nil, fmt.Errorf("get_er_diagram format %q is not supported (supported: %s, %s)", format, DiagramMermaid, DiagramDOT)
+	}
+	conn, err := qh.repository.Get(args.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := conn.Client.TableNames(ctx, args.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("get_er_diagram failed to list the tables of schema %q: %v", args.Schema, err)
+	}
+	tables := make([]string, len(names))
+	for i, n := range names {
+		tables[i] = n.String()
+	}
+	described, err := conn.Client.DescribeTables(ctx, tables)
+	if err != nil {
+		return nil, fmt.Errorf("get_er_diagram failed %v", err)
+	}
+
+	graph := erd.New(described)
+	if args.Table != "" {
+		table, ok := graph.Lookup(args.Table)
+		if !ok {
+			return nil, fmt.Errorf("get_er_diagram table %q doesn't exist in schema %q", args.Table, args.Schema)
+		}
+		hops := args.Hops
+		if hops <= 0 {
+			hops = defaultDiagramHops
+		}
+		graph = graph.Neighbourhood(table, hops)
+	}
+
+	resp := &types.ERDiagramResponse{
+		Database:      conn.Name,
+		Format:        format,
+		Tables:        graph.Tables(),
+		Relationships: len(graph.Relationships()),
+	}
+	if resp.Tables == nil {
+		resp.Tables = []string{}
+	}
+	if format == DiagramDOT {
+		resp.Diagram = graph.DOT(args.Columns)
+	} else {
+		resp.Diagram = graph.Mermaid(args.Columns)
+	}
+	return resp, nil
+}
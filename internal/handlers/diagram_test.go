@@ -0,0 +1,115 @@
+package handlers_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/internal/handlers"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryHandler_GetERDiagram(t *testing.T) {
+	client, err := database.NewClient(config.DatabaseConfig{
+		Name:   "local",
+		Driver: config.DriverSQLite,
+		DBName: filepath.Join(t.TempDir(), "erd.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE customers (id INTEGER PRIMARY KEY, name TEXT NOT NULL)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, customer_id INTEGER NOT NULL REFERENCES customers (id))",
+		"CREATE TABLE order_lines (order_id INTEGER NOT NULL REFERENCES orders (id), sku TEXT NOT NULL, PRIMARY KEY (order_id, sku))",
+		"CREATE TABLE settings (key TEXT PRIMARY KEY, value TEXT)",
+	} {
+		if _, err := client.ExecPrepared(context.Background(), stmt, nil); err != nil {
+			t.Fatalf("failed to populate database: %v", err)
+		}
+	}
+	qh := handlers.NewQueryHandler(newTestRepository(t, "local", client))
+
+	tests := []struct {
+		name    string
+		args    types.ERDiagramRequest
+		want    *types.ERDiagramResponse
+		wantErr string
+	}{
+		{
+			name: "Happy Flow - schema as Mermaid",
+			args: types.ERDiagramRequest{},
+			want: &types.ERDiagramResponse{Database: "local", Format: "mermaid", Relationships: 2,
+				Tables: []string{"main.customers", "main.order_lines", "main.orders", "main.settings"},
+				Diagram: `erDiagram
+    main_customers["main.customers"]
+    main_order_lines["main.order_lines"]
+    main_orders["main.orders"]
+    main_settings["main.settings"]
+    main_orders ||--o{ main_order_lines : "order_id"
+    main_customers ||--o{ main_orders : "customer_id"
+`},
+		},
+		{
+			name: "Happy Flow - neighbourhood with columns",
+			args: types.ERDiagramRequest{Table: "order_lines", Columns: true},
+			want: &types.ERDiagramResponse{Database: "local", Format: "mermaid", Relationships: 1,
+				Tables: []string{"main.order_lines", "main.orders"},
+				Diagram: `erDiagram
+    main_order_lines["main.order_lines"] {
+        INTEGER order_id PK, FK
+        TEXT sku PK
+    }
+    main_orders["main.orders"] {
+        INTEGER id PK
+        INTEGER customer_id FK
+    }
+    main_orders ||--o{ main_order_lines : "order_id"
+`},
+		},
+		{
+			name: "Happy Flow - two hops as DOT",
+			args: types.ERDiagramRequest{Schema: "main", Table: "main.order_lines", Hops: 2, Format: "dot"},
+			want: &types.ERDiagramResponse{Database: "local", Format: "dot", Relationships: 2,
+				Tables: []string{"main.customers", "main.order_lines", "main.orders"},
+				Diagram: `digraph erd {
+	rankdir=LR;
+	node [shape=box];
+	"main.customers";
+	"main.order_lines";
+	"main.orders";
+	"main.order_lines" -> "main.orders" [label="order_id", dir=both, arrowtail=crowodot, arrowhead=teetee];
+	"main.orders" -> "main.customers" [label="customer_id", dir=both, arrowtail=crowodot, arrowhead=teetee];
+}
+`},
+		},
+		{
+			name: "Happy Flow - schema without tables",
+			args: types.ERDiagramRequest{Schema: "temp"},
+			want: &types.ERDiagramResponse{Database: "local", Format: "mermaid", Tables: []string{}, Diagram: "erDiagram\n"},
+		},
+		{name: "Sad Flow - unknown table", args: types.ERDiagramRequest{Table: "invoices"}, wantErr: `table "invoices" doesn't exist`},
+		{name: "Sad Flow - unsupported format", args: types.ERDiagramRequest{Format: "plantuml"}, wantErr: `format "plantuml" is not supported`},
+		{name: "Sad Flow - unknown database", args: types.ERDiagramRequest{Database: "other"}, wantErr: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.args.Database == "" {
+				tt.args.Database = "local"
+			}
+			got, err := qh.GetERDiagram(context.Background(), mcp.CallToolRequest{}, tt.args)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetERDiagram() failed: %v", err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
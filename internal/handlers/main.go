@@ -1,14 +1,97 @@
 package handlers
 
-import "exmple.com/database-query-server/internal/repository"
+import (
+	"log"
+	"time"
+
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/internal/repository"
+	"exmple.com/database-query-server/internal/store"
+)
 
 type QueryHandler struct {
-	repository *repository.Repository
+	repository       *repository.Repository
+	queryTimeout     time.Duration
+	maxQueryTimeout  time.Duration
+	progressInterval time.Duration
+
+	cursorIdleTimeout time.Duration
+	maxCursors        int
+	cursors           *store.Store[*heldCursor]
+
+	resultRows int
+	resultTTL  time.Duration
+	maxResults int
+	results    *store.Store[*storedResult]
+}
+
+// Option configures optional QueryHandler settings
+type Option func(*QueryHandler)
+
+// WithQueryTimeout sets the execute_query timeout used when a request doesn't set one, and the maximum a request can ask for
+func WithQueryTimeout(timeout, max time.Duration) Option {
+	return func(qh *QueryHandler) {
+		qh.queryTimeout = timeout
+		qh.maxQueryTimeout = max
+	}
+}
+
+// WithProgressInterval sets how often notifications/progress are sent while a query runs
+func WithProgressInterval(interval time.Duration) Option {
+	return func(qh *QueryHandler) {
+		qh.progressInterval = interval
+	}
 }
 
-// NewSystemCollector creates a new system metrics collector
-func NewQueryHandler(repository *repository.Repository) *QueryHandler {
-	return &QueryHandler{
-		repository: repository,
+// WithCursors sets how long an execute_query cursor is kept without a fetch_more, and how many are held at once
+func WithCursors(idle time.Duration, max int) Option {
+	return func(qh *QueryHandler) {
+		qh.cursorIdleTimeout = idle
+		qh.maxCursors = max
 	}
 }
+
+// WithResults sets the number of rows above which a result is stored as a query-result resource, 0 stores every
+// result and -1 never stores,
+// how long a stored result is kept without a read and how many are kept at once
+func WithResults(rows int, ttl time.Duration, max int) Option {
+	return func(qh *QueryHandler) {
+		qh.resultRows = rows
+		qh.resultTTL = ttl
+		qh.maxResults = max
+	}
+}
+
+// NewQueryHandler creates the MCP tool handlers for the databases in the repository
+func NewQueryHandler(repository *repository.Repository, opts ...Option) *QueryHandler {
+	qh := &QueryHandler{
+		repository:        repository,
+		queryTimeout:      config.DefaultQueryTimeout,
+		maxQueryTimeout:   config.DefaultMaxQueryTimeout,
+		progressInterval:  DefaultProgressInterval,
+		cursorIdleTimeout: config.DefaultCursorIdleTimeout,
+		maxCursors:        config.DefaultMaxCursors,
+		resultRows:        config.DefaultResultRows,
+		resultTTL:         config.DefaultResultTTL,
+		maxResults:        config.DefaultMaxResults,
+	}
+	for _, opt := range opts {
+		opt(qh)
+	}
+	qh.cursors = store.New(qh.cursorIdleTimeout,
+		store.WithMax[*heldCursor](qh.maxCursors),
+		store.WithEvict(func(_ string, c *heldCursor) {
+			log.Printf("closing an idle cursor of database %q", c.conn.Name)
+			c.cursor.Close()
+		}),
+	)
+	// a new result replaces the one read least recently when max_results results are held
+	qh.results = store.New(qh.resultTTL, store.WithMax[*storedResult](qh.maxResults), store.WithEvictOldest[*storedResult]())
+	return qh
+}
+
+// Close closes the cursors held between execute_query and fetch_more calls and drops the stored results
+func (qh *QueryHandler) Close() {
+	qh.cursors.Close()
+	qh.results.Close()
+}
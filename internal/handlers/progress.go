@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"exmple.com/database-query-server/internal/database"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultProgressInterval is how often notifications/progress are sent while a query runs
+const DefaultProgressInterval = time.Second
+
+// trackProgress counts the rows the database client scans with the returned context and sends them with the elapsed
+// time as notifications/progress every progress interval, when the request has a progress token. Over StreamableHTTP
+// the first notification turns the response into an SSE stream, the tool result follows the notifications.
+// stop sends the final count and must be called once the query is done.
+func (qh *QueryHandler) trackProgress(ctx context.Context, req mcp.CallToolRequest) (context.Context, func()) {
+	srv := server.ServerFromContext(ctx)
+	if srv == nil || req.Params.Meta == nil || req.Params.Meta.ProgressToken == nil {
+		return ctx, func() {}
+	}
+	token := req.Params.Meta.ProgressToken
+
+	start := time.Now()
+	var scanned atomic.Int64
+	sent := int64(-1)
+	// progress must increase with every notification, unchanged counts aren't sent
+	notify := func() {
+		rows := scanned.Load()
+		if rows <= sent {
+			return
+		}
+		sent = rows
+		err := srv.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+			"progressToken": token,
+			"progress":      rows,
+			"message":       fmt.Sprintf("%d rows scanned in %s", rows, time.Since(start).Round(time.Millisecond)),
+		})
+		if err != nil {
+			log.Printf("failed to send progress notification: %v", err)
+		}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(qh.progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				notify()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	ctx = database.WithProgress(ctx, func(rows int) { scanned.Store(int64(rows)) })
+	stop := func() {
+		close(done)
+		wg.Wait()
+		notify()
+	}
+	return ctx, stop
+}
@@ -0,0 +1,95 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/internal/handlers"
+	"exmple.com/database-query-server/internal/repository"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+)
+
+// testSession is an initialized MCP session that keeps the notifications sent to it
+type testSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return s.id }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func TestQueryHandler_Progress(t *testing.T) {
+	client, err := database.NewClient(config.DatabaseConfig{
+		Name:   "local",
+		Driver: config.DriverSQLite,
+		DBName: filepath.Join(t.TempDir(), "progress.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	repo := repository.NewRepository()
+	if err := repo.Register(config.DatabaseConfig{Name: "local", Driver: config.DriverSQLite}, client); err != nil {
+		t.Fatalf("failed to register local database: %v", err)
+	}
+	// only the final count is sent, the query is done long before the first tick
+	qh := handlers.NewQueryHandler(repo, handlers.WithProgressInterval(time.Hour))
+	defer qh.Close()
+
+	srv := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	srv.AddTool(
+		mcp.NewTool("execute_query", mcp.WithInputSchema[types.QueryRequest](), mcp.WithOutputSchema[types.QueryResponse]()),
+		mcp.NewStructuredToolHandler(qh.ExecuteQuery),
+	)
+
+	query := "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c LIMIT 20) SELECT x FROM c"
+	tests := []struct {
+		name     string
+		meta     string
+		limit    int
+		wantRows any
+	}{
+		{name: "Happy Flow execute_query - progress token gets the rows scanned", meta: `,"_meta":{"progressToken":"tok"}`, limit: 100, wantRows: int64(20)},
+		{name: "Happy Flow execute_query - the row read ahead for truncation is counted", meta: `,"_meta":{"progressToken":"tok"}`, limit: 5, wantRows: int64(6)},
+		{name: "Happy Flow execute_query - no progress token, no notifications", limit: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &testSession{id: "progress", notifications: make(chan mcp.JSONRPCNotification, 10)}
+			ctx := srv.WithContext(context.Background(), session)
+
+			msg := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"execute_query","arguments":{"database":"local","query":%q,"limit":%d}%s}}`, query, tt.limit, tt.meta)
+			resp := srv.HandleMessage(ctx, []byte(msg))
+			if _, ok := resp.(mcp.JSONRPCResponse); !ok {
+				t.Fatalf("tools/call failed: %+v", resp)
+			}
+			close(session.notifications)
+
+			var got []mcp.JSONRPCNotification
+			for n := range session.notifications {
+				got = append(got, n)
+			}
+			if tt.wantRows == nil {
+				assert.Empty(t, got)
+				return
+			}
+			if assert.Len(t, got, 1) {
+				assert.Equal(t, "notifications/progress", got[0].Method)
+				assert.Equal(t, "tok", got[0].Params.AdditionalFields["progressToken"])
+				assert.Equal(t, tt.wantRows, got[0].Params.AdditionalFields["progress"])
+				assert.Contains(t, got[0].Params.AdditionalFields["message"], fmt.Sprintf("%d rows scanned in", tt.wantRows))
+			}
+		})
+	}
+}
@@ -2,73 +2,87 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/internal/repository"
+	"exmple.com/database-query-server/internal/sqlparser"
 	"exmple.com/database-query-server/internal/utils"
 	"exmple.com/database-query-server/pkg/types"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
-// GetSchema retrieves the schema information for the specified tables and formats response for MCP client
+// GetSchema retrieves the schema information for the specified tables and formats response for MCP client.
+// The tables are grouped as Tables, a detailed request adds their keys, constraints, indexes and comments.
 func (qh *QueryHandler) GetSchema(ctx context.Context, req mcp.CallToolRequest, args types.SchemaRequest) (*types.QueryResponse, error) {
-	log.Printf("execute GetSchema for tables: %v deatiled: %v", args.Tables, args.Detailed)
-	res, err := qh.repository.Postgress.GetSchema(ctx, args.Tables)
+	log.Printf("execute GetSchema for tables: %v schema: %v deatiled: %v", args.Tables, args.Schema, args.Detailed)
+	conn, err := qh.repository.Get(args.Database)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	names, missing, err := resolveTables(ctx, conn.Client, args.Schema, args.Tables)
 	if err != nil {
 		return nil, fmt.Errorf("get_schema for table %v failed %v", args.Tables, err)
 	}
-
-	jsonRes, err := utils.DataToJson(res)
+	describe := conn.Client.GetSchema
+	if args.Detailed {
+		describe = conn.Client.DescribeTables
+	}
+	tables, err := describe(ctx, names)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encode get_schema response to JSON format %v", err)
+		return nil, fmt.Errorf("get_schema for table %v failed %v", args.Tables, err)
 	}
-
-	response := &types.QueryResponse{
-		Query:    "get_schema",
-		Response: jsonRes,
-		Format:   "json",
+	tables = uniqueTables(tables)
+	response, err := qh.newQueryResponse(ctx, conn, "get_schema", schemaResult(tables, args.Detailed), time.Since(start), "json")
+	if err != nil {
+		return nil, err
 	}
+	response.Tables = tables
+	response.Missing = append(missing, missingTables(names, tables)...)
 	return response, nil
 }
 
-// GetStatus returns the database status, the number of connections, and the timestamp of the last DB access
+// GetStatus pings the database and returns the connection pool statistics, ping latency and server version
 func (qh *QueryHandler) GetStatus(ctx context.Context, req mcp.CallToolRequest, args types.ConnectionStatus) (*types.ConnectionStatusResp, error) {
 	log.Printf("execute GetStatus for DB: %v ", args.Database)
-	par := make(map[string]any)
-	par["1"] = args.Database
-	query := "SELECT numbackends FROM pg_stat_database WHERE datname = $1"
-
-	resp, err := qh.repository.Postgress.ExecQuery(ctx, query, par)
+	conn, err := qh.repository.Get(args.Database)
 	if err != nil {
 		return nil, err
 	}
-	//casting to int64
-	i64, ok := resp[0]["numbackends"].(int64)
-	if !ok {
-		return nil, fmt.Errorf("failed converting connection pool status")
-	}
 
-	parLastPing := make(map[string]any)
-	parLastPing["1"] = args.Database
-
-	queryLastPing := "SELECT state_change FROM pg_stat_activity WHERE datname= $1 ORDER BY state_change DESC"
-	respLastPing, err := qh.repository.Postgress.ExecQuery(ctx, queryLastPing, parLastPing)
+	st, err := conn.Client.Status(ctx)
 	if err != nil {
-		return nil, err
-	}
-
-	//casting to time
-	st, ok := respLastPing[0]["state_change"].(time.Time)
-	if !ok {
-		return nil, fmt.Errorf("failed converting last ping status")
+		// an unreachable database is a valid status, not a tool error
+		return &types.ConnectionStatusResp{
+			Database:  conn.Name,
+			Connected: false,
+			Reason:    err.Error(),
+		}, nil
 	}
 
 	statResp := &types.ConnectionStatusResp{
-		Database:  args.Database,
-		Connected: true, // if the DB isn’t up, this function will return an error before reaching this point
-		PoolStats: int(i64),
-		LastPing:  st.Format(time.RFC3339),
+		Database:      conn.Name,
+		Connected:     true,
+		ServerVersion: st.ServerVersion,
+		PingLatency:   st.PingLatency.String(),
+		LastPing:      st.PingedAt.Format(time.RFC3339),
+		Pool: &types.PoolStats{
+			MaxOpenConnections: st.Pool.MaxOpenConnections,
+			OpenConnections:    st.Pool.OpenConnections,
+			InUse:              st.Pool.InUse,
+			Idle:               st.Pool.Idle,
+			WaitCount:          st.Pool.WaitCount,
+			WaitDuration:       st.Pool.WaitDuration.String(),
+			MaxIdleClosed:      st.Pool.MaxIdleClosed,
+			MaxIdleTimeClosed:  st.Pool.MaxIdleTimeClosed,
+			MaxLifetimeClosed:  st.Pool.MaxLifetimeClosed,
+		},
 	}
 
 	return statResp, nil
@@ -78,78 +92,167 @@ func (qh *QueryHandler) GetStatus(ctx context.Context, req mcp.CallToolRequest,
 func (qh *QueryHandler) ExecuteQuery(ctx context.Context, req mcp.CallToolRequest, args types.QueryRequest) (*types.QueryResponse, error) {
 	log.Printf("execute_query handler got query %v with format %v", args.Query, args.Format)
 
-	// query must start with SELECT statement
-	err := utils.CheckFirstWord(args.Query)
+	conn, err := qh.repository.Get(args.Database)
 	if err != nil {
 		return nil, err
 	}
 
-	// Input is already validated and bound to SearchRequest struct
-	limit := args.Limit
-	if limit <= 0 {
-		limit = 10
+	// only a single read statement is accepted, the READ ONLY transaction of the client is the second line of defence
+	stmt, err := parseStatement(conn, args.Query, sqlparser.Read)
+	if err != nil {
+		return nil, fmt.Errorf("execute_query: %w", err)
 	}
 
-	// appending LIMIT into query
-	query := args.Query + fmt.Sprintf(" LIMIT %d ", limit)
+	// the query runs as written, the client asks for one row more than the limit to detect truncation
+	limit := rowLimit(conn.Policy, args.Limit)
+
+	timeout := qh.timeout(args.Timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ctx, stopProgress := qh.trackProgress(ctx, req)
+	defer stopProgress()
 
-	qResp, err := qh.repository.Postgress.ExecQuery(ctx, query, args.Parameters)
+	if args.Paginate {
+		return qh.queryWithCursor(ctx, conn, args, stmt.SQL, limit, timeout)
+	}
+
+	start := time.Now()
+	result, err := conn.Client.ExecQuery(ctx, stmt.SQL, args.Parameters, limit+1)
+	if errors.Is(err, database.ErrQueryTimeout) {
+		return nil, fmt.Errorf("%w: execute_query was cancelled after %s", database.ErrQueryTimeout, timeout)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("execute_query %v failed %v", args.Query, err)
 	}
-	formattedResp, err := formatData(args.Format, qResp)
+	elapsed := time.Since(start)
+
+	truncated := len(result.Rows) > limit
+	if truncated {
+		result.Rows = result.Rows[:limit]
+	}
+	response, err := qh.newQueryResponse(ctx, conn, args.Query, result, elapsed, args.Format)
 	if err != nil {
 		return nil, err
 	}
-
-	response := &types.QueryResponse{
-		Query:    args.Query,
-		Response: formattedResp,
-		Format:   args.Format,
-	}
+	response.Truncated = truncated
 	return response, nil
 }
 
 func (qh *QueryHandler) ExecutePrepared(ctx context.Context, req mcp.CallToolRequest, args types.PreparedRequest) (*types.QueryResponse, error) {
 	log.Printf("execute_prepared handler got query %v with format %v", args.StatementName, args.Format)
-	qResp, err := qh.repository.Postgress.ExecPrepared(ctx, args.StatementName, args.Parameters)
+	conn, err := qh.repository.Get(args.Database)
+	if err != nil {
+		return nil, err
+	}
+	if conn.Policy.ReadOnly {
+		return nil, fmt.Errorf("execute_prepared is not allowed, database %q is read only", conn.Name)
+	}
+	stmt, err := parseStatement(conn, args.StatementName)
+	if err != nil {
+		return nil, fmt.Errorf("execute_prepared: %w", err)
+	}
+	start := time.Now()
+	result, err := conn.Client.ExecPrepared(ctx, stmt.SQL, args.Parameters)
 	if err != nil {
 		return nil, fmt.Errorf("execute_prepared %v failed %v", args.StatementName, err)
 	}
-	formattedResp, err := formatData(args.Format, qResp)
+	return qh.newQueryResponse(ctx, conn, args.StatementName, result, time.Since(start), args.Format)
+}
+
+// newQueryResponse builds the tool response of a result, Response is only rendered when a format is given.
+// A result above the result_rows threshold is stored as a resource, the response holds a preview of its rows.
+func (qh *QueryHandler) newQueryResponse(ctx context.Context, conn *repository.Connection, query string, result *types.ResultSet, elapsed time.Duration, format string) (*types.QueryResponse, error) {
+	rowCount := len(result.Rows)
+	resultID, result := qh.storeResult(ctx, conn.Name, query, result)
+	var notice string
+	if resultID == "" && len(result.Rows) < rowCount {
+		notice = fmt.Sprintf("the rows are a preview, the whole result of %d rows could not be kept, run the query with a lower limit or paginate", rowCount)
+	}
+	response := &types.QueryResponse{
+		Database: conn.Name,
+		Query:    query,
+		Columns:  result.Columns,
+		Rows:     result.Rows,
+		RowCount: rowCount,
+		Elapsed:  elapsed.String(),
+		ResultID: resultID,
+		Notice:   notice,
+		Format:   format,
+	}
+	f, formattedResp, err := formatData(format, result)
 	if err != nil {
 		return nil, err
 	}
-	response := &types.QueryResponse{
-		Query:    args.StatementName,
-		Response: formattedResp,
-		Format:   args.Format,
+	if f != nil && f.Binary() {
+		response.File = formattedResp
+	} else {
+		response.Response = string(formattedResp)
 	}
 	return response, nil
 }
 
-func formatData(format string, data []map[string]interface{}) (string, error) {
-	switch format {
-	case "json":
-		formattedResp, err := utils.DataToJson(data)
-		if err != nil {
-			return "", fmt.Errorf("failed to encode execute_query response to JSON format %v", err)
-		}
-		return formattedResp, nil
-	case "csv":
-		formattedResp, err := utils.DataToCSV(data)
-		if err != nil {
-			return "", fmt.Errorf("failed to encode execute_query response to CSV format %v", err)
-		}
-		return formattedResp, nil
-	case "table":
-		formattedResp, err := utils.DataToHTMLTable(data)
-		if err != nil {
-			return "", fmt.Errorf("failed to encode execute_query response to HTML table format %v", err)
+// parseStatement parses a single statement in the dialect of the connection's driver,
+// it's rejected unless it's one of the allowed kinds when any are given
+func parseStatement(conn *repository.Connection, sql string, allowed ...sqlparser.Kind) (*sqlparser.Statement, error) {
+	stmt, err := sqlparser.Parse(sql, sqlparser.Dialect(conn.Driver))
+	if err != nil {
+		return nil, err
+	}
+	if len(allowed) > 0 {
+		if err := stmt.Allow(allowed...); err != nil {
+			return nil, err
 		}
-		return formattedResp, nil
-	default:
-		return "", fmt.Errorf("format %v not supported", format)
 	}
+	return stmt, nil
+}
 
+// formatData renders the rows in a registered format, the formatter is nil when no format is given
+func formatData(format string, data *types.ResultSet) (utils.Formatter, []byte, error) {
+	if format == "" {
+		return nil, nil, nil
+	}
+	f, err := lookupFormat(format)
+	if err != nil {
+		return nil, nil, err
+	}
+	formattedResp, err := f.Format(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode execute_query response to %s format %v", f.Name(), err)
+	}
+	return f, formattedResp, nil
+}
+
+// lookupFormat returns the formatter of a format, it's checked before rows are read so a bad format costs no rows
+func lookupFormat(format string) (utils.Formatter, error) {
+	f, ok := utils.LookupFormatter(format)
+	if !ok {
+		return nil, fmt.Errorf("format %v not supported (supported: %s)", format, strings.Join(utils.FormatNames(), ", "))
+	}
+	return f, nil
+}
+
+// timeout returns the requested timeout in seconds clamped to the maximum, or the default when none is requested
+func (qh *QueryHandler) timeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		return qh.queryTimeout
+	}
+	return min(time.Duration(seconds)*time.Second, qh.maxQueryTimeout)
+}
+
+// rowLimit returns the requested execute_query row limit, or the policy's default_limit when the request has none,
+// capped by the policy's max_rows
+func rowLimit(policy config.PolicyConfig, requested int) int {
+	maxRows := policy.MaxRows
+	if maxRows <= 0 {
+		maxRows = config.DefaultMaxRows
+	}
+	limit := requested
+	if limit <= 0 {
+		limit = policy.DefaultLimit
+	}
+	if limit <= 0 {
+		limit = config.DefaultLimit
+	}
+	return min(limit, maxRows)
 }
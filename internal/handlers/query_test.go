@@ -2,10 +2,11 @@ package handlers_test
 
 import (
 	"context"
-	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"exmple.com/database-query-server/internal/config"
 	"exmple.com/database-query-server/internal/database"
 	"exmple.com/database-query-server/internal/handlers"
 	"exmple.com/database-query-server/internal/repository"
@@ -14,6 +15,24 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// newTestRepository returns a repository with client registered under name as a postgres database
+func newTestRepository(t *testing.T, name string, client database.ClientInterface) *repository.Repository {
+	t.Helper()
+	repo := repository.NewRepository()
+	if err := repo.Register(config.DatabaseConfig{Name: name, Driver: config.DriverPostgres}, client); err != nil {
+		t.Fatalf("failed to register %v database: %v", name, err)
+	}
+	return repo
+}
+
+// withoutElapsed clears the elapsed time of a response after checking it's set, it differs on every run
+func withoutElapsed(t *testing.T, resp *types.QueryResponse) *types.QueryResponse {
+	t.Helper()
+	assert.NotEmpty(t, resp.Elapsed)
+	resp.Elapsed = ""
+	return resp
+}
+
 func TestQueryHandler_ExecuteQuery(t *testing.T) {
 
 	//mocked table rows
@@ -63,12 +82,42 @@ func TestQueryHandler_ExecuteQuery(t *testing.T) {
 		Format:   "table",
 	}
 
+	reqToMarkdown := types.QueryRequest{
+		Database: "postgres",
+		Query:    "SELECT * FROM customers",
+		Format:   "markdown",
+	}
+
+	reqUnknownDatabase := types.QueryRequest{
+		Database: "analytics",
+		Query:    "SELECT * FROM customers",
+		Format:   "json",
+	}
+
 	reqInvalidQuery := types.QueryRequest{
 		Database: "postgres",
 		Query:    "SELECT_INJ SELECT * FROM customers",
 		Format:   "table",
 	}
 
+	reqLowercaseCTE := types.QueryRequest{
+		Database: "postgres",
+		Query:    "with c as (select * from customers) select * from c;",
+		Format:   "json",
+	}
+
+	reqMultipleStatements := types.QueryRequest{
+		Database: "postgres",
+		Query:    "SELECT * FROM customers; DROP TABLE customers",
+		Format:   "json",
+	}
+
+	reqWrite := types.QueryRequest{
+		Database: "postgres",
+		Query:    "DELETE FROM customers",
+		Format:   "json",
+	}
+
 	args := make(map[string]interface{})
 	args["databse"] = "sql"
 	args["query"] = "SELECT * FROM customers"
@@ -84,29 +133,59 @@ func TestQueryHandler_ExecuteQuery(t *testing.T) {
 		},
 	}
 
+	// the mock returns the columns sorted by name
+	columns := []types.Column{{Name: "Address"}, {Name: "City"}, {Name: "ContactName"}, {Name: "Country"}, {Name: "CustomerName"}, {Name: "PostalCode"}, {Name: "id"}}
+	rows := [][]any{{"Some street in London", "London", "Bob mum", "UK", "Bob", "1ld12", "1"}}
+
 	expected := types.QueryResponse{
+		Database: "postgres",
 		Query:    "SELECT * FROM customers",
+		Columns:  columns,
+		Rows:     rows,
+		RowCount: 1,
 		Response: `[{"Address":"Some street in London","City":"London","ContactName":"Bob mum","Country":"UK","CustomerName":"Bob","PostalCode":"1ld12","id":"1"}]`,
 		Format:   "json",
 	}
 	expectedCSVOutput := types.QueryResponse{
+		Database: "postgres",
 		Query:    "SELECT * FROM customers",
+		Columns:  columns,
+		Rows:     rows,
+		RowCount: 1,
 		Response: "Address,City,ContactName,Country,CustomerName,PostalCode,id\nSome street in London,London,Bob mum,UK,Bob,1ld12,1\n",
 		Format:   "csv",
 	}
 
 	expectedCSVOutputWithDifferentTypes := types.QueryResponse{
+		Database: "postgres",
 		Query:    "SELECT * FROM customers",
+		Columns:  []types.Column{{Name: "Address"}, {Name: "City"}, {Name: "ContactName"}, {Name: "Country"}, {Name: "PostalCode"}, {Name: "id"}, {Name: "price"}},
+		Rows:     [][]any{{nil, "Dublin", true, "Ireland", "1dbld12", 22, 123.78}},
+		RowCount: 1,
 		Response: "Address,City,ContactName,Country,PostalCode,id,price\n,Dublin,true,Ireland,1dbld12,22,123.78\n",
 		Format:   "csv",
 	}
 
 	expectedTableOutput := types.QueryResponse{
+		Database: "postgres",
 		Query:    "SELECT * FROM customers",
+		Columns:  columns,
+		Rows:     rows,
+		RowCount: 1,
 		Response: "<table><thead><tr><th>Address</th><th>City</th><th>ContactName</th><th>Country</th><th>CustomerName</th><th>PostalCode</th><th>id</th></tr></thead><tbody><tr><td>Some street in London</td><td>London</td><td>Bob mum</td><td>UK</td><td>Bob</td><td>1ld12</td><td>1</td></tr></tbody></table>",
 		Format:   "table",
 	}
 
+	expectedMarkdownOutput := types.QueryResponse{
+		Database: "postgres",
+		Query:    "SELECT * FROM customers",
+		Columns:  columns,
+		Rows:     rows,
+		RowCount: 1,
+		Response: "| Address | City | ContactName | Country | CustomerName | PostalCode | id |\n| --- | --- | --- | --- | --- | --- | --- |\n| Some street in London | London | Bob mum | UK | Bob | 1ld12 | 1 |\n",
+		Format:   "markdown",
+	}
+
 	expectedInvalidQueryErr := types.QueryResponse{
 		Query:    "SELECT * FROM customers",
 		Response: "---",
@@ -126,14 +205,19 @@ func TestQueryHandler_ExecuteQuery(t *testing.T) {
 		{name: "Happy Flow execute_query - to CSV export with different Postgres types", req: request, args: reqArgsCVS, tableMock: mtblWithDifferentTypes, want: &expectedCSVOutputWithDifferentTypes, wantErr: false},
 		{name: "Fail execute_query - invalid format", req: request, args: reqArgsInvalidFormat, tableMock: mtbl, want: &expectedCSVOutput, wantErr: true},
 		{name: "Happy Flow execute_query - export to HTML table", req: request, args: reqToTable, tableMock: mtbl, want: &expectedTableOutput, wantErr: false},
+		{name: "Happy Flow execute_query - export to Markdown table", req: request, args: reqToMarkdown, tableMock: mtbl, want: &expectedMarkdownOutput, wantErr: false},
 		{name: "Fail execute_query - query must start with SELECT statement", req: request, args: reqInvalidQuery, tableMock: mtbl, want: &expectedInvalidQueryErr, wantErr: true},
+		{name: "Fail execute_query - unknown database", req: request, args: reqUnknownDatabase, tableMock: mtbl, want: &expected, wantErr: true},
+		{name: "Happy Flow execute_query - lowercase CTE", req: request, args: reqLowercaseCTE, tableMock: mtbl, want: &types.QueryResponse{Database: "postgres", Query: reqLowercaseCTE.Query, Columns: columns, Rows: rows, RowCount: 1, Response: expected.Response, Format: "json"}, wantErr: false},
+		{name: "Fail execute_query - multiple statements", req: request, args: reqMultipleStatements, tableMock: mtbl, wantErr: true},
+		{name: "Fail execute_query - write statement", req: request, args: reqWrite, tableMock: mtbl, wantErr: true},
 
 		// Add execute_query test
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			pg, _ := database.NewPostgresClientMock(tt.tableMock, false)
-			repo := &repository.Repository{Postgress: pg}
+			repo := newTestRepository(t, "postgres", pg)
 			qh := handlers.NewQueryHandler(repo)
 			got, gotErr := qh.ExecuteQuery(context.Background(), tt.req, tt.args)
 			if gotErr != nil {
@@ -146,10 +230,17 @@ func TestQueryHandler_ExecuteQuery(t *testing.T) {
 				t.Fatal("ExecuteQuery() succeeded unexpectedly")
 			}
 			if true {
-				assert.EqualValues(t, tt.want, got)
+				assert.EqualValues(t, tt.want, withoutElapsed(t, got))
 			}
 		})
 	}
+
+	t.Run("Fail execute_query - rejection reason", func(t *testing.T) {
+		pg, _ := database.NewPostgresClientMock(mtbl, false)
+		qh := handlers.NewQueryHandler(newTestRepository(t, "postgres", pg))
+		_, gotErr := qh.ExecuteQuery(context.Background(), request, reqWrite)
+		assert.EqualError(t, gotErr, "execute_query: statement rejected (not_allowed): DELETE is a dml statement, allowed: read")
+	})
 }
 
 func TestQueryHandler_GetSchema(t *testing.T) {
@@ -167,12 +258,12 @@ func TestQueryHandler_GetSchema(t *testing.T) {
 	reqArgs := types.SchemaRequest{
 		Database: "postgres",
 		Tables:   tbls,
-		Detailed: true,
 	}
+	detailedArgs := reqArgs
+	detailedArgs.Detailed = true
 	args := make(map[string]interface{})
 	args["database"] = "postgres"
 	args["tables"] = tbls
-	args["detailed"] = true
 
 	request := mcp.CallToolRequest{
 		Params: mcp.CallToolParams{
@@ -181,10 +272,47 @@ func TestQueryHandler_GetSchema(t *testing.T) {
 		},
 	}
 
+	length := int64(200)
+	tables := []types.TableSchema{{Schema: "public", Name: "customers", Columns: []types.ColumnSchema{
+		{Name: "customername", DataType: "character varying", Nullable: true, Position: 1, MaxLength: &length},
+	}}}
 	expected := types.QueryResponse{
+		Database: "postgres",
 		Query:    "get_schema",
-		Response: `[{"character_maximum_length":200,"column_name":"customername","data_type":"character varying"}]`,
+		Columns: []types.Column{
+			{Name: "table_name", Type: "TEXT"}, {Name: "column_name", Type: "TEXT"}, {Name: "data_type", Type: "TEXT"},
+			{Name: "character_maximum_length", Type: "INT8"},
+		},
+		Rows:     [][]any{{"public.customers", "customername", "character varying", int64(200)}},
+		RowCount: 1,
+		Response: `[{"table_name":"public.customers","column_name":"customername","data_type":"character varying","character_maximum_length":200}]`,
 		Format:   "json",
+		Tables:   tables,
+	}
+	expectedDetailed := types.QueryResponse{
+		Database: "postgres",
+		Query:    "get_schema",
+		Columns: []types.Column{
+			{Name: "table_name", Type: "TEXT"}, {Name: "column_name", Type: "TEXT"}, {Name: "data_type", Type: "TEXT"},
+			{Name: "is_nullable", Type: "BOOL"}, {Name: "column_default", Type: "TEXT"}, {Name: "ordinal_position", Type: "INT4"},
+			{Name: "is_primary_key", Type: "BOOL"}, {Name: "references", Type: "TEXT"}, {Name: "column_comment", Type: "TEXT"},
+		},
+		Rows:     [][]any{{"public.customers", "customername", "character varying", true, nil, int64(1), false, nil, nil}},
+		RowCount: 1,
+		Response: `[{"table_name":"public.customers","column_name":"customername","data_type":"character varying","is_nullable":true,"column_default":null,"ordinal_position":1,"is_primary_key":false,"references":null,"column_comment":null}]`,
+		Format:   "json",
+		Tables:   tables,
+	}
+	patternArgs := reqArgs
+	patternArgs.Tables = []string{"cust*"}
+	expectedMissing := types.QueryResponse{
+		Database: "postgres",
+		Query:    "get_schema",
+		Columns:  expected.Columns,
+		Rows:     nil,
+		Response: `[]`,
+		Format:   "json",
+		Missing:  []string{"cust*"},
 	}
 	tests := []struct {
 		name       string
@@ -196,11 +324,13 @@ func TestQueryHandler_GetSchema(t *testing.T) {
 		wantErr    bool
 	}{
 		{name: "Happy Flow - GetSchema", req: request, args: reqArgs, tableMock: mtbl, want: &expected, wantErr: false},
+		{name: "Happy Flow - GetSchema detailed", req: request, args: detailedArgs, tableMock: mtbl, want: &expectedDetailed, wantErr: false},
+		{name: "Happy Flow - GetSchema pattern without matches", req: request, args: patternArgs, tableMock: mtbl, want: &expectedMissing, wantErr: false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			pg, _ := database.NewPostgresClientMock(tt.tableMock, false)
-			repo := &repository.Repository{Postgress: pg}
+			repo := newTestRepository(t, "postgres", pg)
 			qh := handlers.NewQueryHandler(repo)
 			got, gotErr := qh.GetSchema(context.Background(), tt.req, tt.args)
 			if gotErr != nil {
@@ -213,21 +343,13 @@ func TestQueryHandler_GetSchema(t *testing.T) {
 				t.Fatal("GetSchema() succeeded unexpectedly")
 			}
 			if true {
-				assert.EqualValues(t, tt.want, got)
+				assert.EqualValues(t, tt.want, withoutElapsed(t, got))
 			}
 		})
 	}
 }
 
 func TestQueryHandler_GetStatus(t *testing.T) {
-	timNow := time.Now()
-	var mtbl []map[string]interface{}
-	row2 := make(map[string]interface{})
-	row2["numbackends"] = int64(20)
-	row2["state_change"] = timNow
-	row2["character_maximum_length"] = int64(200)
-	mtbl = append(mtbl, row2)
-
 	reqArgs := types.ConnectionStatus{
 		Database: "mcp-db",
 	}
@@ -240,62 +362,54 @@ func TestQueryHandler_GetStatus(t *testing.T) {
 	}
 
 	expected := types.ConnectionStatusResp{
-		Database:  "mcp-db",
-		Connected: true,
-		PoolStats: 20,
-		LastPing:  timNow.Format(time.RFC3339),
-	}
-
-	reqArgsEmpty := types.ConnectionStatus{
-		Database: "",
-	}
-
-	requestFail := mcp.CallToolRequest{
-		Params: mcp.CallToolParams{
-			Name:      "get_connection_status",
-			Arguments: reqArgsEmpty,
+		Database:      "mcp-db",
+		Connected:     true,
+		ServerVersion: "16.4",
+		PingLatency:   "1.5ms",
+		LastPing:      "2025-01-02T03:04:05Z",
+		Pool: &types.PoolStats{
+			MaxOpenConnections: 10,
+			OpenConnections:    2,
+			InUse:              1,
+			Idle:               1,
+			WaitDuration:       "0s",
 		},
 	}
 
-	var mtbl1 []map[string]interface{}
-	rowErr := make(map[string]interface{})
-	rowErr["numbackends"] = 200
-	rowErr["state_change"] = timNow
-	rowErr["character_maximum_length"] = int64(200)
-	mtbl1 = append(mtbl1, rowErr)
-
-	expectedErr := fmt.Errorf("failed converting connection pool status")
+	expectedDown := types.ConnectionStatusResp{
+		Database:  "mcp-db",
+		Connected: false,
+		Reason:    "dial tcp 127.0.0.1:5432: connect: connection refused",
+	}
 
 	tests := []struct {
-		name       string
-		repository *repository.Repository
-		tableMock  []map[string]interface{}
-		req        mcp.CallToolRequest
-		args       types.ConnectionStatus
-		want       *types.ConnectionStatusResp
-		wantErr    bool
+		name            string
+		simulateFailure bool
+		args            types.ConnectionStatus
+		want            *types.ConnectionStatusResp
+		wantErr         bool
 	}{
-		{name: "Happy Flow - GetStatus", req: request, args: reqArgs, tableMock: mtbl, want: &expected, wantErr: false},
-		{name: "Sad Flow - GetStatus connection pool status error", req: requestFail, args: reqArgsEmpty, tableMock: mtbl1, want: &expected, wantErr: false},
+		{name: "Happy Flow - GetStatus", args: reqArgs, want: &expected, wantErr: false},
+		{name: "Happy Flow - GetStatus of the default database", args: types.ConnectionStatus{}, want: &expected, wantErr: false},
+		{name: "Happy Flow - GetStatus database is down", simulateFailure: true, args: reqArgs, want: &expectedDown, wantErr: false},
+		{name: "Sad Flow - GetStatus unknown database", args: types.ConnectionStatus{Database: "missing"}, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			pg, _ := database.NewPostgresClientMock(tt.tableMock, false)
-			repo := &repository.Repository{Postgress: pg}
+			pg, _ := database.NewPostgresClientMock(nil, tt.simulateFailure)
+			repo := newTestRepository(t, "mcp-db", pg)
 			qh := handlers.NewQueryHandler(repo)
-			got, gotErr := qh.GetStatus(context.Background(), tt.req, tt.args)
+			got, gotErr := qh.GetStatus(context.Background(), request, tt.args)
 			if gotErr != nil {
 				if !tt.wantErr {
-					assert.EqualValues(t, expectedErr, gotErr)
+					t.Errorf("GetStatus() failed: %v", gotErr)
 				}
 				return
 			}
 			if tt.wantErr {
 				t.Fatal("GetStatus() succeeded unexpectedly")
 			}
-			if true {
-				assert.EqualValues(t, tt.want, got)
-			}
+			assert.EqualValues(t, tt.want, got)
 		})
 	}
 }
@@ -370,20 +484,35 @@ func TestQueryHandler_ExecutePrepared(t *testing.T) {
 		Format:        "table",
 	}
 
+	columns := []types.Column{{Name: "Address"}, {Name: "City"}, {Name: "ContactName"}, {Name: "Country"}, {Name: "CustomerName"}, {Name: "PostalCode"}, {Name: "id"}}
+	rows := [][]any{{"Some street in London", "London", "Bob mum", "UK", "Bob", "1ld12", "1"}}
+
 	expected := types.QueryResponse{
+		Database: "mcp-db",
 		Query:    query,
+		Columns:  columns,
+		Rows:     rows,
+		RowCount: 1,
 		Response: `[{"Address":"Some street in London","City":"London","ContactName":"Bob mum","Country":"UK","CustomerName":"Bob","PostalCode":"1ld12","id":"1"}]`,
 		Format:   "json",
 	}
 
 	expectedCSV := types.QueryResponse{
+		Database: "mcp-db",
 		Query:    query,
+		Columns:  columns,
+		Rows:     rows,
+		RowCount: 1,
 		Response: "Address,City,ContactName,Country,CustomerName,PostalCode,id\nSome street in London,London,Bob mum,UK,Bob,1ld12,1\n",
 		Format:   "csv",
 	}
 
 	expectedTable := types.QueryResponse{
+		Database: "mcp-db",
 		Query:    query,
+		Columns:  columns,
+		Rows:     rows,
+		RowCount: 1,
 		Response: "<table><thead><tr><th>Address</th><th>City</th><th>ContactName</th><th>Country</th><th>CustomerName</th><th>PostalCode</th><th>id</th></tr></thead><tbody><tr><td>Some street in London</td><td>London</td><td>Bob mum</td><td>UK</td><td>Bob</td><td>1ld12</td><td>1</td></tr></tbody></table>",
 		Format:   "table",
 	}
@@ -404,7 +533,7 @@ func TestQueryHandler_ExecutePrepared(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			pg, _ := database.NewPostgresClientMock(tt.tableMock, false)
-			repo := &repository.Repository{Postgress: pg}
+			repo := newTestRepository(t, "mcp-db", pg)
 			qh := handlers.NewQueryHandler(repo)
 			got, gotErr := qh.ExecutePrepared(context.Background(), tt.req, tt.args)
 			if gotErr != nil {
@@ -417,8 +546,296 @@ func TestQueryHandler_ExecutePrepared(t *testing.T) {
 				t.Fatal("ExecutePrepared() succeeded unexpectedly")
 			}
 			if true {
-				assert.EqualValues(t, tt.want, got)
+				assert.EqualValues(t, tt.want, withoutElapsed(t, got))
 			}
 		})
 	}
+
+	t.Run("Sad Flow execute_prepared - read only database", func(t *testing.T) {
+		pg, _ := database.NewPostgresClientMock(mtblMock, false)
+		repo := repository.NewRepository()
+		if err := repo.Register(config.DatabaseConfig{Name: "mcp-db", Driver: config.DriverPostgres, Policy: config.PolicyConfig{ReadOnly: true}}, pg); err != nil {
+			t.Fatalf("failed to register mcp-db database: %v", err)
+		}
+		qh := handlers.NewQueryHandler(repo)
+		_, gotErr := qh.ExecutePrepared(context.Background(), request, reqArgs)
+		assert.EqualError(t, gotErr, `execute_prepared is not allowed, database "mcp-db" is read only`)
+	})
+}
+
+func TestQueryHandler_SQLite(t *testing.T) {
+	// The whole handler stack against a SQLite database file, no Docker required
+	client, err := database.NewClient(config.DatabaseConfig{
+		Name:   "local",
+		Driver: config.DriverSQLite,
+		DBName: filepath.Join(t.TempDir(), "handlers.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	repo := repository.NewRepository()
+	if err := repo.Register(config.DatabaseConfig{Name: "local", Driver: config.DriverSQLite}, client); err != nil {
+		t.Fatalf("failed to register local database: %v", err)
+	}
+	qh := handlers.NewQueryHandler(repo, handlers.WithQueryTimeout(100*time.Millisecond, time.Second))
+	ctx := context.Background()
+
+	statements := []types.PreparedRequest{
+		{Database: "local", StatementName: "CREATE TABLE customers (id INTEGER PRIMARY KEY, name VARCHAR(200), country TEXT)", Format: "json"},
+		{Database: "local", StatementName: "INSERT INTO customers (id, name, country) VALUES (?, ?, ?)", Parameters: []any{1, "Bob", "UK"}, Format: "json"},
+		{Database: "local", StatementName: "INSERT INTO customers (id, name, country) VALUES (?, ?, ?)", Parameters: []any{2, "Anna", "IE"}, Format: "json"},
+	}
+	for _, stmt := range statements {
+		if _, err := qh.ExecutePrepared(ctx, mcp.CallToolRequest{}, stmt); err != nil {
+			t.Fatalf("ExecutePrepared() failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		args    types.QueryRequest
+		want    *types.QueryResponse
+		wantErr bool
+	}{
+		{
+			name: "Happy Flow execute_query - JSON format keeps the column order",
+			args: types.QueryRequest{Database: "local", Query: "SELECT name, id FROM customers WHERE country = ?", Parameters: map[string]any{"1": "IE"}, Format: "json"},
+			want: &types.QueryResponse{
+				Database: "local",
+				Query:    "SELECT name, id FROM customers WHERE country = ?",
+				Columns:  []types.Column{{Name: "name", Type: "VARCHAR(200)"}, {Name: "id", Type: "INTEGER"}},
+				Rows:     [][]any{{"Anna", int64(2)}},
+				RowCount: 1,
+				Response: `[{"name":"Anna","id":2}]`,
+				Format:   "json",
+			},
+		},
+		{
+			name: "Happy Flow execute_query - no format returns only the rows",
+			args: types.QueryRequest{Database: "local", Query: "SELECT id FROM customers WHERE id = 0"},
+			want: &types.QueryResponse{Database: "local", Query: "SELECT id FROM customers WHERE id = 0", Columns: []types.Column{{Name: "id", Type: "INTEGER"}}, Rows: [][]any{}},
+		},
+		{
+			name: "Happy Flow execute_query - CSV format with limit",
+			args: types.QueryRequest{Database: "local", Query: "SELECT id, name FROM customers ORDER BY id", Format: "csv", Limit: 1},
+			want: &types.QueryResponse{
+				Database:  "local",
+				Query:     "SELECT id, name FROM customers ORDER BY id",
+				Columns:   []types.Column{{Name: "id", Type: "INTEGER"}, {Name: "name", Type: "VARCHAR(200)"}},
+				Rows:      [][]any{{int64(1), "Bob"}},
+				RowCount:  1,
+				Truncated: true,
+				Response:  "id,name\n1,Bob\n",
+				Format:    "csv",
+			},
+		},
+		{
+			name: "Happy Flow execute_query - own LIMIT, semicolon and trailing comment",
+			args: types.QueryRequest{Database: "local", Query: "SELECT id FROM customers ORDER BY id LIMIT 5 OFFSET 1; -- skip the first", Format: "csv", Limit: 1},
+			want: &types.QueryResponse{
+				Database: "local",
+				Query:    "SELECT id FROM customers ORDER BY id LIMIT 5 OFFSET 1; -- skip the first",
+				Columns:  []types.Column{{Name: "id", Type: "INTEGER"}},
+				Rows:     [][]any{{int64(2)}},
+				RowCount: 1,
+				Response: "id\n2\n",
+				Format:   "csv",
+			},
+		},
+		{
+			name:    "Sad Flow execute_query - table doesn't exist",
+			args:    types.QueryRequest{Database: "local", Query: "SELECT * FROM orders", Format: "json"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := qh.ExecuteQuery(ctx, mcp.CallToolRequest{}, tt.args)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("ExecuteQuery() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("ExecuteQuery() succeeded unexpectedly")
+			}
+			assert.EqualValues(t, tt.want, withoutElapsed(t, got))
+		})
+	}
+
+	t.Run("Happy Flow execute_query - limit capped by max_rows", func(t *testing.T) {
+		capped := repository.NewRepository()
+		if err := capped.Register(config.DatabaseConfig{Name: "local", Driver: config.DriverSQLite, Policy: config.PolicyConfig{MaxRows: 1}}, client); err != nil {
+			t.Fatalf("failed to register local database: %v", err)
+		}
+		got, gotErr := handlers.NewQueryHandler(capped).ExecuteQuery(ctx, mcp.CallToolRequest{}, types.QueryRequest{Database: "local", Query: "SELECT id FROM customers ORDER BY id", Format: "csv", Limit: 100})
+		if gotErr != nil {
+			t.Fatalf("ExecuteQuery() failed: %v", gotErr)
+		}
+		assert.Equal(t, 1, got.RowCount)
+		assert.True(t, got.Truncated)
+		assert.Equal(t, "id\n1\n", got.Response)
+	})
+
+	t.Run("Happy Flow execute_query - paginate with fetch_more", func(t *testing.T) {
+		query := "SELECT id, name FROM customers ORDER BY id"
+		columns := []types.Column{{Name: "id", Type: "INTEGER"}, {Name: "name", Type: "VARCHAR(200)"}}
+
+		first, gotErr := qh.ExecuteQuery(ctx, mcp.CallToolRequest{}, types.QueryRequest{Database: "local", Query: query, Limit: 1, Paginate: true})
+		if gotErr != nil {
+			t.Fatalf("ExecuteQuery() failed: %v", gotErr)
+		}
+		assert.NotEmpty(t, first.Cursor)
+		assert.EqualValues(t, &types.QueryResponse{Database: "local", Query: query, Columns: columns, Rows: [][]any{{int64(1), "Bob"}}, RowCount: 1, Truncated: true, Cursor: first.Cursor}, withoutElapsed(t, first))
+
+		// the last page closes the cursor
+		second, gotErr := qh.FetchMore(ctx, mcp.CallToolRequest{}, types.FetchMoreRequest{Cursor: first.Cursor, Limit: 5, Format: "csv"})
+		if gotErr != nil {
+			t.Fatalf("FetchMore() failed: %v", gotErr)
+		}
+		assert.EqualValues(t, &types.QueryResponse{Database: "local", Query: query, Columns: columns, Rows: [][]any{{int64(2), "Anna"}}, RowCount: 1, Format: "csv", Response: "id,name\n2,Anna\n"}, withoutElapsed(t, second))
+
+		_, gotErr = qh.FetchMore(ctx, mcp.CallToolRequest{}, types.FetchMoreRequest{Cursor: first.Cursor})
+		assert.ErrorContains(t, gotErr, "unknown or expired")
+	})
+
+	t.Run("Sad Flow fetch_more - unknown format skips no rows", func(t *testing.T) {
+		query := "SELECT id FROM customers ORDER BY id"
+		first, gotErr := qh.ExecuteQuery(ctx, mcp.CallToolRequest{}, types.QueryRequest{Database: "local", Query: query, Limit: 1, Paginate: true})
+		if gotErr != nil {
+			t.Fatalf("ExecuteQuery() failed: %v", gotErr)
+		}
+
+		_, gotErr = qh.FetchMore(ctx, mcp.CallToolRequest{}, types.FetchMoreRequest{Cursor: first.Cursor, Limit: 5, Format: "docx"})
+		assert.ErrorContains(t, gotErr, "format docx not supported")
+
+		second, gotErr := qh.FetchMore(ctx, mcp.CallToolRequest{}, types.FetchMoreRequest{Cursor: first.Cursor, Limit: 5, Format: "csv"})
+		if gotErr != nil {
+			t.Fatalf("FetchMore() failed: %v", gotErr)
+		}
+		assert.Equal(t, [][]any{{int64(2)}}, second.Rows)
+		assert.Equal(t, "id\n2\n", second.Response)
+	})
+
+	t.Run("Happy Flow execute_query - paginate without more rows returns no cursor", func(t *testing.T) {
+		got, gotErr := qh.ExecuteQuery(ctx, mcp.CallToolRequest{}, types.QueryRequest{Database: "local", Query: "SELECT id FROM customers", Limit: 5, Paginate: true})
+		if gotErr != nil {
+			t.Fatalf("ExecuteQuery() failed: %v", gotErr)
+		}
+		assert.Equal(t, 2, got.RowCount)
+		assert.Empty(t, got.Cursor)
+		assert.False(t, got.Truncated)
+	})
+
+	t.Run("Sad Flow execute_query - too many cursors", func(t *testing.T) {
+		limited := handlers.NewQueryHandler(repo, handlers.WithCursors(time.Minute, 1))
+		defer limited.Close()
+
+		args := types.QueryRequest{Database: "local", Query: "SELECT id FROM customers", Limit: 1, Paginate: true}
+		if _, gotErr := limited.ExecuteQuery(ctx, mcp.CallToolRequest{}, args); gotErr != nil {
+			t.Fatalf("ExecuteQuery() failed: %v", gotErr)
+		}
+		_, gotErr := limited.ExecuteQuery(ctx, mcp.CallToolRequest{}, args)
+		assert.ErrorContains(t, gotErr, "1 cursors are open")
+	})
+
+	t.Run("Sad Flow fetch_more - unknown cursor", func(t *testing.T) {
+		_, gotErr := qh.FetchMore(ctx, mcp.CallToolRequest{}, types.FetchMoreRequest{Cursor: "unknown"})
+		assert.ErrorContains(t, gotErr, `cursor "unknown" is unknown or expired`)
+	})
+
+	t.Run("Happy Flow get_schema", func(t *testing.T) {
+		got, gotErr := qh.GetSchema(ctx, mcp.CallToolRequest{}, types.SchemaRequest{Database: "local", Tables: []string{"customers"}})
+		if gotErr != nil {
+			t.Fatalf("GetSchema() failed: %v", gotErr)
+		}
+		length := int64(200)
+		expected := &types.QueryResponse{
+			Database: "local",
+			Query:    "get_schema",
+			Columns: []types.Column{
+				{Name: "table_name", Type: "TEXT"}, {Name: "column_name", Type: "TEXT"}, {Name: "data_type", Type: "TEXT"},
+				{Name: "character_maximum_length", Type: "INT8"},
+			},
+			Rows:     [][]any{{"main.customers", "id", "INTEGER", nil}, {"main.customers", "name", "VARCHAR(200)", int64(200)}, {"main.customers", "country", "TEXT", nil}},
+			RowCount: 3,
+			Response: `[{"table_name":"main.customers","column_name":"id","data_type":"INTEGER","character_maximum_length":null},{"table_name":"main.customers","column_name":"name","data_type":"VARCHAR(200)","character_maximum_length":200},{"table_name":"main.customers","column_name":"country","data_type":"TEXT","character_maximum_length":null}]`,
+			Format:   "json",
+			Tables: []types.TableSchema{{Schema: "main", Name: "customers", Columns: []types.ColumnSchema{
+				{Name: "id", DataType: "INTEGER", Position: 1},
+				{Name: "name", DataType: "VARCHAR(200)", Nullable: true, Position: 2, MaxLength: &length},
+				{Name: "country", DataType: "TEXT", Nullable: true, Position: 3},
+			}}},
+		}
+		assert.EqualValues(t, expected, withoutElapsed(t, got))
+	})
+
+	t.Run("Happy Flow get_schema - detailed", func(t *testing.T) {
+		got, gotErr := qh.GetSchema(ctx, mcp.CallToolRequest{}, types.SchemaRequest{Database: "local", Tables: []string{"customers"}, Detailed: true})
+		if gotErr != nil {
+			t.Fatalf("GetSchema() failed: %v", gotErr)
+		}
+		length := int64(200)
+		assert.Equal(t, []types.TableSchema{{
+			Schema: "main",
+			Name:   "customers",
+			Columns: []types.ColumnSchema{
+				{Name: "id", DataType: "INTEGER", Position: 1},
+				{Name: "name", DataType: "VARCHAR(200)", Nullable: true, Position: 2, MaxLength: &length},
+				{Name: "country", DataType: "TEXT", Nullable: true, Position: 3},
+			},
+			PrimaryKey: []string{"id"},
+		}}, got.Tables)
+		assert.Equal(t, []any{"main.customers", "id", "INTEGER", false, nil, int64(1), true, nil, nil}, got.Rows[0])
+		assert.Equal(t, 3, got.RowCount)
+	})
+
+	t.Run("Happy Flow get_schema - names, patterns and schemas", func(t *testing.T) {
+		if _, err := qh.ExecutePrepared(ctx, mcp.CallToolRequest{}, types.PreparedRequest{Database: "local", StatementName: "CREATE VIEW customer_names AS SELECT name FROM customers"}); err != nil {
+			t.Fatalf("ExecutePrepared() failed: %v", err)
+		}
+		tests := []struct {
+			name        string
+			args        types.SchemaRequest
+			wantTables  []string
+			wantMissing []string
+		}{
+			{name: "several tables", args: types.SchemaRequest{Tables: []string{"customer_names", "main.customers"}}, wantTables: []string{"main.customer_names", "main.customers"}},
+			{name: "glob pattern", args: types.SchemaRequest{Tables: []string{"cust*"}}, wantTables: []string{"main.customer_names", "main.customers"}},
+			{name: "table matched twice", args: types.SchemaRequest{Tables: []string{"customers", "main.cust?mers"}}, wantTables: []string{"main.customers"}},
+			{name: "every table of the schema", args: types.SchemaRequest{Schema: "main"}, wantTables: []string{"main.customer_names", "main.customers"}},
+			{name: "unqualified names in the schema", args: types.SchemaRequest{Schema: "temp", Tables: []string{"customers"}}, wantMissing: []string{"temp.customers"}},
+			{name: "missing tables", args: types.SchemaRequest{Tables: []string{"orders", "customers", "inv*"}}, wantTables: []string{"main.customers"}, wantMissing: []string{"inv*", "orders"}},
+			{name: "empty schema", args: types.SchemaRequest{Schema: "temp"}, wantMissing: []string{"temp.*"}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tt.args.Database = "local"
+				got, gotErr := qh.GetSchema(ctx, mcp.CallToolRequest{}, tt.args)
+				if gotErr != nil {
+					t.Fatalf("GetSchema() failed: %v", gotErr)
+				}
+				var names []string
+				for _, table := range got.Tables {
+					names = append(names, table.Schema+"."+table.Name)
+				}
+				assert.Equal(t, tt.wantTables, names)
+				assert.Equal(t, tt.wantMissing, got.Missing)
+			})
+		}
+	})
+
+	t.Run("Sad Flow get_schema - invalid pattern", func(t *testing.T) {
+		_, gotErr := qh.GetSchema(ctx, mcp.CallToolRequest{}, types.SchemaRequest{Database: "local", Tables: []string{"cust[omers"}})
+		assert.ErrorContains(t, gotErr, `invalid table pattern "cust[omers"`)
+	})
+
+	t.Run("Sad Flow execute_query - timeout", func(t *testing.T) {
+		// counts forever, cancelled by the handler's 100ms default timeout
+		query := "SELECT count(*) FROM (WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT x FROM c)"
+		_, gotErr := qh.ExecuteQuery(ctx, mcp.CallToolRequest{}, types.QueryRequest{Database: "local", Query: query, Format: "json"})
+		assert.ErrorIs(t, gotErr, database.ErrQueryTimeout)
+		assert.EqualError(t, gotErr, "query timeout: execute_query was cancelled after 100ms")
+	})
 }
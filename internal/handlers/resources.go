@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// SchemaURITemplate is the resource template of a table definition, the segments are path escaped
+	SchemaURITemplate = schemaScheme + "{database}/{schema}/{table}"
+	// SchemaIndexURI is the resource listing the tables of every database with their schema:// URIs
+	SchemaIndexURI = schemaScheme + "index"
+	schemaScheme   = "schema://"
+)
+
+// SchemaURI returns the schema://{database}/{schema}/{table} URI of a table
+func SchemaURI(database, schema, table string) string {
+	return schemaScheme + url.PathEscape(database) + "/" + url.PathEscape(schema) + "/" + url.PathEscape(table)
+}
+
+// ReadTableSchema returns the columns, keys, constraints, indexes and comment of a table as a
+// schema://{database}/{schema}/{table} resource in JSON
+func (qh *QueryHandler) ReadTableSchema(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	log.Printf("read resource %v", req.Params.URI)
+
+	segments := strings.Split(strings.TrimPrefix(req.Params.URI, schemaScheme), "/")
+	if !strings.HasPrefix(req.Params.URI, schemaScheme) || len(segments) != 3 {
+		return nil, fmt.Errorf("resource %q is not a table schema, expected %s", req.Params.URI, SchemaURITemplate)
+	}
+	for i, s := range segments {
+		unescaped, err := url.PathUnescape(s)
+		if err != nil || unescaped == "" {
+			return nil, fmt.Errorf("resource %q is not a table schema, expected %s", req.Params.URI, SchemaURITemplate)
+		}
+		segments[i] = unescaped
+	}
+	database, schema, table := segments[0], segments[1], segments[2]
+
+	conn, err := qh.repository.Get(database)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := conn.Client.DescribeTables(ctx, []string{schema + "." + table})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe table %s.%s of database %q: %v", schema, table, conn.Name, err)
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("table %s.%s of database %q doesn't exist", schema, table, conn.Name)
+	}
+	enco, err := json.Marshal(tables[0])
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{URI: req.Params.URI, MIMEType: "application/json", Text: string(enco)},
+	}, nil
+}
+
+// ReadSchemaIndex returns the tables and views of every schema of every database as the schema://index resource.
+// A database that can't be listed is reported in errors, the other ones are still indexed.
+func (qh *QueryHandler) ReadSchemaIndex(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	log.Printf("read resource %v", req.Params.URI)
+
+	index := types.SchemaIndex{Tables: []types.SchemaIndexEntry{}}
+	for _, name := range qh.repository.Names() {
+		entries, err := qh.indexDatabase(ctx, name)
+		if err != nil {
+			log.Printf("failed to index the tables of database %q: %v", name, err)
+			if index.Errors == nil {
+				index.Errors = map[string]string{}
+			}
+			index.Errors[name] = err.Error()
+			continue
+		}
+		index.Tables = append(index.Tables, entries...)
+	}
+	enco, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{URI: req.Params.URI, MIMEType: "application/json", Text: string(enco)},
+	}, nil
+}
+
+// indexDatabase returns the index entries of the tables of every schema of a database
+func (qh *QueryHandler) indexDatabase(ctx context.Context, name string) ([]types.SchemaIndexEntry, error) {
+	conn, err := qh.repository.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := conn.Client.ListSchemas(ctx, database.CatalogFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("list_schemas failed %v", err)
+	}
+	var entries []types.SchemaIndexEntry
+	for _, schema := range schemas {
+		tables, err := conn.Client.ListTables(ctx, schema.Name, database.CatalogFilter{})
+		if err != nil {
+			return nil, fmt.Errorf("list_tables for schema %q failed %v", schema.Name, err)
+		}
+		for _, t := range tables {
+			entries = append(entries, types.SchemaIndexEntry{URI: SchemaURI(conn.Name, t.Schema, t.Name), Database: conn.Name, TableInfo: t})
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,132 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/internal/handlers"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryHandler_SchemaResources(t *testing.T) {
+	client, err := database.NewClient(config.DatabaseConfig{
+		Name:   "local",
+		Driver: config.DriverSQLite,
+		DBName: filepath.Join(t.TempDir(), "schema.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE customers (id INTEGER PRIMARY KEY, name TEXT NOT NULL)",
+		`CREATE TABLE "order lines" (id INTEGER PRIMARY KEY, customer_id INTEGER REFERENCES customers (id))`,
+	} {
+		if _, err := client.ExecPrepared(context.Background(), stmt, nil); err != nil {
+			t.Fatalf("failed to populate database: %v", err)
+		}
+	}
+	repo := newTestRepository(t, "local", client)
+	// the index still lists the tables of local when another database is down
+	down, _ := database.NewPostgresClientMock(nil, true)
+	if err := repo.Register(config.DatabaseConfig{Name: "down", Driver: config.DriverPostgres}, down); err != nil {
+		t.Fatalf("failed to register down database: %v", err)
+	}
+
+	qh := handlers.NewQueryHandler(repo)
+	srv := server.NewMCPServer("test", "1.0.0", server.WithResourceCapabilities(true, true))
+	srv.AddResource(mcp.NewResource(handlers.SchemaIndexURI, "Schema index"), qh.ReadSchemaIndex)
+	srv.AddResourceTemplate(mcp.NewResourceTemplate(handlers.SchemaURITemplate, "Table schema"), qh.ReadTableSchema)
+	session := &testSession{id: "schema"}
+
+	type contents struct {
+		Contents []struct {
+			URI      string `json:"uri"`
+			MIMEType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"contents"`
+	}
+
+	t.Run("Happy Flow resources/read - index", func(t *testing.T) {
+		var read contents
+		if rpcErr := call(t, srv, session, "resources/read", map[string]any{"uri": "schema://index"}, &read); rpcErr != nil {
+			t.Fatalf("resources/read failed: %+v", rpcErr)
+		}
+		if !assert.Len(t, read.Contents, 1) {
+			return
+		}
+		assert.Equal(t, "application/json", read.Contents[0].MIMEType)
+		var index types.SchemaIndex
+		if err := json.Unmarshal([]byte(read.Contents[0].Text), &index); err != nil {
+			t.Fatalf("failed to decode index: %v", err)
+		}
+		var uris []string
+		for _, entry := range index.Tables {
+			assert.Equal(t, "local", entry.Database)
+			assert.Equal(t, "table", entry.Type)
+			uris = append(uris, entry.URI)
+		}
+		assert.Equal(t, []string{"schema://local/main/customers", "schema://local/main/order%20lines"}, uris)
+		assert.Contains(t, index.Errors["down"], "connection refused")
+	})
+
+	tests := []struct {
+		name    string
+		uri     string
+		want    types.TableSchema
+		wantErr bool
+	}{
+		{
+			name: "Happy Flow resources/read - table",
+			uri:  "schema://local/main/customers",
+			want: types.TableSchema{Schema: "main", Name: "customers", PrimaryKey: []string{"id"}, Columns: []types.ColumnSchema{
+				{Name: "id", DataType: "INTEGER", Position: 1},
+				{Name: "name", DataType: "TEXT", Position: 2},
+			}},
+		},
+		{
+			name: "Happy Flow resources/read - escaped table name",
+			uri:  handlers.SchemaURI("local", "main", "order lines"),
+			want: types.TableSchema{Schema: "main", Name: "order lines", PrimaryKey: []string{"id"}, Columns: []types.ColumnSchema{
+				{Name: "id", DataType: "INTEGER", Position: 1},
+				{Name: "customer_id", DataType: "INTEGER", Nullable: true, Position: 2},
+			}, ForeignKeys: []types.ForeignKey{
+				{Columns: []string{"customer_id"}, RefTable: "customers", RefColumns: []string{"id"}, OnUpdate: "NO ACTION", OnDelete: "NO ACTION"},
+			}},
+		},
+		{name: "Sad Flow resources/read - unknown table", uri: "schema://local/main/invoices", wantErr: true},
+		{name: "Sad Flow resources/read - unknown database", uri: "schema://other/main/customers", wantErr: true},
+		{name: "Sad Flow resources/read - not a table", uri: "schema://local/customers", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var read contents
+			rpcErr := call(t, srv, session, "resources/read", map[string]any{"uri": tt.uri}, &read)
+			if rpcErr != nil {
+				if !tt.wantErr {
+					t.Errorf("resources/read failed: %+v", rpcErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("resources/read succeeded unexpectedly")
+			}
+			if !assert.Len(t, read.Contents, 1) {
+				return
+			}
+			assert.Equal(t, tt.uri, read.Contents[0].URI)
+			assert.Equal(t, "application/json", read.Contents[0].MIMEType)
+			var got types.TableSchema
+			if err := json.Unmarshal([]byte(read.Contents[0].Text), &got); err != nil {
+				t.Fatalf("failed to decode table: %v", err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
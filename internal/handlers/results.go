@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"exmple.com/database-query-server/internal/utils"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// ResultURITemplate is the resource template of stored results, ext is the extension of a registered format
+	ResultURITemplate = resultScheme + "{id}.{ext}"
+	resultScheme      = "query-result://"
+	// embeddedURI names the binary rendering embedded in a tool result, it's outside resultScheme as the rows are only
+	// in the tool result and can't be read as a resource
+	embeddedURI = "file:///query-result."
+	// resultPreviewRows is the number of rows a response keeps when its result is stored
+	resultPreviewRows = 10
+)
+
+// storedResult is a result kept as a query-result resource, only the session that ran the query can read it
+type storedResult struct {
+	result   *types.ResultSet
+	database string
+	query    string
+	session  string
+}
+
+// storeResult keeps a result with more rows than the result_rows threshold as a resource and returns its id with a
+// preview of the first rows, results under the threshold are returned as they are. When the result can't be stored
+// only the preview is returned, the id is empty.
+func (qh *QueryHandler) storeResult(ctx context.Context, database, query string, result *types.ResultSet) (string, *types.ResultSet) {
+	if qh.resultRows < 0 || len(result.Rows) <= qh.resultRows {
+		return "", result
+	}
+	preview := &types.ResultSet{Columns: result.Columns, Rows: result.Rows[:min(resultPreviewRows, qh.resultRows)]}
+	id, err := qh.results.Put(&storedResult{result: result, database: database, query: query, session: sessionID(ctx)})
+	if err != nil {
+		log.Printf("failed to store the %d rows result of database %q, returning a preview: %v", len(result.Rows), database, err)
+		return "", preview
+	}
+	return id, preview
+}
+
+// ReadResult returns a stored result as a query-result://<id>.<ext> resource rendered in the format of ext
+func (qh *QueryHandler) ReadResult(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	log.Printf("read resource %v", req.Params.URI)
+
+	id, ext, ok := strings.Cut(strings.TrimPrefix(req.Params.URI, resultScheme), ".")
+	if !strings.HasPrefix(req.Params.URI, resultScheme) || !ok {
+		return nil, fmt.Errorf("resource %q is not a query result, expected %s", req.Params.URI, ResultURITemplate)
+	}
+	f, ok := utils.LookupFormatterByExt(ext)
+	if !ok {
+		return nil, fmt.Errorf("query result format %q is not supported (supported: %s)", ext, strings.Join(utils.FormatExts(), ", "))
+	}
+
+	stored, ok := qh.results.Get(id)
+	if !ok || stored.session != sessionID(ctx) {
+		return nil, fmt.Errorf("query result %q is unknown or expired, run the query again", id)
+	}
+	_, formattedResp, err := formatData(f.Name(), stored.result)
+	if err != nil {
+		return nil, err
+	}
+	if f.Binary() {
+		return []mcp.ResourceContents{
+			mcp.BlobResourceContents{URI: req.Params.URI, MIMEType: f.MimeType(), Blob: base64.StdEncoding.EncodeToString(formattedResp)},
+		}, nil
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{URI: req.Params.URI, MIMEType: f.MimeType(), Text: string(formattedResp)},
+	}, nil
+}
+
+// QueryToolHandler is mcp.NewStructuredToolHandler for tools returning a QueryResponse. A binary rendering of the rows
+// is added as an embedded resource, and when the result was stored a resource_link to it for every format.
+func QueryToolHandler[TArgs any](handler mcp.StructuredToolHandlerFunc[TArgs, *types.QueryResponse]) server.ToolHandlerFunc {
+	structured := mcp.NewStructuredToolHandler(handler)
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := structured(ctx, req)
+		if err != nil || result.IsError {
+			return result, err
+		}
+		resp, ok := result.StructuredContent.(*types.QueryResponse)
+		if !ok {
+			return result, nil
+		}
+		if f, ok := utils.LookupFormatter(resp.Format); ok && resp.File != nil {
+			result.Content = append(result.Content, mcp.NewEmbeddedResource(mcp.BlobResourceContents{
+				URI:      embeddedURI + f.Ext(),
+				MIMEType: f.MimeType(),
+				Blob:     base64.StdEncoding.EncodeToString(resp.File),
+			}))
+		}
+		if resp.ResultID != "" {
+			for _, f := range utils.Formatters() {
+				uri := resultScheme + resp.ResultID + "." + f.Ext()
+				description := fmt.Sprintf("All %d rows of %q as %s", resp.RowCount, resp.Query, f.Ext())
+				result.Content = append(result.Content, mcp.NewResourceLink(uri, "query-result."+f.Ext(), description, f.MimeType()))
+			}
+		}
+		return result, nil
+	}
+}
+
+// WithFormats lists the registered formats as the enum of the format property of a tool's input schema,
+// it's passed after mcp.WithInputSchema
+func WithFormats() mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		var schema map[string]any
+		if err := json.Unmarshal(t.RawInputSchema, &schema); err != nil {
+			log.Printf("failed to list formats in the input schema of %s: %v", t.Name, err)
+			return
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		format, ok := properties["format"].(map[string]any)
+		if !ok {
+			return
+		}
+		format["enum"] = utils.FormatNames()
+		enco, err := json.Marshal(schema)
+		if err != nil {
+			log.Printf("failed to list formats in the input schema of %s: %v", t.Name, err)
+			return
+		}
+		t.RawInputSchema = enco
+	}
+}
@@ -0,0 +1,281 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/internal/handlers"
+	"exmple.com/database-query-server/internal/repository"
+	"exmple.com/database-query-server/internal/utils"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+// call sends a JSON-RPC request to the server in the given session and decodes the result into v
+func call(t *testing.T, srv *server.MCPServer, session server.ClientSession, method string, params any, v any) *mcp.JSONRPCError {
+	t.Helper()
+	msg, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatalf("failed to encode request: %v", err)
+	}
+	switch resp := srv.HandleMessage(srv.WithContext(context.Background(), session), msg).(type) {
+	case mcp.JSONRPCResponse:
+		enco, err := json.Marshal(resp.Result)
+		if err != nil {
+			t.Fatalf("failed to encode result: %v", err)
+		}
+		if err := json.Unmarshal(enco, v); err != nil {
+			t.Fatalf("failed to decode result: %v", err)
+		}
+		return nil
+	case mcp.JSONRPCError:
+		return &resp
+	default:
+		t.Fatalf("unexpected response %T", resp)
+		return nil
+	}
+}
+
+func TestQueryHandler_StoredResults(t *testing.T) {
+	client, err := database.NewClient(config.DatabaseConfig{
+		Name:   "local",
+		Driver: config.DriverSQLite,
+		DBName: filepath.Join(t.TempDir(), "results.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	repo := repository.NewRepository()
+	if err := repo.Register(config.DatabaseConfig{Name: "local", Driver: config.DriverSQLite}, client); err != nil {
+		t.Fatalf("failed to register local database: %v", err)
+	}
+	qh := handlers.NewQueryHandler(repo, handlers.WithResults(5, time.Minute, 10))
+	defer qh.Close()
+
+	srv := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true), server.WithResourceCapabilities(true, true))
+	srv.AddTool(
+		mcp.NewTool("execute_query", mcp.WithInputSchema[types.QueryRequest](), mcp.WithOutputSchema[types.QueryResponse]()),
+		handlers.QueryToolHandler(qh.ExecuteQuery),
+	)
+	srv.AddResourceTemplate(mcp.NewResourceTemplate(handlers.ResultURITemplate, "Query result"), qh.ReadResult)
+
+	session := &testSession{id: "results", notifications: make(chan mcp.JSONRPCNotification, 10)}
+	query := "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c LIMIT 7) SELECT x FROM c"
+
+	var result struct {
+		Content []struct {
+			Type     string `json:"type"`
+			URI      string `json:"uri"`
+			MIMEType string `json:"mimeType"`
+		} `json:"content"`
+		StructuredContent types.QueryResponse `json:"structuredContent"`
+	}
+	if rpcErr := call(t, srv, session, "tools/call", map[string]any{"name": "execute_query", "arguments": map[string]any{"database": "local", "query": query, "limit": 100, "format": "csv"}}, &result); rpcErr != nil {
+		t.Fatalf("tools/call failed: %+v", rpcErr)
+	}
+
+	// the response keeps a preview, the whole result is linked in every format
+	resp := result.StructuredContent
+	id := resp.ResultID
+	assert.NotEmpty(t, id)
+	assert.Equal(t, 7, resp.RowCount)
+	assert.Len(t, resp.Rows, 5)
+	assert.Equal(t, "x\n1\n2\n3\n4\n5\n", resp.Response)
+	if assert.Len(t, result.Content, 11) {
+		assert.Equal(t, "text", result.Content[0].Type)
+		assert.Equal(t, "resource_link", result.Content[1].Type)
+		assert.Equal(t, "query-result://"+id+".json", result.Content[1].URI)
+		assert.Equal(t, "query-result://"+id+".csv", result.Content[2].URI)
+		assert.Equal(t, "text/csv", result.Content[2].MIMEType)
+		assert.Equal(t, "query-result://"+id+".html", result.Content[3].URI)
+		assert.Equal(t, "query-result://"+id+".md", result.Content[4].URI)
+		assert.Equal(t, "query-result://"+id+".xml", result.Content[7].URI)
+	}
+
+	tests := []struct {
+		name     string
+		session  server.ClientSession
+		uri      string
+		wantMIME string
+		wantText string
+		wantErr  bool
+	}{
+		{name: "Happy Flow resources/read - CSV", session: session, uri: "query-result://" + id + ".csv", wantMIME: "text/csv", wantText: "x\n1\n2\n3\n4\n5\n6\n7\n"},
+		{name: "Happy Flow resources/read - JSON", session: session, uri: "query-result://" + id + ".json", wantMIME: "application/json", wantText: `[{"x":1},{"x":2},{"x":3},{"x":4},{"x":5},{"x":6},{"x":7}]`},
+		{name: "Happy Flow resources/read - Markdown", session: session, uri: "query-result://" + id + ".md", wantMIME: "text/markdown", wantText: "| x |\n| --- |\n| 1 |\n| 2 |\n| 3 |\n| 4 |\n| 5 |\n| 6 |\n| 7 |\n"},
+		{name: "Happy Flow resources/read - NDJSON", session: session, uri: "query-result://" + id + ".ndjson", wantMIME: "application/x-ndjson", wantText: "{\"x\":1}\n{\"x\":2}\n{\"x\":3}\n{\"x\":4}\n{\"x\":5}\n{\"x\":6}\n{\"x\":7}\n"},
+		{name: "Sad Flow resources/read - unsupported format", session: session, uri: "query-result://" + id + ".pdf", wantErr: true},
+		{name: "Sad Flow resources/read - unknown result", session: session, uri: "query-result://unknown.csv", wantErr: true},
+		{name: "Sad Flow resources/read - other session", session: &testSession{id: "other"}, uri: "query-result://" + id + ".csv", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var read struct {
+				Contents []struct {
+					URI      string `json:"uri"`
+					MIMEType string `json:"mimeType"`
+					Text     string `json:"text"`
+				} `json:"contents"`
+			}
+			rpcErr := call(t, srv, tt.session, "resources/read", map[string]any{"uri": tt.uri}, &read)
+			if rpcErr != nil {
+				if !tt.wantErr {
+					t.Errorf("resources/read failed: %+v", rpcErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("resources/read succeeded unexpectedly")
+			}
+			if assert.Len(t, read.Contents, 1) {
+				assert.Equal(t, tt.uri, read.Contents[0].URI)
+				assert.Equal(t, tt.wantMIME, read.Contents[0].MIMEType)
+				assert.Equal(t, tt.wantText, read.Contents[0].Text)
+			}
+		})
+	}
+
+	t.Run("Happy Flow resources/read - XLSX is a blob", func(t *testing.T) {
+		var read struct {
+			Contents []struct {
+				MIMEType string `json:"mimeType"`
+				Blob     []byte `json:"blob"`
+			} `json:"contents"`
+		}
+		if rpcErr := call(t, srv, session, "resources/read", map[string]any{"uri": "query-result://" + id + ".xlsx"}, &read); rpcErr != nil {
+			t.Fatalf("resources/read failed: %+v", rpcErr)
+		}
+		if assert.Len(t, read.Contents, 1) {
+			assert.Equal(t, utils.XLSXMimeType, read.Contents[0].MIMEType)
+			assert.Equal(t, []string{"x", "1", "2", "3", "4", "5", "6", "7"}, xlsxColumn(t, read.Contents[0].Blob))
+		}
+	})
+
+	t.Run("Happy Flow execute_query - XLSX is an embedded resource", func(t *testing.T) {
+		var result struct {
+			Content []struct {
+				Type     string `json:"type"`
+				Resource struct {
+					URI      string `json:"uri"`
+					MIMEType string `json:"mimeType"`
+					Blob     []byte `json:"blob"`
+				} `json:"resource"`
+			} `json:"content"`
+			StructuredContent types.QueryResponse `json:"structuredContent"`
+		}
+		if rpcErr := call(t, srv, session, "tools/call", map[string]any{"name": "execute_query", "arguments": map[string]any{"database": "local", "query": "SELECT 1 AS x UNION ALL SELECT 2", "format": "xlsx"}}, &result); rpcErr != nil {
+			t.Fatalf("tools/call failed: %+v", rpcErr)
+		}
+		assert.Empty(t, result.StructuredContent.Response)
+		if assert.Len(t, result.Content, 2) {
+			assert.Equal(t, "resource", result.Content[1].Type)
+			assert.Equal(t, "file:///query-result.xlsx", result.Content[1].Resource.URI)
+			assert.Equal(t, utils.XLSXMimeType, result.Content[1].Resource.MIMEType)
+			assert.Equal(t, []string{"x", "1", "2"}, xlsxColumn(t, result.Content[1].Resource.Blob))
+		}
+	})
+
+	t.Run("Happy Flow execute_query - small result isn't stored", func(t *testing.T) {
+		got, gotErr := qh.ExecuteQuery(context.Background(), mcp.CallToolRequest{}, types.QueryRequest{Database: "local", Query: "SELECT 1 AS x"})
+		if gotErr != nil {
+			t.Fatalf("ExecuteQuery() failed: %v", gotErr)
+		}
+		assert.Empty(t, got.ResultID)
+		assert.Equal(t, [][]any{{int64(1)}}, got.Rows)
+	})
+}
+
+func TestQueryHandler_StoredResults_Full(t *testing.T) {
+	client, err := database.NewClient(config.DatabaseConfig{
+		Name:   "local",
+		Driver: config.DriverSQLite,
+		DBName: filepath.Join(t.TempDir(), "full.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewClient() failed: %v", err)
+	}
+	// a single result is kept
+	qh := handlers.NewQueryHandler(newTestRepository(t, "local", client), handlers.WithResults(2, time.Minute, 1))
+	defer qh.Close()
+
+	query := "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c LIMIT 7) SELECT x FROM c"
+	var ids []string
+	for range 2 {
+		got, err := qh.ExecuteQuery(context.Background(), mcp.CallToolRequest{}, types.QueryRequest{Database: "local", Query: query, Limit: 100})
+		if err != nil {
+			t.Fatalf("ExecuteQuery() failed: %v", err)
+		}
+		// the response never holds more rows than the preview
+		assert.Len(t, got.Rows, 2)
+		assert.Equal(t, 7, got.RowCount)
+		assert.NotEmpty(t, got.ResultID)
+		assert.Empty(t, got.Notice)
+		ids = append(ids, got.ResultID)
+	}
+
+	read := func(id string) error {
+		req := mcp.ReadResourceRequest{}
+		req.Params.URI = "query-result://" + id + ".csv"
+		_, err := qh.ReadResult(context.Background(), req)
+		return err
+	}
+	// the first result made room for the second one
+	assert.ErrorContains(t, read(ids[0]), "unknown or expired")
+	assert.NoError(t, read(ids[1]))
+}
+
+// xlsxColumn returns the first column of a workbook's sheet
+func xlsxColumn(t *testing.T, workbook []byte) []string {
+	t.Helper()
+	f, err := excelize.OpenReader(bytes.NewReader(workbook))
+	if err != nil {
+		t.Fatalf("failed to open workbook: %v", err)
+	}
+	defer f.Close()
+	cols, err := f.GetCols(f.GetSheetName(0))
+	if err != nil {
+		t.Fatalf("failed to read workbook: %v", err)
+	}
+	if len(cols) == 0 {
+		return nil
+	}
+	return cols[0]
+}
+
+func TestWithFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		tool     mcp.Tool
+		wantEnum any
+	}{
+		{name: "Happy Flow - format property lists the registered formats", tool: mcp.NewTool("execute_query", mcp.WithInputSchema[types.QueryRequest](), handlers.WithFormats()), wantEnum: utils.FormatNames()},
+		{name: "Happy Flow - schema without a format property is unchanged", tool: mcp.NewTool("get_schema", mcp.WithInputSchema[types.SchemaRequest](), handlers.WithFormats())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema struct {
+				Properties map[string]struct {
+					Enum []string `json:"enum"`
+				} `json:"properties"`
+			}
+			if err := json.Unmarshal(tt.tool.RawInputSchema, &schema); err != nil {
+				t.Fatalf("failed to decode input schema: %v", err)
+			}
+			if tt.wantEnum == nil {
+				assert.NotContains(t, schema.Properties, "format")
+				return
+			}
+			assert.Equal(t, tt.wantEnum, schema.Properties["format"].Enum)
+			assert.Contains(t, schema.Properties["format"].Enum, "parquet")
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/pkg/types"
+)
+
+// resolveTables returns the tables get_schema describes and the patterns that matched no table. Unqualified names
+// are qualified with schema when it's given and glob patterns are matched against the tables of their schema,
+// plain names are passed on for the client to look up. Every table of schema is returned when there are no patterns.
+func resolveTables(ctx context.Context, client database.Reader, schema string, patterns []string) ([]string, []string, error) {
+	if len(patterns) == 0 {
+		catalog, err := client.TableNames(ctx, schema)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(catalog) == 0 && schema != "" {
+			return nil, []string{schema + ".*"}, nil
+		}
+		names := make([]string, len(catalog))
+		for i, t := range catalog {
+			names[i] = t.String()
+		}
+		return names, nil, nil
+	}
+
+	catalogs := map[string][]types.TableName{} // by schema, listed once
+	var names, missing []string
+	for _, pattern := range patterns {
+		if schema != "" && !strings.Contains(pattern, ".") {
+			pattern = schema + "." + pattern
+		}
+		tableSchema, table, qualified := strings.Cut(pattern, ".")
+		if !qualified {
+			tableSchema, table = "", pattern
+		}
+		if !strings.ContainsAny(table, "*?[") {
+			names = append(names, pattern)
+			continue
+		}
+
+		catalog, ok := catalogs[tableSchema]
+		if !ok {
+			var err error
+			if catalog, err = client.TableNames(ctx, tableSchema); err != nil {
+				return nil, nil, err
+			}
+			catalogs[tableSchema] = catalog
+		}
+		matched := false
+		for _, t := range catalog {
+			ok, err := path.Match(table, t.Name)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid table pattern %q: %w", pattern, err)
+			}
+			if ok {
+				names = append(names, t.String())
+				matched = true
+			}
+		}
+		if !matched {
+			missing = append(missing, pattern)
+		}
+	}
+	return names, missing, nil
+}
+
+// missingTables returns the plain names the client found no table for, a glob pattern is resolved to found tables
+func missingTables(names []string, tables []types.TableSchema) []string {
+	var missing []string
+	for _, name := range names {
+		schema, table, qualified := strings.Cut(name, ".")
+		if !qualified {
+			table = name
+		}
+		found := slices.ContainsFunc(tables, func(t types.TableSchema) bool {
+			return t.Name == table && (!qualified || t.Schema == schema)
+		})
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// uniqueTables drops the tables already described by an earlier name or pattern
+func uniqueTables(tables []types.TableSchema) []types.TableSchema {
+	seen := map[types.TableName]bool{}
+	return slices.DeleteFunc(tables, func(t types.TableSchema) bool {
+		name := types.TableName{Schema: t.Schema, Name: t.Name}
+		if seen[name] {
+			return true
+		}
+		seen[name] = true
+		return false
+	})
+}
+
+// schemaResult returns a row per column of the tables, the table name is qualified by its schema. Detailed rows add
+// the nullability, default, position, whether the column is in the primary key, the schema.table.column a single
+// column foreign key references and the comment of the column.
+func schemaResult(tables []types.TableSchema, detailed bool) *types.ResultSet {
+	result := &types.ResultSet{Columns: []types.Column{
+		{Name: "table_name", Type: "TEXT"}, {Name: "column_name", Type: "TEXT"}, {Name: "data_type", Type: "TEXT"},
+		{Name: "character_maximum_length", Type: "INT8"},
+	}}
+	if detailed {
+		result.Columns = append(result.Columns[:3],
+			types.Column{Name: "is_nullable", Type: "BOOL"}, types.Column{Name: "column_default", Type: "TEXT"},
+			types.Column{Name: "ordinal_position", Type: "INT4"}, types.Column{Name: "is_primary_key", Type: "BOOL"},
+			types.Column{Name: "references", Type: "TEXT"}, types.Column{Name: "column_comment", Type: "TEXT"},
+		)
+	}
+	for _, t := range tables {
+		name := strings.TrimPrefix(t.Schema+"."+t.Name, ".")
+		for _, c := range t.Columns {
+			if !detailed {
+				var length any
+				if c.MaxLength != nil {
+					length = *c.MaxLength
+				}
+				result.Rows = append(result.Rows, []any{name, c.Name, c.DataType, length})
+				continue
+			}
+			var def, ref, comment any
+			if c.Default != nil {
+				def = *c.Default
+			}
+			for _, fk := range t.ForeignKeys {
+				if len(fk.Columns) == 1 && fk.Columns[0] == c.Name && len(fk.RefColumns) == 1 {
+					ref = strings.TrimPrefix(fk.RefSchema+"."+fk.RefTable+"."+fk.RefColumns[0], ".")
+				}
+			}
+			if c.Comment != "" {
+				comment = c.Comment
+			}
+			result.Rows = append(result.Rows, []any{
+				name, c.Name, c.DataType, c.Nullable, def, int64(c.Position), slices.Contains(t.PrimaryKey, c.Name), ref, comment,
+			})
+		}
+	}
+	return result
+}
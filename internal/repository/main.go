@@ -1,21 +1,118 @@
 package repository
 
 import (
-	"log"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
+	"exmple.com/database-query-server/internal/config"
 	"exmple.com/database-query-server/internal/database"
 )
 
+// Connection is a named database client held by the Repository
+type Connection struct {
+	Name   string
+	Driver string // see config.Drivers
+	Client database.ClientInterface
+	Policy config.PolicyConfig
+}
+
+// Repository is a registry of named database clients ie. "primary", "analytics", "reporting"
+// Tool handlers resolve the client for each call by the request's `database` argument.
 type Repository struct {
-	Postgress database.ClientInterface
+	mu          sync.RWMutex
+	connections map[string]*Connection
+	defaultName string
 }
 
-// New returns a pointer to a new Repository instance or an error.
-func New() (*Repository, error) {
-	pg, err := database.NewPostgressClient()
-	if err != nil {
-		log.Fatal(err)
+// New returns a pointer to a new Repository instance with a client for every configured database, or an error.
+func New(cfg *config.Config) (*Repository, error) {
+	r := NewRepository()
+	for _, db := range cfg.Databases {
+		client, err := database.NewClient(db)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.Register(db, client); err != nil {
+			return nil, err
+		}
 	}
+	if err := r.SetDefault(cfg.DefaultDatabase()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewRepository returns an empty Repository, clients are added with Register
+func NewRepository() *Repository {
+	return &Repository{
+		connections: make(map[string]*Connection),
+	}
+}
 
-	return &Repository{Postgress: pg}, err
+// Register adds the client of a database config to the repository, only the name, driver and policy are used.
+// The first registered client becomes the default used when a request doesn't name a database.
+func (r *Repository) Register(db config.DatabaseConfig, client database.ClientInterface) error {
+	name := strings.TrimSpace(db.Name)
+	if name == "" {
+		return fmt.Errorf("database name can not be empty")
+	}
+	if client == nil {
+		return fmt.Errorf("database %q has no client", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.connections[name]; ok {
+		return fmt.Errorf("database %q is already registered", name)
+	}
+	r.connections[name] = &Connection{Name: name, Driver: db.Driver, Client: client, Policy: db.Policy}
+	if r.defaultName == "" {
+		r.defaultName = name
+	}
+	return nil
+}
+
+// SetDefault changes the database used when a request doesn't name one
+func (r *Repository) SetDefault(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.connections[name]; !ok {
+		return fmt.Errorf("unknown database %q, available databases: %s", name, strings.Join(r.names(), ", "))
+	}
+	r.defaultName = name
+	return nil
+}
+
+// Get returns the connection registered under name.
+// An empty name resolves to the default database.
+func (r *Repository) Get(name string) (*Connection, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if name == "" {
+		name = r.defaultName
+	}
+	conn, ok := r.connections[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown database %q, available databases: %s", name, strings.Join(r.names(), ", "))
+	}
+	return conn, nil
+}
+
+// Names returns the names of all registered databases in alphabetical order
+func (r *Repository) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.names()
+}
+
+func (r *Repository) names() []string {
+	names := make([]string, 0, len(r.connections))
+	for name := range r.connections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
@@ -0,0 +1,87 @@
+package repository_test
+
+import (
+	"testing"
+
+	"exmple.com/database-query-server/internal/config"
+	"exmple.com/database-query-server/internal/database"
+	"exmple.com/database-query-server/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_Get(t *testing.T) {
+	primary, _ := database.NewPostgresClientMock(nil, false)
+	analytics, _ := database.NewPostgresClientMock(nil, false)
+
+	repo := repository.NewRepository()
+	if err := repo.Register(config.DatabaseConfig{Name: "primary", Driver: config.DriverPostgres}, primary); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+	if err := repo.Register(config.DatabaseConfig{Name: "analytics", Driver: config.DriverPostgres}, analytics); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		database string
+		want     database.ClientInterface
+		wantErr  bool
+	}{
+		{name: "Happy Flow - get primary", database: "primary", want: primary, wantErr: false},
+		{name: "Happy Flow - get analytics", database: "analytics", want: analytics, wantErr: false},
+		{name: "Happy Flow - empty name resolves to the default database", database: "", want: primary, wantErr: false},
+		{name: "Sad Flow - unknown database", database: "reporting", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := repo.Get(tt.database)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("Get() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("Get() succeeded unexpectedly")
+			}
+			assert.Same(t, tt.want, got.Client)
+			assert.Equal(t, config.DriverPostgres, got.Driver)
+		})
+	}
+}
+
+func TestRepository_Register(t *testing.T) {
+	client, _ := database.NewPostgresClientMock(nil, false)
+
+	repo := repository.NewRepository()
+	if err := repo.Register(config.DatabaseConfig{Name: "primary", Driver: config.DriverPostgres}, client); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		database string
+		client   database.ClientInterface
+		wantErr  bool
+	}{
+		{name: "Happy Flow - register reporting", database: "reporting", client: client, wantErr: false},
+		{name: "Sad Flow - duplicated name", database: "primary", client: client, wantErr: true},
+		{name: "Sad Flow - empty name", database: " ", client: client, wantErr: true},
+		{name: "Sad Flow - missing client", database: "analytics", client: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotErr := repo.Register(config.DatabaseConfig{Name: tt.database, Driver: config.DriverPostgres}, tt.client)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("Register() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("Register() succeeded unexpectedly")
+			}
+		})
+	}
+	assert.EqualValues(t, []string{"primary", "reporting"}, repo.Names())
+}
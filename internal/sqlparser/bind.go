@@ -0,0 +1,176 @@
+package sqlparser
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ReasonParameters rejects parameters that don't match the placeholders of the statement
+const ReasonParameters Reason = "parameters"
+
+// Bind returns the statement and its arguments in placeholder order for database/sql.
+//
+// Numeric keys ("1", "2", ...) bind positional placeholders, $1 in Postgres and ? or ?1 in MySQL and SQLite.
+// They must be contiguous from 1 and match the placeholders of the statement.
+// Named keys ("country") bind :country placeholders, which are rewritten to the positional placeholders
+// of the dialect, a name can be used more than once. Numeric and named keys can't be mixed.
+func Bind(sql string, params map[string]any, d Dialect) (string, []any, error) {
+	tokens, err := Tokenize(sql, d)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var numbered, named []string
+	for key := range params {
+		if _, err := strconv.Atoi(key); err == nil {
+			numbered = append(numbered, key)
+		} else {
+			named = append(named, key)
+		}
+	}
+	if len(numbered) > 0 && len(named) > 0 {
+		sort.Strings(named)
+		return "", nil, reject(ReasonParameters, 0, fmt.Sprintf("numbered and named parameters can't be mixed, named: %s", strings.Join(named, ", ")))
+	}
+
+	var placeholders []Token
+	names := false
+	for _, t := range tokens {
+		if t.Kind != Param {
+			continue
+		}
+		if isNamed(t) {
+			// :name tokens are only placeholders when named parameters are given ie. Postgres array slices a[1:n]
+			if len(named) == 0 && d == Postgres {
+				continue
+			}
+			names = true
+		}
+		placeholders = append(placeholders, t)
+	}
+
+	if len(named) > 0 || names {
+		return bindNamed(sql, placeholders, params, d)
+	}
+	args, err := bindNumbered(placeholders, params)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, args, nil
+}
+
+// bindNumbered orders the parameters by their numeric key, the keys must be 1..n and match the placeholders
+func bindNumbered(placeholders []Token, params map[string]any) ([]any, error) {
+	n := len(params)
+	if n == 0 && len(placeholders) == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, n)
+	keys := make([]int, 0, n)
+	for key, value := range params {
+		i, _ := strconv.Atoi(key)
+		keys = append(keys, i)
+		if i >= 1 && i <= n {
+			args[i-1] = value
+		}
+	}
+	sort.Ints(keys)
+	for i, key := range keys {
+		if key != i+1 {
+			return nil, reject(ReasonParameters, 0, fmt.Sprintf("parameter keys must be numbered 1 to %d without gaps, got %s", n, joinInts(keys)))
+		}
+	}
+
+	used := make([]bool, n)
+	anonymous := 0
+	for _, t := range placeholders {
+		if t.Text == "?" {
+			anonymous++
+			continue
+		}
+		i, err := strconv.Atoi(t.Text[1:])
+		if err != nil || i < 1 || i > n {
+			return nil, reject(ReasonParameters, t.Pos, fmt.Sprintf("placeholder %s has no parameter, %d given", t.Text, n))
+		}
+		used[i-1] = true
+	}
+
+	if anonymous > 0 {
+		if anonymous != len(placeholders) {
+			return nil, reject(ReasonParameters, 0, "? and numbered placeholders can't be mixed")
+		}
+		if anonymous != n {
+			return nil, reject(ReasonParameters, 0, fmt.Sprintf("the statement has %d placeholders, %d parameters given", anonymous, n))
+		}
+		return args, nil
+	}
+	for i, ok := range used {
+		if !ok {
+			return nil, reject(ReasonParameters, 0, fmt.Sprintf("parameter %d is not used by the statement", i+1))
+		}
+	}
+	return args, nil
+}
+
+// isNamed reports whether a placeholder is named ie. :country, @country or $country in SQLite
+func isNamed(t Token) bool {
+	return len(t.Text) > 1 && !isDigit(t.Text[1])
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// bindNamed rewrites :name placeholders to positional ones, every placeholder needs a parameter and every parameter is used
+func bindNamed(sql string, placeholders []Token, params map[string]any, d Dialect) (string, []any, error) {
+	var b strings.Builder
+	var args []any
+	index := make(map[string]int) // Postgres reuses $n for repeated names
+	last := 0
+	for _, t := range placeholders {
+		if !isNamed(t) {
+			return "", nil, reject(ReasonParameters, t.Pos, fmt.Sprintf("positional placeholder %s can't be used with named parameters", t.Text))
+		}
+		name := t.Text[1:]
+		value, ok := params[name]
+		if !ok {
+			return "", nil, reject(ReasonParameters, t.Pos, fmt.Sprintf("missing parameter %q for placeholder %s", name, t.Text))
+		}
+
+		b.WriteString(sql[last:t.Pos])
+		if d == Postgres {
+			n, ok := index[name]
+			if !ok {
+				args = append(args, value)
+				n = len(args)
+				index[name] = n
+			}
+			b.WriteString("$" + strconv.Itoa(n))
+		} else {
+			index[name] = len(args)
+			args = append(args, value)
+			b.WriteString("?")
+		}
+		last = t.Pos + len(t.Text)
+	}
+	b.WriteString(sql[last:])
+
+	var unused []string
+	for name := range params {
+		if _, ok := index[name]; !ok {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return "", nil, reject(ReasonParameters, 0, fmt.Sprintf("parameters %s are not used by the statement", strings.Join(unused, ", ")))
+	}
+	return b.String(), args, nil
+}
@@ -0,0 +1,79 @@
+package sqlparser_test
+
+import (
+	"testing"
+
+	"exmple.com/database-query-server/internal/sqlparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		params   map[string]any
+		dialect  sqlparser.Dialect
+		wantSQL  string
+		wantArgs []any
+		wantErr  string
+	}{
+		{name: "Happy Flow - no parameters", sql: "SELECT 1", dialect: sqlparser.Postgres, wantSQL: "SELECT 1"},
+		{name: "Happy Flow - numbered parameters are ordered by key", sql: "SELECT * FROM t WHERE country = $1 AND city LIKE $2 AND id > $10 - $3 - $4 - $5 - $6 - $7 - $8 - $9", dialect: sqlparser.Postgres,
+			params:   map[string]any{"2": "L%", "1": "UK", "10": 10, "3": 3, "4": 4, "5": 5, "6": 6, "7": 7, "8": 8, "9": 9},
+			wantSQL:  "SELECT * FROM t WHERE country = $1 AND city LIKE $2 AND id > $10 - $3 - $4 - $5 - $6 - $7 - $8 - $9",
+			wantArgs: []any{"UK", "L%", 3, 4, 5, 6, 7, 8, 9, 10}},
+		{name: "Happy Flow - numbered parameter used twice", sql: "SELECT $1 = $1 OR $2", dialect: sqlparser.Postgres, params: map[string]any{"1": 1, "2": 2}, wantSQL: "SELECT $1 = $1 OR $2", wantArgs: []any{1, 2}},
+		{name: "Happy Flow - ? placeholders", sql: "SELECT * FROM t WHERE a = ? AND b = ?", dialect: sqlparser.MySQL, params: map[string]any{"2": "b", "1": "a"}, wantSQL: "SELECT * FROM t WHERE a = ? AND b = ?", wantArgs: []any{"a", "b"}},
+		{name: "Happy Flow - Postgres casts, slices and jsonb ? aren't placeholders", sql: "SELECT a::text, b[1:2], c ? 'k' FROM t WHERE id = $1", dialect: sqlparser.Postgres, params: map[string]any{"1": 1},
+			wantSQL: "SELECT a::text, b[1:2], c ? 'k' FROM t WHERE id = $1", wantArgs: []any{1}},
+		{name: "Happy Flow - placeholders in strings and comments are ignored", sql: "SELECT ':x', '$2' -- :y\nFROM t WHERE id = $1", dialect: sqlparser.Postgres, params: map[string]any{"1": 1},
+			wantSQL: "SELECT ':x', '$2' -- :y\nFROM t WHERE id = $1", wantArgs: []any{1}},
+		{name: "Happy Flow - named parameters in Postgres", sql: "SELECT * FROM t WHERE country = :country AND (city = :city OR town = :city)", dialect: sqlparser.Postgres,
+			params:   map[string]any{"city": "London", "country": "UK"},
+			wantSQL:  "SELECT * FROM t WHERE country = $1 AND (city = $2 OR town = $2)",
+			wantArgs: []any{"UK", "London"}},
+		{name: "Happy Flow - named parameters in MySQL", sql: "SELECT * FROM t WHERE country = :country AND (city = :city OR town = :city)", dialect: sqlparser.MySQL,
+			params:   map[string]any{"city": "London", "country": "UK"},
+			wantSQL:  "SELECT * FROM t WHERE country = ? AND (city = ? OR town = ?)",
+			wantArgs: []any{"UK", "London", "London"}},
+		{name: "Happy Flow - named parameters with a cast", sql: "SELECT :day::date", dialect: sqlparser.Postgres, params: map[string]any{"day": "2025-01-02"}, wantSQL: "SELECT $1::date", wantArgs: []any{"2025-01-02"}},
+		{name: "Happy Flow - SQLite @name", sql: "SELECT @id", dialect: sqlparser.SQLite, params: map[string]any{"id": 1}, wantSQL: "SELECT ?", wantArgs: []any{1}},
+		{name: "Sad Flow - keys with a gap", sql: "SELECT $1, $3", dialect: sqlparser.Postgres, params: map[string]any{"1": 1, "3": 3},
+			wantErr: "statement rejected (parameters): parameter keys must be numbered 1 to 2 without gaps, got 1, 3"},
+		{name: "Sad Flow - placeholder without a parameter", sql: "SELECT $1, $2", dialect: sqlparser.Postgres, params: map[string]any{"1": 1},
+			wantErr: "statement rejected (parameters): placeholder $2 has no parameter, 1 given"},
+		{name: "Sad Flow - unused parameter", sql: "SELECT $1", dialect: sqlparser.Postgres, params: map[string]any{"1": 1, "2": 2},
+			wantErr: "statement rejected (parameters): parameter 2 is not used by the statement"},
+		{name: "Sad Flow - parameters without placeholders", sql: "SELECT 1", dialect: sqlparser.Postgres, params: map[string]any{"1": 1},
+			wantErr: "statement rejected (parameters): parameter 1 is not used by the statement"},
+		{name: "Sad Flow - ? count mismatch", sql: "SELECT ?, ?", dialect: sqlparser.SQLite, params: map[string]any{"1": 1},
+			wantErr: "statement rejected (parameters): the statement has 2 placeholders, 1 parameters given"},
+		{name: "Sad Flow - ? mixed with numbered placeholders", sql: "SELECT ?, ?1", dialect: sqlparser.SQLite, params: map[string]any{"1": 1},
+			wantErr: "statement rejected (parameters): ? and numbered placeholders can't be mixed"},
+		{name: "Sad Flow - numbered and named keys", sql: "SELECT $1, :name", dialect: sqlparser.Postgres, params: map[string]any{"1": 1, "name": "x"},
+			wantErr: "statement rejected (parameters): numbered and named parameters can't be mixed, named: name"},
+		{name: "Sad Flow - missing named parameter", sql: "SELECT :a, :b", dialect: sqlparser.Postgres, params: map[string]any{"a": 1},
+			wantErr: `statement rejected (parameters): missing parameter "b" for placeholder :b`},
+		{name: "Sad Flow - unused named parameters", sql: "SELECT :a", dialect: sqlparser.MySQL, params: map[string]any{"a": 1, "c": 3, "b": 2},
+			wantErr: "statement rejected (parameters): parameters b, c are not used by the statement"},
+		{name: "Sad Flow - positional placeholder with named parameters", sql: "SELECT :a, $1", dialect: sqlparser.Postgres, params: map[string]any{"a": 1},
+			wantErr: "statement rejected (parameters): positional placeholder $1 can't be used with named parameters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSQL, gotArgs, gotErr := sqlparser.Bind(tt.sql, tt.params, tt.dialect)
+			if gotErr != nil {
+				if tt.wantErr == "" {
+					t.Errorf("Bind() failed: %v", gotErr)
+				}
+				assert.EqualError(t, gotErr, tt.wantErr)
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatal("Bind() succeeded unexpectedly")
+			}
+			assert.Equal(t, tt.wantSQL, gotSQL)
+			assert.Equal(t, tt.wantArgs, gotArgs)
+		})
+	}
+}
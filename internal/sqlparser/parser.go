@@ -0,0 +1,349 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the class of a SQL statement
+type Kind string
+
+const (
+	Read    Kind = "read"    // SELECT, WITH ... SELECT, VALUES, TABLE, SHOW, EXPLAIN
+	DML     Kind = "dml"     // INSERT, UPDATE, DELETE, MERGE and data modifying CTEs
+	DDL     Kind = "ddl"     // CREATE, ALTER, DROP, TRUNCATE, GRANT and SELECT ... INTO
+	Utility Kind = "utility" // transaction control, SET, VACUUM, CALL, PRAGMA etc.
+)
+
+// Reason is the machine readable cause of a Rejection
+type Reason string
+
+const (
+	ReasonEmpty              Reason = "empty"
+	ReasonSyntax             Reason = "syntax"
+	ReasonMultipleStatements Reason = "multiple_statements"
+	ReasonUnknownStatement   Reason = "unknown_statement"
+	ReasonNotAllowed         Reason = "not_allowed"
+)
+
+// Rejection is returned when a statement can't be parsed or isn't allowed
+type Rejection struct {
+	Reason Reason
+	Pos    int // byte offset in the statement the rejection refers to
+	Detail string
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("statement rejected (%s): %s", r.Reason, r.Detail)
+}
+
+func reject(reason Reason, pos int, detail string) *Rejection {
+	return &Rejection{Reason: reason, Pos: pos, Detail: detail}
+}
+
+// Statement is a single classified SQL statement
+type Statement struct {
+	SQL     string // the statement without surrounding whitespace and the trailing semicolon
+	Kind    Kind
+	Keyword string  // the leading keyword in upper case ie. SELECT, WITH, EXPLAIN
+	Tokens  []Token // positions are offsets in SQL
+}
+
+// Allow returns a Rejection unless the statement is one of the kinds
+func (s *Statement) Allow(kinds ...Kind) error {
+	for _, k := range kinds {
+		if s.Kind == k {
+			return nil
+		}
+	}
+	allowed := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		allowed = append(allowed, string(k))
+	}
+	return reject(ReasonNotAllowed, 0, fmt.Sprintf("%s is a %s statement, allowed: %s", s.Keyword, s.Kind, strings.Join(allowed, ", ")))
+}
+
+var keywordKinds = map[string]Kind{
+	"SELECT": Read, "VALUES": Read, "TABLE": Read, "SHOW": Read,
+
+	"INSERT": DML, "UPDATE": DML, "DELETE": DML, "MERGE": DML, "REPLACE": DML, "UPSERT": DML, "COPY": DML,
+
+	"CREATE": DDL, "ALTER": DDL, "DROP": DDL, "TRUNCATE": DDL, "COMMENT": DDL, "RENAME": DDL,
+	"GRANT": DDL, "REVOKE": DDL, "REFRESH": DDL, "IMPORT": DDL, "SECURITY": DDL,
+
+	"BEGIN": Utility, "START": Utility, "COMMIT": Utility, "ROLLBACK": Utility, "SAVEPOINT": Utility,
+	"RELEASE": Utility, "END": Utility, "ABORT": Utility, "SET": Utility, "RESET": Utility,
+	"VACUUM": Utility, "ANALYZE": Utility, "ANALYSE": Utility, "CLUSTER": Utility, "REINDEX": Utility,
+	"LOCK": Utility, "UNLOCK": Utility, "CALL": Utility, "DO": Utility, "PRAGMA": Utility,
+	"ATTACH": Utility, "DETACH": Utility, "LISTEN": Utility, "UNLISTEN": Utility, "NOTIFY": Utility,
+	"PREPARE": Utility, "EXECUTE": Utility, "DEALLOCATE": Utility, "DISCARD": Utility, "CHECKPOINT": Utility,
+	"LOAD": Utility, "DECLARE": Utility, "FETCH": Utility, "MOVE": Utility, "CLOSE": Utility,
+	"FLUSH": Utility, "KILL": Utility, "USE": Utility, "HANDLER": Utility, "OPTIMIZE": Utility,
+	"REPAIR": Utility, "CHECK": Utility, "INSTALL": Utility, "UNINSTALL": Utility, "SHUTDOWN": Utility,
+}
+
+// severity orders kinds so a statement is classified by its most dangerous part
+var severity = map[Kind]int{Read: 0, Utility: 1, DML: 2, DDL: 3}
+
+// Parse tokenizes sql and classifies it, sql must hold exactly one statement.
+// A trailing semicolon is allowed, comments and whitespace are ignored.
+func Parse(sql string, d Dialect) (*Statement, error) {
+	tokens, err := Tokenize(sql, d)
+	if err != nil {
+		return nil, err
+	}
+
+	statements := split(tokens)
+	if len(statements) == 0 {
+		return nil, reject(ReasonEmpty, 0, "the statement is empty")
+	}
+	if len(statements) > 1 {
+		pos := statements[1][0].Pos
+		return nil, reject(ReasonMultipleStatements, pos, fmt.Sprintf("only one statement is allowed, found another one at offset %d", pos))
+	}
+
+	stmt := statements[0]
+	first, last := stmt[0], stmt[len(stmt)-1]
+	text := sql[first.Pos : last.Pos+len(last.Text)]
+	// keep the positions relative to the statement text
+	relative := make([]Token, len(stmt))
+	for i, t := range stmt {
+		t.Pos -= first.Pos
+		relative[i] = t
+	}
+
+	kind, err := classify(relative)
+	if err != nil {
+		return nil, err
+	}
+	return &Statement{
+		SQL:     text,
+		Kind:    kind,
+		Keyword: strings.ToUpper(leading(relative).Text),
+		Tokens:  relative,
+	}, nil
+}
+
+// split groups tokens into statements at semicolons, empty statements are dropped.
+// Semicolons inside a CREATE ... BEGIN ATOMIC ... END body don't end the statement.
+func split(tokens []Token) [][]Token {
+	var statements [][]Token
+	var current []Token
+	blocks := 0
+	for i, t := range tokens {
+		switch {
+		case t.Kind == Semicolon && blocks == 0:
+			if len(current) > 0 {
+				statements = append(statements, current)
+			}
+			current = nil
+			continue
+		case t.Is("BEGIN") && i+1 < len(tokens) && tokens[i+1].Is("ATOMIC") && len(current) > 0 && current[0].Is("CREATE"):
+			blocks++
+		case t.Is("CASE") && blocks > 0:
+			blocks++
+		case t.Is("END") && blocks > 0:
+			blocks--
+		}
+		if t.Kind != Semicolon || blocks > 0 {
+			current = append(current, t)
+		}
+	}
+	if len(current) > 0 {
+		statements = append(statements, current)
+	}
+	return statements
+}
+
+// leading returns the first token after opening parentheses ie. (SELECT 1) UNION (SELECT 2)
+func leading(tokens []Token) Token {
+	for _, t := range tokens {
+		if t.Kind != Operator || t.Text != "(" {
+			return t
+		}
+	}
+	return tokens[0]
+}
+
+// classify returns the kind of the statement in tokens
+func classify(tokens []Token) (Kind, error) {
+	i := 0
+	for i < len(tokens) && tokens[i].Kind == Operator && tokens[i].Text == "(" {
+		i++
+	}
+	if i == len(tokens) {
+		return "", reject(ReasonSyntax, tokens[0].Pos, "the statement has no keyword")
+	}
+
+	first := tokens[i]
+	keyword := strings.ToUpper(first.Text)
+	switch {
+	case first.Kind != Word:
+		return "", reject(ReasonUnknownStatement, first.Pos, fmt.Sprintf("the statement can't start with %s", first.Text))
+	case keyword == "WITH":
+		return classifyWith(tokens[i:])
+	case keyword == "EXPLAIN" || keyword == "DESCRIBE" || keyword == "DESC":
+		return classifyExplain(tokens[i:])
+	case keyword == "SELECT":
+		if selectsInto(tokens[i:]) {
+			return DDL, nil
+		}
+		return Read, nil
+	}
+
+	kind, ok := keywordKinds[keyword]
+	if !ok {
+		return "", reject(ReasonUnknownStatement, first.Pos, fmt.Sprintf("%s is not a known statement", keyword))
+	}
+	return kind, nil
+}
+
+// classifyWith classifies WITH [RECURSIVE] name [(columns)] AS [[NOT] MATERIALIZED] (query) [, ...] statement,
+// a data modifying CTE makes the whole statement DML
+func classifyWith(tokens []Token) (Kind, error) {
+	kind := Read
+	i := 1
+	if i < len(tokens) && tokens[i].Is("RECURSIVE") {
+		i++
+	}
+	for {
+		// name and optional column list
+		if i >= len(tokens) || (tokens[i].Kind != Word && tokens[i].Kind != QuotedIdent) {
+			return "", reject(ReasonSyntax, posAt(tokens, i), "expected a common table expression name after WITH")
+		}
+		i++
+		if i < len(tokens) && isOpen(tokens[i]) {
+			i = closing(tokens, i) + 1
+		}
+		if i < len(tokens) && tokens[i].Is("AS") {
+			i++
+		}
+		for i < len(tokens) && (tokens[i].Is("NOT") || tokens[i].Is("MATERIALIZED")) {
+			i++
+		}
+		if i >= len(tokens) || !isOpen(tokens[i]) {
+			return "", reject(ReasonSyntax, posAt(tokens, i), "expected ( after AS in a common table expression")
+		}
+		end := closing(tokens, i)
+		if end < 0 {
+			return "", reject(ReasonSyntax, tokens[i].Pos, "unbalanced parentheses")
+		}
+		body, err := classify(tokens[i+1 : end])
+		if err != nil {
+			return "", err
+		}
+		kind = worst(kind, body)
+		i = end + 1
+
+		// Postgres SEARCH and CYCLE clauses are skipped up to the next CTE or the statement
+		for i < len(tokens) && !(tokens[i].Kind == Operator && tokens[i].Text == ",") && !isStatementStart(tokens[i]) {
+			i++
+		}
+		if i < len(tokens) && tokens[i].Text == "," {
+			i++
+			continue
+		}
+		break
+	}
+	if i >= len(tokens) {
+		return "", reject(ReasonSyntax, posAt(tokens, i), "expected a statement after the common table expressions")
+	}
+	main, err := classify(tokens[i:])
+	if err != nil {
+		return "", err
+	}
+	return worst(kind, main), nil
+}
+
+// classifyExplain classifies EXPLAIN statements as reads unless ANALYZE executes a statement that isn't one
+func classifyExplain(tokens []Token) (Kind, error) {
+	analyze := false
+	for i := 1; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.Is("ANALYZE") || t.Is("ANALYSE"):
+			// EXPLAIN (ANALYZE false) doesn't execute the statement
+			next := ""
+			if i+1 < len(tokens) {
+				next = strings.ToUpper(tokens[i+1].Text)
+			}
+			analyze = next != "FALSE" && next != "OFF" && next != "0"
+		case isStatementStart(t) || t.Is("WITH"):
+			inner, err := classify(tokens[i:])
+			if err != nil {
+				return "", err
+			}
+			if analyze {
+				return inner, nil
+			}
+			return Read, nil
+		}
+	}
+	// MySQL EXPLAIN table and DESCRIBE table
+	return Read, nil
+}
+
+// selectsInto reports whether a SELECT has a top level INTO, which creates a table or writes a file
+func selectsInto(tokens []Token) bool {
+	depth := 0
+	for _, t := range tokens {
+		switch {
+		case isOpen(t):
+			depth++
+		case t.Kind == Operator && t.Text == ")":
+			depth--
+		case depth == 0 && t.Is("INTO"):
+			return true
+		}
+	}
+	return false
+}
+
+func isStatementStart(t Token) bool {
+	for _, k := range []string{"SELECT", "INSERT", "UPDATE", "DELETE", "MERGE", "VALUES", "TABLE", "REPLACE"} {
+		if t.Is(k) {
+			return true
+		}
+	}
+	return false
+}
+
+func isOpen(t Token) bool {
+	return t.Kind == Operator && t.Text == "("
+}
+
+// closing returns the index of the parenthesis closing the one at tokens[open], or -1
+func closing(tokens []Token, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		if tokens[i].Kind != Operator {
+			continue
+		}
+		switch tokens[i].Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// posAt returns the offset of tokens[i], or the end of the last token
+func posAt(tokens []Token, i int) int {
+	if i < len(tokens) {
+		return tokens[i].Pos
+	}
+	last := tokens[len(tokens)-1]
+	return last.Pos + len(last.Text)
+}
+
+func worst(a, b Kind) Kind {
+	if severity[b] > severity[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,84 @@
+package sqlparser_test
+
+import (
+	"errors"
+	"testing"
+
+	"exmple.com/database-query-server/internal/sqlparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		dialect     sqlparser.Dialect
+		wantKind    sqlparser.Kind
+		wantKeyword string
+		wantSQL     string
+		wantReason  sqlparser.Reason
+	}{
+		{name: "Happy Flow - SELECT", sql: "SELECT * FROM mydb", wantKind: sqlparser.Read, wantKeyword: "SELECT"},
+		{name: "Happy Flow - lowercase select with trailing semicolon", sql: "  select 1;  ", wantKind: sqlparser.Read, wantKeyword: "SELECT", wantSQL: "select 1"},
+		{name: "Happy Flow - leading comment", sql: "/* report */ -- daily\nSELECT 1", wantKind: sqlparser.Read, wantKeyword: "SELECT", wantSQL: "SELECT 1"},
+		{name: "Happy Flow - CTE", sql: "WITH recent AS (SELECT * FROM orders), top(id) AS MATERIALIZED (SELECT id FROM recent) SELECT * FROM top", wantKind: sqlparser.Read, wantKeyword: "WITH"},
+		{name: "Happy Flow - recursive CTE", sql: "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT x FROM c", wantKind: sqlparser.Read, wantKeyword: "WITH"},
+		{name: "Happy Flow - data modifying CTE", sql: "WITH gone AS (DELETE FROM orders RETURNING *) SELECT count(*) FROM gone", wantKind: sqlparser.DML, wantKeyword: "WITH"},
+		{name: "Happy Flow - EXPLAIN", sql: "EXPLAIN (FORMAT JSON) DELETE FROM orders", wantKind: sqlparser.Read, wantKeyword: "EXPLAIN"},
+		{name: "Happy Flow - EXPLAIN ANALYZE executes the statement", sql: "EXPLAIN ANALYZE DELETE FROM orders", wantKind: sqlparser.DML, wantKeyword: "EXPLAIN"},
+		{name: "Happy Flow - EXPLAIN ANALYZE false", sql: "EXPLAIN (ANALYZE false) DELETE FROM orders", wantKind: sqlparser.Read, wantKeyword: "EXPLAIN"},
+		{name: "Happy Flow - VALUES", sql: "VALUES (1, 'a'), (2, 'b')", wantKind: sqlparser.Read, wantKeyword: "VALUES"},
+		{name: "Happy Flow - parenthesized UNION", sql: "(SELECT 1) UNION (SELECT 2)", wantKind: sqlparser.Read, wantKeyword: "SELECT"},
+		{name: "Happy Flow - SELECT INTO creates a table", sql: "SELECT * INTO backup FROM users", wantKind: sqlparser.DDL, wantKeyword: "SELECT"},
+		{name: "Happy Flow - INSERT", sql: "insert into users (name) values ($1)", wantKind: sqlparser.DML, wantKeyword: "INSERT"},
+		{name: "Happy Flow - DDL", sql: "DROP TABLE users", wantKind: sqlparser.DDL, wantKeyword: "DROP"},
+		{name: "Happy Flow - utility", sql: "VACUUM ANALYZE users", wantKind: sqlparser.Utility, wantKeyword: "VACUUM"},
+		{name: "Happy Flow - function body with semicolons", sql: "CREATE FUNCTION f() RETURNS int LANGUAGE sql AS $$ SELECT 1; $$", wantKind: sqlparser.DDL, wantKeyword: "CREATE"},
+		{name: "Happy Flow - BEGIN ATOMIC function body", sql: "CREATE FUNCTION f() RETURNS int LANGUAGE sql BEGIN ATOMIC SELECT CASE WHEN true THEN 1 END; SELECT 2; END", wantKind: sqlparser.DDL, wantKeyword: "CREATE"},
+		{name: "Happy Flow - MySQL SHOW", sql: "SHOW TABLES", dialect: sqlparser.MySQL, wantKind: sqlparser.Read, wantKeyword: "SHOW"},
+		{name: "Sad Flow - multiple statements", sql: "SELECT 1; DROP TABLE users", wantReason: sqlparser.ReasonMultipleStatements},
+		{name: "Sad Flow - statement hidden after a comment", sql: "SELECT 1 -- x\n; DELETE FROM users", wantReason: sqlparser.ReasonMultipleStatements},
+		{name: "Sad Flow - empty", sql: " ; -- nothing", wantReason: sqlparser.ReasonEmpty},
+		{name: "Sad Flow - unknown statement", sql: "SELECTA SELECT * FROM mydb", wantReason: sqlparser.ReasonUnknownStatement},
+		{name: "Sad Flow - unterminated string", sql: "SELECT 'abc", wantReason: sqlparser.ReasonSyntax},
+		{name: "Sad Flow - CTE without a statement", sql: "WITH a AS (SELECT 1)", wantReason: sqlparser.ReasonSyntax},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialect := tt.dialect
+			if dialect == "" {
+				dialect = sqlparser.Postgres
+			}
+			got, gotErr := sqlparser.Parse(tt.sql, dialect)
+			if gotErr != nil {
+				if tt.wantReason == "" {
+					t.Errorf("Parse() failed: %v", gotErr)
+					return
+				}
+				var rejection *sqlparser.Rejection
+				if !errors.As(gotErr, &rejection) {
+					t.Fatalf("Parse() error is not a Rejection: %v", gotErr)
+				}
+				assert.Equal(t, tt.wantReason, rejection.Reason)
+				return
+			}
+			if tt.wantReason != "" {
+				t.Fatal("Parse() succeeded unexpectedly")
+			}
+			assert.Equal(t, tt.wantKind, got.Kind)
+			assert.Equal(t, tt.wantKeyword, got.Keyword)
+			if tt.wantSQL != "" {
+				assert.Equal(t, tt.wantSQL, got.SQL)
+			}
+		})
+	}
+}
+
+func TestStatement_Allow(t *testing.T) {
+	stmt, err := sqlparser.Parse("DELETE FROM users", sqlparser.Postgres)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	assert.NoError(t, stmt.Allow(sqlparser.DML, sqlparser.DDL))
+	assert.EqualError(t, stmt.Allow(sqlparser.Read), "statement rejected (not_allowed): DELETE is a dml statement, allowed: read")
+}
@@ -0,0 +1,305 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect selects the quoting and comment rules of the tokenizer, the values match the config driver names
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	MySQL    Dialect = "mysql"
+	SQLite   Dialect = "sqlite"
+)
+
+// TokenKind is the lexical class of a Token
+type TokenKind int
+
+const (
+	Word        TokenKind = iota // keywords and unquoted identifiers
+	QuotedIdent                  // "name", `name` or [name]
+	String                       // string literals including E'...' and $tag$...$tag$
+	Number
+	Param     // bind parameters ie. $1, ?, ?2, :name
+	Operator  // punctuation and operators ie. ( , :: <=
+	Semicolon // statement terminator
+)
+
+// Token is a lexical unit of a SQL statement, comments and whitespace are skipped
+type Token struct {
+	Kind TokenKind
+	Text string // the token as written in the statement
+	Pos  int    // byte offset of the token in the statement
+}
+
+// Is reports whether the token is the keyword, case insensitive
+func (t Token) Is(keyword string) bool {
+	return t.Kind == Word && strings.EqualFold(t.Text, keyword)
+}
+
+// Tokenize splits sql into tokens following the dialect's rules for strings, identifiers and comments
+func Tokenize(sql string, d Dialect) ([]Token, error) {
+	var tokens []Token
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		start := i
+		switch {
+		case isSpace(c):
+			i++
+			continue
+
+		case c == '-' && at(sql, i+1) == '-':
+			// MySQL only starts a comment when "--" is followed by whitespace, "1--1" is an expression
+			if d == MySQL && i+2 < len(sql) && !isSpace(sql[i+2]) {
+				tokens = append(tokens, Token{Kind: Operator, Text: "-", Pos: i})
+				i++
+				continue
+			}
+			i = skipLine(sql, i)
+			continue
+
+		case c == '#' && d == MySQL:
+			i = skipLine(sql, i)
+			continue
+
+		case c == '/' && at(sql, i+1) == '*':
+			if d == MySQL && at(sql, i+2) == '!' {
+				// MySQL executes the content of /*! ... */ comments
+				return nil, reject(ReasonSyntax, i, "MySQL executable comments are not allowed")
+			}
+			end, err := skipBlockComment(sql, i, d == Postgres)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			continue
+
+		case c == ';':
+			tokens = append(tokens, Token{Kind: Semicolon, Text: ";", Pos: i})
+			i++
+			continue
+
+		case c == '\'':
+			end, err := scanQuoted(sql, i, '\'', d == MySQL)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			tokens = append(tokens, Token{Kind: String, Text: sql[start:i], Pos: start})
+			continue
+
+		case (c == 'E' || c == 'e') && at(sql, i+1) == '\'' && d == Postgres:
+			// escape string constant, backslash sequences are allowed
+			end, err := scanQuoted(sql, i+1, '\'', true)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			tokens = append(tokens, Token{Kind: String, Text: sql[start:i], Pos: start})
+			continue
+
+		case c == '"':
+			// MySQL treats double quotes as strings unless ANSI_QUOTES is set
+			kind := QuotedIdent
+			if d == MySQL {
+				kind = String
+			}
+			end, err := scanQuoted(sql, i, '"', d == MySQL)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			tokens = append(tokens, Token{Kind: kind, Text: sql[start:i], Pos: start})
+			continue
+
+		case c == '`' && d != Postgres:
+			end, err := scanQuoted(sql, i, '`', false)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			tokens = append(tokens, Token{Kind: QuotedIdent, Text: sql[start:i], Pos: start})
+			continue
+
+		case c == '[' && d == SQLite:
+			end := strings.IndexByte(sql[i:], ']')
+			if end < 0 {
+				return nil, reject(ReasonSyntax, i, "unterminated quoted identifier")
+			}
+			i += end + 1
+			tokens = append(tokens, Token{Kind: QuotedIdent, Text: sql[start:i], Pos: start})
+			continue
+
+		case c == '$' && d == Postgres:
+			if isDigit(at(sql, i+1)) {
+				i = scanWhile(sql, i+1, isDigit)
+				tokens = append(tokens, Token{Kind: Param, Text: sql[start:i], Pos: start})
+				continue
+			}
+			if tag, ok := dollarTag(sql, i); ok {
+				end := strings.Index(sql[i+len(tag):], tag)
+				if end < 0 {
+					return nil, reject(ReasonSyntax, i, fmt.Sprintf("unterminated dollar-quoted string %s", tag))
+				}
+				i += len(tag) + end + len(tag)
+				tokens = append(tokens, Token{Kind: String, Text: sql[start:i], Pos: start})
+				continue
+			}
+
+		case c == '?' && d != Postgres:
+			// ? is a jsonb operator in Postgres
+			i = scanWhile(sql, i+1, isDigit)
+			tokens = append(tokens, Token{Kind: Param, Text: sql[start:i], Pos: start})
+			continue
+
+		case c == ':' && isIdentStart(at(sql, i+1)) && (i == 0 || sql[i-1] != ':'):
+			i = scanWhile(sql, i+1, isIdentPart)
+			tokens = append(tokens, Token{Kind: Param, Text: sql[start:i], Pos: start})
+			continue
+
+		case (c == '@' || c == '$') && d == SQLite && isIdentStart(at(sql, i+1)):
+			i = scanWhile(sql, i+1, isIdentPart)
+			tokens = append(tokens, Token{Kind: Param, Text: sql[start:i], Pos: start})
+			continue
+
+		case c == '@' && d == MySQL:
+			// user and system variables ie. @total, @@version
+			i = scanWhile(sql, i+1, func(b byte) bool { return b == '@' || isIdentPart(b) })
+			tokens = append(tokens, Token{Kind: Word, Text: sql[start:i], Pos: start})
+			continue
+
+		case isDigit(c) || (c == '.' && isDigit(at(sql, i+1))):
+			i = scanNumber(sql, i)
+			tokens = append(tokens, Token{Kind: Number, Text: sql[start:i], Pos: start})
+			continue
+
+		case isIdentStart(c):
+			i = scanWhile(sql, i+1, func(b byte) bool { return isIdentPart(b) || (b == '$' && d != SQLite) })
+			tokens = append(tokens, Token{Kind: Word, Text: sql[start:i], Pos: start})
+			continue
+		}
+
+		// operators, multi character ones are kept together so "::" isn't read as a named parameter
+		i++
+		for _, op := range []string{"::", "<=", ">=", "<>", "!=", "||", "->>", "->", "=>"} {
+			if strings.HasPrefix(sql[start:], op) {
+				i = start + len(op)
+				break
+			}
+		}
+		tokens = append(tokens, Token{Kind: Operator, Text: sql[start:i], Pos: start})
+	}
+	return tokens, nil
+}
+
+// scanQuoted returns the offset after the quoted text starting at sql[i], a doubled quote is an escaped quote
+func scanQuoted(sql string, i int, quote byte, backslash bool) (int, error) {
+	for j := i + 1; j < len(sql); j++ {
+		switch sql[j] {
+		case '\\':
+			if backslash {
+				j++
+			}
+		case quote:
+			if at(sql, j+1) == quote {
+				j++
+				continue
+			}
+			return j + 1, nil
+		}
+	}
+	if quote == '\'' {
+		return 0, reject(ReasonSyntax, i, "unterminated string literal")
+	}
+	return 0, reject(ReasonSyntax, i, "unterminated quoted identifier")
+}
+
+// skipBlockComment returns the offset after the comment starting at sql[i], Postgres comments can be nested
+func skipBlockComment(sql string, i int, nested bool) (int, error) {
+	depth := 0
+	for j := i; j+1 < len(sql); j++ {
+		switch {
+		case sql[j] == '/' && sql[j+1] == '*' && (nested || depth == 0):
+			depth++
+			j++
+		case sql[j] == '*' && sql[j+1] == '/':
+			depth--
+			j++
+			if depth == 0 {
+				return j + 1, nil
+			}
+		}
+	}
+	return 0, reject(ReasonSyntax, i, "unterminated block comment")
+}
+
+// dollarTag returns the $tag$ opening a dollar-quoted string at sql[i]
+func dollarTag(sql string, i int) (string, bool) {
+	j := i + 1
+	if j < len(sql) && isIdentStart(sql[j]) {
+		j = scanWhile(sql, j, isIdentPart)
+	}
+	if at(sql, j) != '$' {
+		return "", false
+	}
+	return sql[i : j+1], true
+}
+
+func scanNumber(sql string, i int) int {
+	i = scanWhile(sql, i, isDigit)
+	if at(sql, i) == '.' {
+		i = scanWhile(sql, i+1, isDigit)
+	}
+	if c := at(sql, i); c == 'e' || c == 'E' {
+		j := i + 1
+		if c := at(sql, j); c == '+' || c == '-' {
+			j++
+		}
+		if isDigit(at(sql, j)) {
+			i = scanWhile(sql, j, isDigit)
+		}
+	}
+	return i
+}
+
+func skipLine(sql string, i int) int {
+	end := strings.IndexByte(sql[i:], '\n')
+	if end < 0 {
+		return len(sql)
+	}
+	return i + end + 1
+}
+
+func scanWhile(sql string, i int, ok func(byte) bool) int {
+	for i < len(sql) && ok(sql[i]) {
+		i++
+	}
+	return i
+}
+
+// at returns the byte at sql[i], or 0 past the end
+func at(sql string, i int) byte {
+	if i < len(sql) {
+		return sql[i]
+	}
+	return 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentStart accepts letters, '_' and the bytes of multi-byte UTF-8 characters
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
@@ -0,0 +1,69 @@
+package sqlparser_test
+
+import (
+	"testing"
+
+	"exmple.com/database-query-server/internal/sqlparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	tok := func(kind sqlparser.TokenKind, text string, pos int) sqlparser.Token {
+		return sqlparser.Token{Kind: kind, Text: text, Pos: pos}
+	}
+
+	tests := []struct {
+		name    string
+		sql     string
+		dialect sqlparser.Dialect
+		want    []sqlparser.Token
+		wantErr string
+	}{
+		{name: "Happy Flow - words, operators and parameters", sql: "select id::text from t where id = $1", dialect: sqlparser.Postgres, want: []sqlparser.Token{
+			tok(sqlparser.Word, "select", 0), tok(sqlparser.Word, "id", 7), tok(sqlparser.Operator, "::", 9), tok(sqlparser.Word, "text", 11),
+			tok(sqlparser.Word, "from", 16), tok(sqlparser.Word, "t", 21), tok(sqlparser.Word, "where", 23), tok(sqlparser.Word, "id", 29),
+			tok(sqlparser.Operator, "=", 32), tok(sqlparser.Param, "$1", 34),
+		}},
+		{name: "Happy Flow - comments are skipped", sql: "-- note; here\nSELECT /* a /* nested */ ; */ 1", dialect: sqlparser.Postgres, want: []sqlparser.Token{
+			tok(sqlparser.Word, "SELECT", 14), tok(sqlparser.Number, "1", 44),
+		}},
+		{name: "Happy Flow - strings hide semicolons", sql: `SELECT 'it''s; fine', "a;b", E'\'; x'`, dialect: sqlparser.Postgres, want: []sqlparser.Token{
+			tok(sqlparser.Word, "SELECT", 0), tok(sqlparser.String, `'it''s; fine'`, 7), tok(sqlparser.Operator, ",", 20),
+			tok(sqlparser.QuotedIdent, `"a;b"`, 22), tok(sqlparser.Operator, ",", 27), tok(sqlparser.String, `E'\'; x'`, 29),
+		}},
+		{name: "Happy Flow - Postgres backslash doesn't escape a standard string", sql: `SELECT 'a\'; DROP`, dialect: sqlparser.Postgres, want: []sqlparser.Token{
+			tok(sqlparser.Word, "SELECT", 0), tok(sqlparser.String, `'a\'`, 7), tok(sqlparser.Semicolon, ";", 11), tok(sqlparser.Word, "DROP", 13),
+		}},
+		{name: "Happy Flow - dollar quoted strings", sql: "SELECT $$a;b$$, $fn$ $$ ; $fn$", dialect: sqlparser.Postgres, want: []sqlparser.Token{
+			tok(sqlparser.Word, "SELECT", 0), tok(sqlparser.String, "$$a;b$$", 7), tok(sqlparser.Operator, ",", 14), tok(sqlparser.String, "$fn$ $$ ; $fn$", 16),
+		}},
+		{name: "Happy Flow - named parameters", sql: "SELECT :name, ?, ?2", dialect: sqlparser.SQLite, want: []sqlparser.Token{
+			tok(sqlparser.Word, "SELECT", 0), tok(sqlparser.Param, ":name", 7), tok(sqlparser.Operator, ",", 12),
+			tok(sqlparser.Param, "?", 14), tok(sqlparser.Operator, ",", 15), tok(sqlparser.Param, "?2", 17),
+		}},
+		{name: "Happy Flow - MySQL quoting and comments", sql: "SELECT `a;b`, 'it\\'s', \"x\" # comment ;", dialect: sqlparser.MySQL, want: []sqlparser.Token{
+			tok(sqlparser.Word, "SELECT", 0), tok(sqlparser.QuotedIdent, "`a;b`", 7), tok(sqlparser.Operator, ",", 12),
+			tok(sqlparser.String, `'it\'s'`, 14), tok(sqlparser.Operator, ",", 21), tok(sqlparser.String, `"x"`, 23),
+		}},
+		{name: "Sad Flow - unterminated string", sql: "SELECT 'abc", dialect: sqlparser.Postgres, wantErr: "statement rejected (syntax): unterminated string literal"},
+		{name: "Sad Flow - unterminated comment", sql: "SELECT 1 /* abc", dialect: sqlparser.Postgres, wantErr: "statement rejected (syntax): unterminated block comment"},
+		{name: "Sad Flow - unterminated dollar quote", sql: "SELECT $x$abc", dialect: sqlparser.Postgres, wantErr: "statement rejected (syntax): unterminated dollar-quoted string $x$"},
+		{name: "Sad Flow - MySQL executable comment", sql: "SELECT 1 /*!50000 ; DROP TABLE t */", dialect: sqlparser.MySQL, wantErr: "statement rejected (syntax): MySQL executable comments are not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := sqlparser.Tokenize(tt.sql, tt.dialect)
+			if gotErr != nil {
+				if tt.wantErr == "" {
+					t.Errorf("Tokenize() failed: %v", gotErr)
+				}
+				assert.EqualError(t, gotErr, tt.wantErr)
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatal("Tokenize() succeeded unexpectedly")
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrFull is returned by Put when the store holds its maximum number of values
+var ErrFull = errors.New("store is full")
+
+// Store holds values under random ids until they are deleted or haven't been used for the idle timeout.
+// Expired values are passed to the evict function, ie. to close the resources they hold.
+type Store[V any] struct {
+	mu       sync.Mutex
+	items    map[string]*item[V]
+	idle     time.Duration
+	max      int
+	evictLRU bool
+	onEvict  func(id string, v V)
+	now      func() time.Time
+	stop     chan struct{}
+	once     sync.Once
+}
+
+type item[V any] struct {
+	value    V
+	lastUsed time.Time
+}
+
+// Option configures optional Store settings
+type Option[V any] func(*Store[V])
+
+// WithMax limits the number of values held, 0 is unlimited
+func WithMax[V any](n int) Option[V] {
+	return func(s *Store[V]) {
+		s.max = n
+	}
+}
+
+// WithEvictOldest makes Put evict the value used least recently when the store is full instead of returning ErrFull
+func WithEvictOldest[V any]() Option[V] {
+	return func(s *Store[V]) {
+		s.evictLRU = true
+	}
+}
+
+// WithEvict sets the function called with values removed because they expired, or when the store is closed
+func WithEvict[V any](fn func(id string, v V)) Option[V] {
+	return func(s *Store[V]) {
+		s.onEvict = fn
+	}
+}
+
+// New creates a Store whose values expire after idle without a Get, a background sweep removes them until Close
+func New[V any](idle time.Duration, opts ...Option[V]) *Store[V] {
+	s := &Store[V]{
+		items: make(map[string]*item[V]),
+		idle:  idle,
+		now:   time.Now,
+		stop:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	go s.sweepEvery(max(idle/4, time.Second))
+	return s
+}
+
+// Put stores v under a new random id, it returns ErrFull when the store holds its maximum number of values unless
+// WithEvictOldest is set
+func (s *Store[V]) Put(v V) (string, error) {
+	s.Sweep()
+
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	evicted := make(map[string]V)
+	for s.max > 0 && len(s.items) >= s.max {
+		if !s.evictLRU {
+			s.mu.Unlock()
+			return "", ErrFull
+		}
+		oldest := s.oldest()
+		evicted[oldest] = s.items[oldest].value
+		delete(s.items, oldest)
+	}
+	s.items[id] = &item[V]{value: v, lastUsed: s.now()}
+	s.mu.Unlock()
+
+	s.evict(evicted)
+	return id, nil
+}
+
+// oldest returns the id of the value used least recently, the store must hold one
+func (s *Store[V]) oldest() string {
+	var oldest string
+	var lastUsed time.Time
+	for id, it := range s.items {
+		if oldest == "" || it.lastUsed.Before(lastUsed) {
+			oldest, lastUsed = id, it.lastUsed
+		}
+	}
+	return oldest
+}
+
+// Get returns the value stored under id and restarts its idle timeout
+func (s *Store[V]) Get(id string) (V, bool) {
+	s.Sweep()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[id]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	it.lastUsed = s.now()
+	return it.value, true
+}
+
+// Delete removes the value stored under id and returns it, the evict function isn't called
+func (s *Store[V]) Delete(id string) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[id]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	delete(s.items, id)
+	return it.value, true
+}
+
+// Len returns the number of values held
+func (s *Store[V]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// Sweep removes the values that expired and passes them to the evict function
+func (s *Store[V]) Sweep() {
+	s.mu.Lock()
+	expired := make(map[string]V)
+	deadline := s.now().Add(-s.idle)
+	for id, it := range s.items {
+		if it.lastUsed.Before(deadline) {
+			expired[id] = it.value
+			delete(s.items, id)
+		}
+	}
+	s.mu.Unlock()
+
+	// evict runs without the lock, it may close connections
+	s.evict(expired)
+}
+
+// Close stops the background sweep and evicts every value
+func (s *Store[V]) Close() {
+	s.once.Do(func() { close(s.stop) })
+
+	s.mu.Lock()
+	all := make(map[string]V, len(s.items))
+	for id, it := range s.items {
+		all[id] = it.value
+	}
+	s.items = make(map[string]*item[V])
+	s.mu.Unlock()
+
+	s.evict(all)
+}
+
+func (s *Store[V]) evict(values map[string]V) {
+	if s.onEvict == nil {
+		return
+	}
+	for id, v := range values {
+		s.onEvict(id, v)
+	}
+}
+
+func (s *Store[V]) sweepEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Sweep()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// newID returns 128 random bits in hex, ids can't be guessed from other ids
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,158 @@
+package store
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clock is a manually advanced time source
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestStore(t *testing.T, opts ...Option[string]) (*Store[string], *clock, map[string]string) {
+	clk := &clock{now: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)}
+	evicted := make(map[string]string)
+	var mu sync.Mutex
+	opts = append(opts, WithEvict(func(id string, v string) {
+		mu.Lock()
+		defer mu.Unlock()
+		evicted[id] = v
+	}))
+	s := New(time.Minute, opts...)
+	s.now = clk.Now
+	t.Cleanup(s.Close)
+	return s, clk, evicted
+}
+
+func TestStore(t *testing.T) {
+	tests := []struct {
+		name        string
+		run         func(s *Store[string], clk *clock) (string, bool)
+		wantValue   string
+		wantOk      bool
+		wantEvicted []string
+	}{
+		{
+			name: "Happy Flow - Get returns the value",
+			run: func(s *Store[string], clk *clock) (string, bool) {
+				id, _ := s.Put("a")
+				return s.Get(id)
+			},
+			wantValue: "a",
+			wantOk:    true,
+		},
+		{
+			name: "Happy Flow - Get restarts the idle timeout",
+			run: func(s *Store[string], clk *clock) (string, bool) {
+				id, _ := s.Put("a")
+				clk.Add(40 * time.Second)
+				s.Get(id)
+				clk.Add(40 * time.Second)
+				return s.Get(id)
+			},
+			wantValue: "a",
+			wantOk:    true,
+		},
+		{
+			name: "Sad Flow - idle value is evicted",
+			run: func(s *Store[string], clk *clock) (string, bool) {
+				id, _ := s.Put("a")
+				clk.Add(2 * time.Minute)
+				return s.Get(id)
+			},
+			wantOk:      false,
+			wantEvicted: []string{"a"},
+		},
+		{
+			name: "Sad Flow - deleted value isn't evicted",
+			run: func(s *Store[string], clk *clock) (string, bool) {
+				id, _ := s.Put("a")
+				s.Delete(id)
+				clk.Add(2 * time.Minute)
+				return s.Get(id)
+			},
+			wantOk: false,
+		},
+		{
+			name: "Sad Flow - unknown id",
+			run: func(s *Store[string], clk *clock) (string, bool) {
+				return s.Get("unknown")
+			},
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, clk, evicted := newTestStore(t)
+			value, ok := tt.run(s, clk)
+			assert.Equal(t, tt.wantValue, value)
+			assert.Equal(t, tt.wantOk, ok)
+
+			var got []string
+			for _, v := range evicted {
+				got = append(got, v)
+			}
+			assert.Equal(t, tt.wantEvicted, got)
+		})
+	}
+}
+
+func TestStore_Max(t *testing.T) {
+	s, _, _ := newTestStore(t, WithMax[string](1))
+
+	id, err := s.Put("a")
+	assert.NoError(t, err)
+	_, err = s.Put("b")
+	assert.ErrorIs(t, err, ErrFull)
+
+	// a deleted value frees its slot
+	s.Delete(id)
+	_, err = s.Put("b")
+	assert.NoError(t, err)
+}
+
+func TestStore_EvictOldest(t *testing.T) {
+	s, clk, evicted := newTestStore(t, WithMax[string](2), WithEvictOldest[string]())
+
+	a, _ := s.Put("a")
+	clk.Add(time.Second)
+	b, _ := s.Put("b")
+	clk.Add(time.Second)
+	// a was used last, b is the oldest
+	s.Get(a)
+
+	c, err := s.Put("c")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, s.Len())
+	assert.Equal(t, map[string]string{b: "b"}, evicted)
+	_, ok := s.Get(a)
+	assert.True(t, ok)
+	_, ok = s.Get(c)
+	assert.True(t, ok)
+}
+
+func TestStore_Close(t *testing.T) {
+	s, _, evicted := newTestStore(t)
+	id, _ := s.Put("a")
+
+	s.Close()
+	assert.Equal(t, map[string]string{id: "a"}, evicted)
+	assert.Equal(t, 0, s.Len())
+}
@@ -0,0 +1,395 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/decimal256"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+const (
+	// ArrowMimeType is the media type of an Arrow IPC file
+	ArrowMimeType = "application/vnd.apache.arrow.file"
+	// ParquetMimeType is the media type of a Parquet file
+	ParquetMimeType = "application/vnd.apache.parquet"
+	// arrowTypeKey is the field metadata key holding the database type of a column
+	arrowTypeKey = "database_type"
+)
+
+// ResultToArrow converts a result set into an Arrow IPC file with one record batch, see ArrowSchema for the types
+func ResultToArrow(result *types.ResultSet) ([]byte, error) {
+	rec, err := arrowRecord(result)
+	if err != nil {
+		return nil, err
+	}
+	defer rec.Release()
+
+	var buf bytes.Buffer
+	w, err := ipc.NewFileWriter(&buf, ipc.WithSchema(rec.Schema()))
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Write(rec); err != nil {
+		return nil, fmt.Errorf("arrow write error: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("arrow write error: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ResultToParquet converts a result set into a Snappy compressed Parquet file, see ArrowSchema for the types
+func ResultToParquet(result *types.ResultSet) ([]byte, error) {
+	rec, err := arrowRecord(result)
+	if err != nil {
+		return nil, err
+	}
+	defer rec.Release()
+
+	var buf bytes.Buffer
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy))
+	w, err := pqarrow.NewFileWriter(rec.Schema(), &buf, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Write(rec); err != nil {
+		return nil, fmt.Errorf("parquet write error: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("parquet write error: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ArrowSchema maps the columns of a result to Arrow fields by their database type: integers, floats, booleans,
+// numeric and decimal with a precision as decimals, dates as date32, timestamp and timestamptz as microsecond
+// timestamps, timestamptz in UTC, bytea as binary, text types as strings and Postgres arrays as lists of their element
+// type, nested as deep as the arrays of the result. The type names are the Postgres ones, MySQL and SQLite columns
+// share some of them, ie. DATE, TIMESTAMP, DECIMAL, VARCHAR and TEXT. Columns of other types take the type of their
+// values, and so do columns with a value their type can't hold, ie. a SQLite DATE holding text or a numeric NaN.
+// Columns with values that don't fit their own type either, ie. a uint above the int64 range, are strings.
+// Every field is nullable and keeps the database type in its metadata.
+func ArrowSchema(result *types.ResultSet) *arrow.Schema {
+	fields := make([]arrow.Field, len(result.Columns))
+	for i, c := range result.Columns {
+		dataType := arrowType(c)
+		if dataType != nil {
+			// Postgres reports the same type for arrays of every dimension
+			if _, ok := dataType.(*arrow.ListType); ok {
+				for range arrayDepth(result.Rows, i) - 1 {
+					dataType = arrow.ListOf(dataType)
+				}
+			}
+			if !fitsArrow(dataType, result.Rows, i) {
+				dataType = nil
+			}
+		}
+		if dataType == nil {
+			dataType = inferArrowType(result.Rows, i)
+			if !fitsArrow(dataType, result.Rows, i) {
+				dataType = arrow.BinaryTypes.String
+			}
+		}
+		fields[i] = arrow.Field{
+			Name:     c.Name,
+			Type:     dataType,
+			Nullable: true,
+			Metadata: arrow.NewMetadata([]string{arrowTypeKey}, []string{c.Type}),
+		}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// fitsArrow reports whether every value of a column can be written as dataType
+func fitsArrow(dataType arrow.DataType, rows [][]any, col int) bool {
+	b := array.NewBuilder(memory.DefaultAllocator, dataType)
+	defer b.Release()
+	for _, row := range rows {
+		if err := appendArrow(b, row[col]); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// arrowType returns the Arrow type of a column by its Postgres type name, nil when the type isn't known
+func arrowType(c types.Column) arrow.DataType {
+	if elem, ok := strings.CutPrefix(c.Type, "_"); ok {
+		if t := arrowType(types.Column{Type: elem}); t != nil {
+			return arrow.ListOf(t)
+		}
+		return arrow.ListOf(arrow.BinaryTypes.String)
+	}
+	switch c.Type {
+	case "INT2":
+		return arrow.PrimitiveTypes.Int16
+	case "INT4":
+		return arrow.PrimitiveTypes.Int32
+	case "INT8", "OID":
+		return arrow.PrimitiveTypes.Int64
+	case "FLOAT4":
+		return arrow.PrimitiveTypes.Float32
+	case "FLOAT8":
+		return arrow.PrimitiveTypes.Float64
+	case "BOOL":
+		return arrow.FixedWidthTypes.Boolean
+	case "NUMERIC", "DECIMAL":
+		// numeric without a precision can hold any number of digits, it stays text
+		switch {
+		case c.Precision <= 0:
+			return arrow.BinaryTypes.String
+		case c.Precision <= decimal128.MaxPrecision:
+			return &arrow.Decimal128Type{Precision: int32(c.Precision), Scale: int32(c.Scale)}
+		case c.Precision <= decimal256.MaxPrecision:
+			return &arrow.Decimal256Type{Precision: int32(c.Precision), Scale: int32(c.Scale)}
+		default:
+			return arrow.BinaryTypes.String
+		}
+	case "DATE":
+		return arrow.FixedWidthTypes.Date32
+	case "TIMESTAMP":
+		return &arrow.TimestampType{Unit: arrow.Microsecond}
+	case "TIMESTAMPTZ":
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+	case "BYTEA":
+		return arrow.BinaryTypes.Binary
+	case "TEXT", "VARCHAR", "BPCHAR", "NAME", "CHAR", "JSON", "JSONB", "UUID", "XML",
+		"TIME", "TIMETZ", "INTERVAL", "INET", "CIDR", "MACADDR", "MONEY":
+		return arrow.BinaryTypes.String
+	}
+	return nil
+}
+
+// arrayDepth returns the most dimensions of the array values of a column
+func arrayDepth(rows [][]any, col int) int {
+	var depth func(val any) int
+	depth = func(val any) int {
+		elems, ok := val.([]any)
+		if !ok {
+			return 0
+		}
+		deepest := 0
+		for _, elem := range elems {
+			deepest = max(deepest, depth(elem))
+		}
+		return deepest + 1
+	}
+	deepest := 0
+	for _, row := range rows {
+		deepest = max(deepest, depth(row[col]))
+	}
+	return deepest
+}
+
+// inferArrowType returns the Arrow type of the values of a column, columns mixing types are strings
+func inferArrowType(rows [][]any, col int) arrow.DataType {
+	var inferred arrow.DataType
+	for _, row := range rows {
+		var t arrow.DataType
+		switch row[col].(type) {
+		case nil:
+			continue
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32:
+			t = arrow.PrimitiveTypes.Int64
+		case float32, float64:
+			t = arrow.PrimitiveTypes.Float64
+		case bool:
+			t = arrow.FixedWidthTypes.Boolean
+		case time.Time:
+			t = &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+		case types.Binary, []byte:
+			t = arrow.BinaryTypes.Binary
+		default:
+			t = arrow.BinaryTypes.String
+		}
+		if inferred != nil && !arrow.TypeEqual(inferred, t) {
+			return arrow.BinaryTypes.String
+		}
+		inferred = t
+	}
+	if inferred == nil {
+		return arrow.BinaryTypes.String
+	}
+	return inferred
+}
+
+// arrowRecord builds a record batch of the rows with the ArrowSchema of the result
+func arrowRecord(result *types.ResultSet) (arrow.RecordBatch, error) {
+	schema := ArrowSchema(result)
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+
+	for r, row := range result.Rows {
+		for i, val := range row {
+			if err := appendArrow(b.Field(i), val); err != nil {
+				return nil, fmt.Errorf("row %d column %q: %w", r+1, result.Columns[i].Name, err)
+			}
+		}
+	}
+	return b.NewRecordBatch(), nil
+}
+
+// appendArrow appends a value to the builder of its column, nil is null
+func appendArrow(b array.Builder, val any) error {
+	if val == nil {
+		b.AppendNull()
+		return nil
+	}
+	switch b := b.(type) {
+	case *array.Int16Builder:
+		n, err := arrowInt(val, math.MinInt16, math.MaxInt16)
+		if err == nil {
+			b.Append(int16(n))
+		}
+		return err
+	case *array.Int32Builder:
+		n, err := arrowInt(val, math.MinInt32, math.MaxInt32)
+		if err == nil {
+			b.Append(int32(n))
+		}
+		return err
+	case *array.Int64Builder:
+		n, err := arrowInt(val, math.MinInt64, math.MaxInt64)
+		if err == nil {
+			b.Append(n)
+		}
+		return err
+	case *array.Float32Builder:
+		f, err := arrowFloat(val)
+		if err == nil {
+			b.Append(float32(f))
+		}
+		return err
+	case *array.Float64Builder:
+		f, err := arrowFloat(val)
+		if err == nil {
+			b.Append(f)
+		}
+		return err
+	case *array.BooleanBuilder:
+		v, ok := val.(bool)
+		if !ok {
+			return fmt.Errorf("%T can't be written as boolean", val)
+		}
+		b.Append(v)
+	case *array.Decimal128Builder:
+		t := b.Type().(*arrow.Decimal128Type)
+		n, err := decimal128.FromString(formatValue(val), t.Precision, t.Scale)
+		if err != nil {
+			return fmt.Errorf("%v can't be written as %s: %w", val, t, err)
+		}
+		b.Append(n)
+	case *array.Decimal256Builder:
+		t := b.Type().(*arrow.Decimal256Type)
+		n, err := decimal256.FromString(formatValue(val), t.Precision, t.Scale)
+		if err != nil {
+			return fmt.Errorf("%v can't be written as %s: %w", val, t, err)
+		}
+		b.Append(n)
+	case *array.Date32Builder:
+		t, ok := val.(time.Time)
+		if !ok {
+			return fmt.Errorf("%T can't be written as date", val)
+		}
+		b.Append(arrow.Date32FromTime(t))
+	case *array.TimestampBuilder:
+		t, ok := val.(time.Time)
+		if !ok {
+			return fmt.Errorf("%T can't be written as timestamp", val)
+		}
+		ts, err := arrow.TimestampFromTime(t, arrow.Microsecond)
+		if err != nil {
+			return err
+		}
+		b.Append(ts)
+	case *array.BinaryBuilder:
+		switch v := val.(type) {
+		case types.Binary:
+			b.Append(v)
+		case []byte:
+			b.Append(v)
+		default:
+			return fmt.Errorf("%T can't be written as binary", val)
+		}
+	case *array.StringBuilder:
+		b.Append(formatValue(val))
+	case *array.ListBuilder:
+		elems, ok := val.([]any)
+		if !ok {
+			return fmt.Errorf("%T can't be written as list", val)
+		}
+		b.Append(true)
+		for _, elem := range elems {
+			if err := appendArrow(b.ValueBuilder(), elem); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%s columns are not supported", b.Type())
+	}
+	return nil
+}
+
+// arrowInt returns an integer value checked against the range of its column
+func arrowInt(val any, minVal, maxVal int64) (int64, error) {
+	var n int64
+	switch v := val.(type) {
+	case int64:
+		n = v
+	case int:
+		n = int64(v)
+	case int32:
+		n = int64(v)
+	case int16:
+		n = int64(v)
+	case int8:
+		n = int64(v)
+	case uint8:
+		n = int64(v)
+	case uint16:
+		n = int64(v)
+	case uint32:
+		n = int64(v)
+	case uint:
+		if uint64(v) > math.MaxInt64 {
+			return 0, fmt.Errorf("%d is out of range", v)
+		}
+		n = int64(v)
+	default:
+		return 0, fmt.Errorf("%T can't be written as integer", val)
+	}
+	if n < minVal || n > maxVal {
+		return 0, fmt.Errorf("%d is out of range", n)
+	}
+	return n, nil
+}
+
+// arrowFloat returns a float value, NaN and infinity are decoded as text
+func arrowFloat(val any) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case json.Number:
+		return v.Float64()
+	default:
+		return 0, fmt.Errorf("%T can't be written as float", val)
+	}
+}
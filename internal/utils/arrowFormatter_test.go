@@ -0,0 +1,161 @@
+package utils_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"exmple.com/database-query-server/internal/utils"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArrowSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		column types.Column
+		values []any
+		want   arrow.DataType
+	}{
+		{name: "Happy Flow - int4 is int32", column: types.Column{Type: "INT4"}, values: []any{int64(1)}, want: arrow.PrimitiveTypes.Int32},
+		{name: "Happy Flow - int8 is int64", column: types.Column{Type: "INT8"}, values: []any{int64(1)}, want: arrow.PrimitiveTypes.Int64},
+		{name: "Happy Flow - float8 is float64", column: types.Column{Type: "FLOAT8"}, values: []any{1.5}, want: arrow.PrimitiveTypes.Float64},
+		{name: "Happy Flow - numeric with a precision is decimal128", column: types.Column{Type: "NUMERIC", Precision: 10, Scale: 2}, values: []any{json.Number("10.50")}, want: &arrow.Decimal128Type{Precision: 10, Scale: 2}},
+		{name: "Happy Flow - wide numeric is decimal256", column: types.Column{Type: "NUMERIC", Precision: 50, Scale: 5}, values: []any{json.Number("1.5")}, want: &arrow.Decimal256Type{Precision: 50, Scale: 5}},
+		{name: "Happy Flow - numeric without a precision is text", column: types.Column{Type: "NUMERIC"}, values: []any{json.Number("1.5")}, want: arrow.BinaryTypes.String},
+		{name: "Happy Flow - timestamptz is a UTC timestamp", column: types.Column{Type: "TIMESTAMPTZ"}, values: []any{time.Now()}, want: &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}},
+		{name: "Happy Flow - timestamp has no zone", column: types.Column{Type: "TIMESTAMP"}, values: []any{time.Now()}, want: &arrow.TimestampType{Unit: arrow.Microsecond}},
+		{name: "Happy Flow - date is date32", column: types.Column{Type: "DATE"}, values: []any{time.Now()}, want: arrow.FixedWidthTypes.Date32},
+		{name: "Happy Flow - bytea is binary", column: types.Column{Type: "BYTEA"}, values: []any{types.Binary{1}}, want: arrow.BinaryTypes.Binary},
+		{name: "Happy Flow - jsonb is text", column: types.Column{Type: "JSONB"}, values: []any{json.RawMessage(`{}`)}, want: arrow.BinaryTypes.String},
+		{name: "Happy Flow - array is a list of its element type", column: types.Column{Type: "_INT4"}, values: []any{[]any{int64(1), nil}}, want: arrow.ListOf(arrow.PrimitiveTypes.Int32)},
+		{name: "Happy Flow - two dimensional array is a list of lists", column: types.Column{Type: "_TEXT"}, values: []any{nil, []any{[]any{"a"}, []any{"b"}}}, want: arrow.ListOf(arrow.ListOf(arrow.BinaryTypes.String))},
+		{name: "Happy Flow - MySQL decimal is decimal128", column: types.Column{Type: "DECIMAL", Precision: 8, Scale: 3}, values: []any{json.Number("1.250")}, want: &arrow.Decimal128Type{Precision: 8, Scale: 3}},
+		{name: "Happy Flow - SQLite date holding text takes the type of its values", column: types.Column{Type: "DATE"}, values: []any{time.Now(), "2024-02-30"}, want: arrow.BinaryTypes.String},
+		{name: "Happy Flow - numeric NaN takes the type of its values", column: types.Column{Type: "NUMERIC", Precision: 10, Scale: 2}, values: []any{json.Number("10.50"), "NaN"}, want: arrow.BinaryTypes.String},
+		{name: "Happy Flow - value out of the column range takes the type of its values", column: types.Column{Type: "INT2"}, values: []any{int64(70000)}, want: arrow.PrimitiveTypes.Int64},
+		{name: "Happy Flow - unknown type takes the type of its values", column: types.Column{Type: "INTEGER"}, values: []any{int64(1), nil}, want: arrow.PrimitiveTypes.Int64},
+		{name: "Happy Flow - uint values are int64", column: types.Column{Type: ""}, values: []any{uint(7)}, want: arrow.PrimitiveTypes.Int64},
+		{name: "Happy Flow - uint above the int64 range is text", column: types.Column{Type: ""}, values: []any{uint(7), uint(math.MaxUint64)}, want: arrow.BinaryTypes.String},
+		{name: "Happy Flow - mixed values are text", column: types.Column{Type: ""}, values: []any{int64(1), "a"}, want: arrow.BinaryTypes.String},
+		{name: "Happy Flow - only nulls are text", column: types.Column{Type: ""}, values: []any{nil}, want: arrow.BinaryTypes.String},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.column.Name = "c"
+			result := &types.ResultSet{Columns: []types.Column{tt.column}}
+			for _, v := range tt.values {
+				result.Rows = append(result.Rows, []any{v})
+			}
+			got := utils.ArrowSchema(result).Field(0)
+			assert.True(t, arrow.TypeEqual(tt.want, got.Type), "want %s, got %s", tt.want, got.Type)
+			assert.True(t, got.Nullable)
+		})
+	}
+}
+
+func TestResultToArrow(t *testing.T) {
+	result := &types.ResultSet{
+		Columns: []types.Column{
+			{Name: "id", Type: "INT4"}, {Name: "price", Type: "NUMERIC", Precision: 10, Scale: 2}, {Name: "at", Type: "TIMESTAMPTZ"},
+			{Name: "tags", Type: "_TEXT"}, {Name: "photo", Type: "BYTEA"},
+		},
+		Rows: [][]any{
+			{int64(1), json.Number("10.50"), time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), []any{"a", nil}, types.Binary{0xde, 0xad}},
+			{int64(2), nil, nil, nil, nil},
+		},
+	}
+	want := `[{"id":1,"price":"10.5","at":"2024-01-02T03:04:05Z","tags":["a",null],"photo":"3q0="},{"id":2,"price":null,"at":null,"tags":null,"photo":null}]`
+
+	tests := []struct {
+		name   string
+		format func(*types.ResultSet) ([]byte, error)
+		read   func(*testing.T, []byte) arrow.RecordBatch
+	}{
+		{name: "Happy Flow - Arrow IPC file", format: utils.ResultToArrow, read: readArrow},
+		{name: "Happy Flow - Parquet file", format: utils.ResultToParquet, read: readParquet},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enco, err := tt.format(result)
+			if err != nil {
+				t.Fatalf("format failed: %v", err)
+			}
+			rec := tt.read(t, enco)
+			defer rec.Release()
+
+			assert.True(t, arrow.TypeEqual(&arrow.Decimal128Type{Precision: 10, Scale: 2}, rec.Schema().Field(1).Type))
+			got, err := rec.MarshalJSON()
+			if err != nil {
+				t.Fatalf("failed to encode record: %v", err)
+			}
+			assert.JSONEq(t, want, string(got))
+		})
+	}
+
+	t.Run("Happy Flow - values their column type can't hold are written as text", func(t *testing.T) {
+		enco, err := utils.ResultToArrow(&types.ResultSet{
+			Columns: []types.Column{{Name: "day", Type: "DATE"}, {Name: "price", Type: "NUMERIC", Precision: 10, Scale: 2}},
+			Rows:    [][]any{{"2024-01-02", "NaN"}, {"yesterday", json.Number("10.50")}},
+		})
+		if err != nil {
+			t.Fatalf("ResultToArrow() failed: %v", err)
+		}
+		rec := readArrow(t, enco)
+		defer rec.Release()
+		got, err := rec.MarshalJSON()
+		if err != nil {
+			t.Fatalf("failed to encode record: %v", err)
+		}
+		assert.JSONEq(t, `[{"day":"2024-01-02","price":"NaN"},{"day":"yesterday","price":"10.50"}]`, string(got))
+		assert.Equal(t, "DATE", rec.Schema().Field(0).Metadata.Values()[0])
+	})
+}
+
+// readArrow reads the first record batch of an Arrow IPC file
+func readArrow(t *testing.T, enco []byte) arrow.RecordBatch {
+	r, err := ipc.NewFileReader(bytes.NewReader(enco))
+	if err != nil {
+		t.Fatalf("failed to open Arrow file: %v", err)
+	}
+	defer r.Close()
+	rec, err := r.RecordBatch(0)
+	if err != nil {
+		t.Fatalf("failed to read Arrow file: %v", err)
+	}
+	rec.Retain()
+	return rec
+}
+
+// readParquet reads a Parquet file as one record batch
+func readParquet(t *testing.T, enco []byte) arrow.RecordBatch {
+	pf, err := file.NewParquetReader(bytes.NewReader(enco))
+	if err != nil {
+		t.Fatalf("failed to open Parquet file: %v", err)
+	}
+	defer pf.Close()
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatalf("failed to open Parquet file: %v", err)
+	}
+	tbl, err := fr.ReadTable(context.Background())
+	if err != nil {
+		t.Fatalf("failed to read Parquet file: %v", err)
+	}
+	defer tbl.Release()
+	tr := array.NewTableReader(tbl, tbl.NumRows())
+	defer tr.Release()
+	tr.Next()
+	rec := tr.RecordBatch()
+	rec.Retain()
+	return rec
+}
@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"html"
 	"reflect"
-	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"exmple.com/database-query-server/pkg/types"
+	"gopkg.in/yaml.v3"
 )
 
 // dataToJson converts a slice of maps containing data into a JSON string
@@ -21,20 +26,101 @@ func DataToJson(data []map[string]interface{}) (string, error) {
 	return string(enco), nil
 }
 
-// dataToCSV converts a slice of maps into a CSV string
+// ResultToJson converts a result set into a JSON array of objects, the keys keep the column order
+func ResultToJson(result *types.ResultSet) (string, error) {
+	keys, err := jsonKeys(result.Columns)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for r, row := range result.Rows {
+		if r > 0 {
+			b.WriteByte(',')
+		}
+		if err := writeJsonObject(&b, keys, row); err != nil {
+			return "", err
+		}
+	}
+	b.WriteByte(']')
+	return b.String(), nil
+}
+
+// ResultToNDJson converts a result set into newline-delimited JSON, one object per row with the keys in column order
+func ResultToNDJson(result *types.ResultSet) (string, error) {
+	keys, err := jsonKeys(result.Columns)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	for _, row := range result.Rows {
+		if err := writeJsonObject(&b, keys, row); err != nil {
+			return "", err
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// jsonKeys encodes the column names as JSON object keys
+func jsonKeys(columns []types.Column) ([][]byte, error) {
+	keys := make([][]byte, len(columns))
+	for i, c := range columns {
+		key, err := json.Marshal(c.Name)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// writeJsonObject writes a row as a JSON object with the keys in column order
+func writeJsonObject(b *bytes.Buffer, keys [][]byte, row []any) error {
+	b.WriteByte('{')
+	for i, val := range row {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		enco, err := jsonValue(val)
+		if err != nil {
+			return err
+		}
+		b.Write(keys[i])
+		b.WriteByte(':')
+		b.Write(enco)
+	}
+	b.WriteByte('}')
+	return nil
+}
+
+// jsonValue encodes a cell value as JSON, raw bytes would be base64 so they are rendered with the same marker as
+// types.Binary
+func jsonValue(val any) ([]byte, error) {
+	if b, ok := val.([]byte); ok {
+		val = types.Binary(b)
+	}
+	return json.Marshal(val)
+}
+
+// dataToCSV converts a slice of maps into a CSV string, the columns are sorted by name
 func DataToCSV(data []map[string]interface{}) (string, error) {
 	if len(data) == 0 {
 		return "", fmt.Errorf("no data to convert")
 	}
-
-	// Extract headers from the first map
-	headers := make([]string, 0, len(data[0]))
-	for key := range data[0] {
-		headers = append(headers, key)
-	}
 	// Map iteration order is intentionally randomized, so we use sorting for consistency
 	// See https://go.dev/blog/maps#iteration-order
-	sort.Strings(headers)
+	return ResultToCSV(types.ResultFromMaps(data))
+}
+
+// ResultToCSV converts a result set into a CSV string with a header row in column order
+func ResultToCSV(result *types.ResultSet) (string, error) {
+	headers := make([]string, len(result.Columns))
+	for i, c := range result.Columns {
+		headers[i] = c.Name
+	}
 
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
@@ -45,25 +131,10 @@ func DataToCSV(data []map[string]interface{}) (string, error) {
 	}
 
 	// Write rows
-	for _, row := range data {
+	for _, row := range result.Rows {
 		record := make([]string, len(headers))
-		for i, header := range headers {
-			if val, ok := row[header]; ok && val != nil {
-				switch v := val.(type) {
-				case string:
-					record[i] = v
-				case fmt.Stringer:
-					record[i] = v.String()
-				case int, int8, int16, int32, int64:
-					record[i] = fmt.Sprintf("%d", v)
-				case float32, float64:
-					record[i] = strconv.FormatFloat(reflect.ValueOf(v).Float(), 'f', -1, 64)
-				case bool:
-					record[i] = strconv.FormatBool(v)
-				default:
-					record[i] = fmt.Sprintf("%v", v)
-				}
-			}
+		for i, val := range row {
+			record[i] = formatValue(val)
 		}
 		if err := writer.Write(record); err != nil {
 			return "", fmt.Errorf("failed to write record: %w", err)
@@ -78,55 +149,74 @@ func DataToCSV(data []map[string]interface{}) (string, error) {
 	return buf.String(), nil
 }
 
+// formatValue converts a cell value into its text for CSV, HTML, Markdown and XML, nil is empty.
+// Embedded JSON, exact numbers and binary values are written the way they appear in JSON output,
+// arrays and objects are written as JSON.
+func formatValue(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.RawMessage:
+		return string(v)
+	case json.Number:
+		return v.String()
+	case []byte:
+		return types.Binary(v).String()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v)
+	case float32, float64:
+		return strconv.FormatFloat(reflect.ValueOf(v).Float(), 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}, map[string]interface{}:
+		if enco, err := json.Marshal(v); err == nil {
+			return string(enco)
+		}
+	}
+	return fmt.Sprintf("%v", val)
+}
+
 // dataHTMLTable converts a slice of maps into an HTML table string.
 // - rows: each map represents one table row (key -> cell value).
 // - Columns are the union of all map keys, sorted alphabetically for deterministic output.
 func DataToHTMLTable(rows []map[string]interface{}) (string, error) {
-	var b strings.Builder
-
-	// start table
-	b.WriteString("<table>")
-
 	// no rows - return error
 	if len(rows) == 0 {
 		return "", fmt.Errorf("no data to convert")
 	}
+	return ResultToHTMLTable(types.ResultFromMaps(rows))
+}
 
-	// collect all column names
-	colSet := make(map[string]struct{})
-	for _, r := range rows {
-		for k := range r {
-			colSet[k] = struct{}{}
-		}
-	}
+// ResultToHTMLTable converts a result set into an HTML table string with the columns in select order
+func ResultToHTMLTable(result *types.ResultSet) (string, error) {
+	var b strings.Builder
 
-	cols := make([]string, 0, len(colSet))
-	for k := range colSet {
-		cols = append(cols, k)
-	}
-	sort.Strings(cols) // deterministic order
+	// start table
+	b.WriteString("<table>")
 
 	// header
 	b.WriteString("<thead><tr>")
-	for _, c := range cols {
+	for _, c := range result.Columns {
 		b.WriteString("<th>")
-		b.WriteString(html.EscapeString(c))
+		b.WriteString(html.EscapeString(c.Name))
 		b.WriteString("</th>")
 	}
 	b.WriteString("</tr></thead>")
 
 	// body
 	b.WriteString("<tbody>")
-	for _, r := range rows {
+	for _, r := range result.Rows {
 		b.WriteString("<tr>")
-		for _, c := range cols {
+		for _, val := range r {
 			b.WriteString("<td>")
-			val, ok := r[c]
-			if ok && val != nil {
-				// convert value to string and escape HTML
-				b.WriteString(html.EscapeString(fmt.Sprint(val)))
-			}
-			// if missing or nil -> empty cell
+			// nil -> empty cell
+			b.WriteString(html.EscapeString(formatValue(val)))
 			b.WriteString("</td>")
 		}
 		b.WriteString("</tr>")
@@ -138,3 +228,134 @@ func DataToHTMLTable(rows []map[string]interface{}) (string, error) {
 
 	return b.String(), nil
 }
+
+// DataToMarkdownTable converts a slice of maps into a GitHub-flavoured Markdown table, the columns are sorted by name.
+// Cells longer than maxWidth characters are cut with an ellipsis, 0 keeps them whole.
+func DataToMarkdownTable(rows []map[string]interface{}, maxWidth int) (string, error) {
+	if len(rows) == 0 {
+		return "", fmt.Errorf("no data to convert")
+	}
+	return ResultToMarkdownTable(types.ResultFromMaps(rows), maxWidth)
+}
+
+// ResultToMarkdownTable converts a result set into a GitHub-flavoured Markdown pipe table with the columns in select
+// order. Cells longer than maxWidth characters are cut with an ellipsis, 0 keeps them whole.
+func ResultToMarkdownTable(result *types.ResultSet, maxWidth int) (string, error) {
+	if len(result.Columns) == 0 {
+		return "", nil
+	}
+	var b strings.Builder
+
+	writeRow := func(cells []string, width int) {
+		b.WriteByte('|')
+		for _, cell := range cells {
+			b.WriteByte(' ')
+			b.WriteString(markdownCell(cell, width))
+			b.WriteString(" |")
+		}
+		b.WriteByte('\n')
+	}
+
+	// header and delimiter row, column names are never cut
+	headers := make([]string, len(result.Columns))
+	for i, c := range result.Columns {
+		headers[i] = c.Name
+	}
+	writeRow(headers, 0)
+	b.WriteString(strings.Repeat("| --- ", len(headers)))
+	b.WriteString("|\n")
+
+	// body
+	cells := make([]string, len(headers))
+	for _, row := range result.Rows {
+		for i, val := range row {
+			cells[i] = formatValue(val)
+		}
+		writeRow(cells, maxWidth)
+	}
+	return b.String(), nil
+}
+
+// markdownEscaper keeps a value in its table cell, pipes would end the cell and newlines the row
+var markdownEscaper = strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+// markdownCell cuts a value to maxWidth characters and escapes it for a Markdown table
+func markdownCell(val string, maxWidth int) string {
+	if maxWidth > 0 && utf8.RuneCountInString(val) > maxWidth {
+		runes := []rune(val)
+		val = string(runes[:maxWidth-1]) + "…"
+	}
+	return markdownEscaper.Replace(val)
+}
+
+// ResultToYAML converts a result set into a YAML sequence of mappings, the keys keep the column order.
+// Values are typed as in JSON output, embedded JSON becomes nested YAML.
+func ResultToYAML(result *types.ResultSet) (string, error) {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Content: make([]*yaml.Node, 0, len(result.Rows))}
+	for _, row := range result.Rows {
+		mapping := &yaml.Node{Kind: yaml.MappingNode}
+		for i, val := range row {
+			// JSON is YAML, decoding the JSON of a value into a node keeps its type and the order of nested keys
+			enco, err := jsonValue(val)
+			if err != nil {
+				return "", err
+			}
+			var doc yaml.Node
+			if err := yaml.Unmarshal(enco, &doc); err != nil {
+				return "", fmt.Errorf("failed to convert %s to YAML: %w", enco, err)
+			}
+			value := doc.Content[0]
+			blockStyle(value)
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: result.Columns[i].Name}, value)
+		}
+		seq.Content = append(seq.Content, mapping)
+	}
+
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(seq); err != nil {
+		return "", fmt.Errorf("yaml write error: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("yaml write error: %w", err)
+	}
+	return b.String(), nil
+}
+
+// blockStyle drops the flow style and quotes of decoded JSON, the encoder quotes the strings that need it
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+// ResultToXML converts a result set into an XML document with a row element per row and a column element per value
+// in column order, the name attribute holds the column name and null values are marked with null="true"
+func ResultToXML(result *types.ResultSet) (string, error) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString("<rows>")
+	for _, row := range result.Rows {
+		b.WriteString("<row>")
+		for i, val := range row {
+			b.WriteString(`<column name="`)
+			if err := xml.EscapeText(&b, []byte(result.Columns[i].Name)); err != nil {
+				return "", err
+			}
+			if val == nil {
+				b.WriteString(`" null="true"/>`)
+				continue
+			}
+			b.WriteString(`">`)
+			if err := xml.EscapeText(&b, []byte(formatValue(val))); err != nil {
+				return "", err
+			}
+			b.WriteString("</column>")
+		}
+		b.WriteString("</row>")
+	}
+	b.WriteString("</rows>\n")
+	return b.String(), nil
+}
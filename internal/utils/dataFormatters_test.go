@@ -1,9 +1,13 @@
 package utils_test
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"testing"
+	"time"
 
 	"exmple.com/database-query-server/internal/utils"
+	"exmple.com/database-query-server/pkg/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -103,3 +107,96 @@ func TestDataToHTMLTable(t *testing.T) {
 		})
 	}
 }
+
+func TestDataToMarkdownTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		rows     []map[string]interface{}
+		maxWidth int
+		want     string
+		wantErr  bool
+	}{
+		{name: "Happy Flow - data to Markdown table", rows: testData(), want: "| bool_data | character_maximum_length | column_name | data_type | float |\n| --- | --- | --- | --- | --- |\n| true | 200 | customername | character varying | 11.22 |\n", wantErr: false},
+		{name: "Happy Flow - pipes and newlines are escaped", rows: []map[string]interface{}{{"a|b": "x|y", "note": "one\r\ntwo\nthree"}}, want: "| a\\|b | note |\n| --- | --- |\n| x\\|y | one<br>two<br>three |\n", wantErr: false},
+		{name: "Happy Flow - long cells are cut with an ellipsis", rows: []map[string]interface{}{{"description": "größer als zehn", "id": 7}}, maxWidth: 6, want: "| description | id |\n| --- | --- |\n| größe… | 7 |\n", wantErr: false},
+		{name: "Happy Flow - cells up to the width are kept", rows: []map[string]interface{}{{"id": "123456"}}, maxWidth: 6, want: "| id |\n| --- |\n| 123456 |\n", wantErr: false},
+		{name: "Sad Flow - no data", rows: []map[string]interface{}{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := utils.DataToMarkdownTable(tt.rows, tt.maxWidth)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("DataToMarkdownTable() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("DataToMarkdownTable() succeeded unexpectedly")
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResultFormatters(t *testing.T) {
+	// the columns aren't in alphabetical order and a name repeats, as in SELECT a.id, b.id
+	result := &types.ResultSet{
+		Columns: []types.Column{{Name: "name", Type: "TEXT"}, {Name: "id", Type: "INT4"}, {Name: "id", Type: "INT4"}},
+		Rows:    [][]any{{"<Bob>", int64(1), nil}},
+	}
+	empty := &types.ResultSet{Columns: []types.Column{{Name: "id", Type: "INT4"}}, Rows: [][]any{}}
+	// decoded Postgres values are written the same way by every format
+	typed := &types.ResultSet{
+		Columns: []types.Column{{Name: "price"}, {Name: "attrs"}, {Name: "tags"}, {Name: "photo"}, {Name: "raw"}, {Name: "at"}},
+		Rows: [][]any{{
+			json.Number("10.50"), json.RawMessage(`{"a":1}`), []any{int64(1), nil}, types.Binary{0xde, 0xad}, []byte{1},
+			time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		}},
+	}
+
+	// strings YAML would read as a bool, a number or a mapping
+	quoted := &types.ResultSet{
+		Columns: []types.Column{{Name: "flag"}, {Name: "code"}, {Name: "note"}, {Name: "lines"}},
+		Rows:    [][]any{{"true", "007", "a: b", "one\ntwo"}},
+	}
+	markdown := func(result *types.ResultSet) (string, error) { return utils.ResultToMarkdownTable(result, 0) }
+
+	tests := []struct {
+		name   string
+		format func(*types.ResultSet) (string, error)
+		result *types.ResultSet
+		want   string
+	}{
+		{name: "Happy Flow - JSON keeps the column order", format: utils.ResultToJson, result: result, want: `[{"name":"\u003cBob\u003e","id":1,"id":null}]`},
+		{name: "Happy Flow - JSON without rows", format: utils.ResultToJson, result: empty, want: `[]`},
+		{name: "Happy Flow - CSV keeps the column order", format: utils.ResultToCSV, result: result, want: "name,id,id\n<Bob>,1,\n"},
+		{name: "Happy Flow - CSV without rows has a header", format: utils.ResultToCSV, result: empty, want: "id\n"},
+		{name: "Happy Flow - JSON embeds typed values", format: utils.ResultToJson, result: typed, want: `[{"price":10.50,"attrs":{"a":1},"tags":[1,null],"photo":"\\xdead","raw":"\\x01","at":"2024-01-02T03:04:05Z"}]`},
+		{name: "Happy Flow - CSV writes typed values as in JSON", format: utils.ResultToCSV, result: typed, want: "price,attrs,tags,photo,raw,at\n10.50,\"{\"\"a\"\":1}\",\"[1,null]\",\\xdead,\\x01,2024-01-02T03:04:05Z\n"},
+		{name: "Happy Flow - HTML table writes typed values as in JSON", format: utils.ResultToHTMLTable, result: typed, want: "<table><thead><tr><th>price</th><th>attrs</th><th>tags</th><th>photo</th><th>raw</th><th>at</th></tr></thead><tbody><tr><td>10.50</td><td>{&#34;a&#34;:1}</td><td>[1,null]</td><td>\\xdead</td><td>\\x01</td><td>2024-01-02T03:04:05Z</td></tr></tbody></table>"},
+		{name: "Happy Flow - HTML table keeps the column order", format: utils.ResultToHTMLTable, result: result, want: "<table><thead><tr><th>name</th><th>id</th><th>id</th></tr></thead><tbody><tr><td>&lt;Bob&gt;</td><td>1</td><td></td></tr></tbody></table>"},
+		{name: "Happy Flow - NDJSON keeps the column order", format: utils.ResultToNDJson, result: result, want: "{\"name\":\"\\u003cBob\\u003e\",\"id\":1,\"id\":null}\n"},
+		{name: "Happy Flow - NDJSON without rows is empty", format: utils.ResultToNDJson, result: empty, want: ""},
+		{name: "Happy Flow - NDJSON embeds typed values", format: utils.ResultToNDJson, result: typed, want: `{"price":10.50,"attrs":{"a":1},"tags":[1,null],"photo":"\\xdead","raw":"\\x01","at":"2024-01-02T03:04:05Z"}` + "\n"},
+		{name: "Happy Flow - YAML keeps the column order", format: utils.ResultToYAML, result: result, want: "- name: <Bob>\n  id: 1\n  id: null\n"},
+		{name: "Happy Flow - YAML without rows", format: utils.ResultToYAML, result: empty, want: "[]\n"},
+		{name: "Happy Flow - YAML nests typed values", format: utils.ResultToYAML, result: typed, want: "- price: 10.50\n  attrs:\n    a: 1\n  tags:\n    - 1\n    - null\n  photo: \\xdead\n  raw: \\x01\n  at: \"2024-01-02T03:04:05Z\"\n"},
+		{name: "Happy Flow - YAML quotes strings that read as other types", format: utils.ResultToYAML, result: quoted, want: "- flag: \"true\"\n  code: \"007\"\n  note: 'a: b'\n  lines: |-\n    one\n    two\n"},
+		{name: "Happy Flow - XML keeps the column order", format: utils.ResultToXML, result: result, want: xml.Header + `<rows><row><column name="name">&lt;Bob&gt;</column><column name="id">1</column><column name="id" null="true"/></row></rows>` + "\n"},
+		{name: "Happy Flow - XML without rows", format: utils.ResultToXML, result: empty, want: xml.Header + "<rows></rows>\n"},
+		{name: "Happy Flow - XML writes typed values as in JSON", format: utils.ResultToXML, result: typed, want: xml.Header + `<rows><row><column name="price">10.50</column><column name="attrs">{&#34;a&#34;:1}</column><column name="tags">[1,null]</column><column name="photo">\xdead</column><column name="raw">\x01</column><column name="at">2024-01-02T03:04:05Z</column></row></rows>` + "\n"},
+		{name: "Happy Flow - Markdown table keeps the column order", format: markdown, result: result, want: "| name | id | id |\n| --- | --- | --- |\n| <Bob> | 1 |  |\n"},
+		{name: "Happy Flow - Markdown table without rows has a header", format: markdown, result: empty, want: "| id |\n| --- |\n"},
+		{name: "Happy Flow - Markdown table writes typed values as in JSON", format: markdown, result: typed, want: "| price | attrs | tags | photo | raw | at |\n| --- | --- | --- | --- | --- | --- |\n| 10.50 | {\"a\":1} | [1,null] | \\xdead | \\x01 | 2024-01-02T03:04:05Z |\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := tt.format(tt.result)
+			if gotErr != nil {
+				t.Fatalf("format failed: %v", gotErr)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
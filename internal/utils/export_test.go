@@ -0,0 +1,4 @@
+package utils
+
+// UnregisterFormatter removes a format registered by a test
+var UnregisterFormatter = unregisterFormatter
@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"exmple.com/database-query-server/pkg/types"
+)
+
+// MarkdownCellWidth caps the characters of a markdown cell so long text and JSON values don't stretch the table,
+// the json and csv formats keep them whole
+const MarkdownCellWidth = 120
+
+// Formatter renders a result set in a format a tool call can ask for
+type Formatter interface {
+	// Name is the format of a request ie. csv
+	Name() string
+	// Ext is the extension of a query-result resource in the format
+	Ext() string
+	MimeType() string
+	// Binary formats are sent as embedded resources, text formats as the response text
+	Binary() bool
+	Format(result *types.ResultSet) ([]byte, error)
+}
+
+// formatter is a Formatter built from a render function
+type formatter struct {
+	name     string
+	ext      string
+	mimeType string
+	binary   bool
+	render   func(*types.ResultSet) ([]byte, error)
+}
+
+func (f *formatter) Name() string     { return f.name }
+func (f *formatter) Ext() string      { return f.ext }
+func (f *formatter) MimeType() string { return f.mimeType }
+func (f *formatter) Binary() bool     { return f.binary }
+
+func (f *formatter) Format(result *types.ResultSet) ([]byte, error) {
+	return f.render(result)
+}
+
+// NewTextFormatter returns a Formatter of a text format
+func NewTextFormatter(name, ext, mimeType string, render func(*types.ResultSet) (string, error)) Formatter {
+	return &formatter{name: name, ext: ext, mimeType: mimeType, render: func(result *types.ResultSet) ([]byte, error) {
+		text, err := render(result)
+		return []byte(text), err
+	}}
+}
+
+// NewBinaryFormatter returns a Formatter of a binary format
+func NewBinaryFormatter(name, ext, mimeType string, render func(*types.ResultSet) ([]byte, error)) Formatter {
+	return &formatter{name: name, ext: ext, mimeType: mimeType, binary: true, render: render}
+}
+
+var registry = struct {
+	sync.RWMutex
+	formatters []Formatter // in registration order
+}{}
+
+// RegisterFormatter adds a format, its name and extension must not be taken by another format.
+// Formats are registered before the server is built, the tool schemas list the formats registered by then.
+func RegisterFormatter(f Formatter) error {
+	if f.Name() == "" || f.Ext() == "" {
+		return fmt.Errorf("formatter needs a name and an extension")
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	for _, registered := range registry.formatters {
+		if registered.Name() == f.Name() {
+			return fmt.Errorf("format %q is already registered", f.Name())
+		}
+		if registered.Ext() == f.Ext() {
+			return fmt.Errorf("extension %q is already used by format %q", f.Ext(), registered.Name())
+		}
+	}
+	registry.formatters = append(registry.formatters, f)
+	return nil
+}
+
+// unregisterFormatter removes a format, tests use it to undo RegisterFormatter
+func unregisterFormatter(name string) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.formatters = slices.DeleteFunc(registry.formatters, func(f Formatter) bool { return f.Name() == name })
+}
+
+// LookupFormatter returns the formatter of a format name
+func LookupFormatter(name string) (Formatter, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, f := range registry.formatters {
+		if f.Name() == name {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// LookupFormatterByExt returns the formatter of a query-result resource extension
+func LookupFormatterByExt(ext string) (Formatter, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, f := range registry.formatters {
+		if f.Ext() == ext {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// Formatters returns the registered formatters in registration order
+func Formatters() []Formatter {
+	registry.RLock()
+	defer registry.RUnlock()
+	return append([]Formatter(nil), registry.formatters...)
+}
+
+// FormatNames returns the names of the registered formats in registration order
+func FormatNames() []string {
+	formatters := Formatters()
+	names := make([]string, len(formatters))
+	for i, f := range formatters {
+		names[i] = f.Name()
+	}
+	return names
+}
+
+// FormatExts returns the extensions of the registered formats in registration order
+func FormatExts() []string {
+	formatters := Formatters()
+	exts := make([]string, len(formatters))
+	for i, f := range formatters {
+		exts[i] = f.Ext()
+	}
+	return exts
+}
+
+func init() {
+	builtin := []Formatter{
+		NewTextFormatter("json", "json", "application/json", ResultToJson),
+		NewTextFormatter("csv", "csv", "text/csv", ResultToCSV),
+		NewTextFormatter("table", "html", "text/html", ResultToHTMLTable),
+		NewTextFormatter("markdown", "md", "text/markdown", func(result *types.ResultSet) (string, error) {
+			return ResultToMarkdownTable(result, MarkdownCellWidth)
+		}),
+		NewTextFormatter("ndjson", "ndjson", "application/x-ndjson", ResultToNDJson),
+		NewTextFormatter("yaml", "yaml", "application/yaml", ResultToYAML),
+		NewTextFormatter("xml", "xml", "application/xml", ResultToXML),
+		NewBinaryFormatter("xlsx", "xlsx", XLSXMimeType, ResultToXLSX),
+		NewBinaryFormatter("arrow", "arrow", ArrowMimeType, ResultToArrow),
+		NewBinaryFormatter("parquet", "parquet", ParquetMimeType, ResultToParquet),
+	}
+	for _, f := range builtin {
+		if err := RegisterFormatter(f); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"exmple.com/database-query-server/internal/utils"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterFormatter(t *testing.T) {
+	names := func(result *types.ResultSet) (string, error) {
+		cols := make([]string, len(result.Columns))
+		for i, c := range result.Columns {
+			cols[i] = c.Name
+		}
+		return strings.Join(cols, " "), nil
+	}
+
+	tests := []struct {
+		name      string
+		formatter utils.Formatter
+		wantErr   bool
+	}{
+		{name: "Happy Flow - custom format", formatter: utils.NewTextFormatter("column-names", "names.txt", "text/plain", names), wantErr: false},
+		{name: "Sad Flow - format name is taken", formatter: utils.NewTextFormatter("csv", "csv2", "text/csv", names), wantErr: true},
+		{name: "Sad Flow - extension is taken", formatter: utils.NewTextFormatter("html", "html", "text/html", names), wantErr: true},
+		{name: "Sad Flow - no name", formatter: utils.NewTextFormatter("", "txt", "text/plain", names), wantErr: true},
+	}
+	// the registry is global, the custom format is removed once the test is done
+	t.Cleanup(func() { utils.UnregisterFormatter("column-names") })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotErr := utils.RegisterFormatter(tt.formatter)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("RegisterFormatter() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("RegisterFormatter() succeeded unexpectedly")
+			}
+			got, ok := utils.LookupFormatter(tt.formatter.Name())
+			assert.True(t, ok)
+			assert.Same(t, tt.formatter, got)
+			got, ok = utils.LookupFormatterByExt(tt.formatter.Ext())
+			assert.True(t, ok)
+			assert.Same(t, tt.formatter, got)
+			assert.Contains(t, utils.FormatNames(), tt.formatter.Name())
+		})
+	}
+
+	t.Run("Happy Flow - custom format renders a result", func(t *testing.T) {
+		f, ok := utils.LookupFormatter("column-names")
+		if !ok {
+			t.Fatal("column-names format isn't registered")
+		}
+		got, err := f.Format(&types.ResultSet{Columns: []types.Column{{Name: "id"}, {Name: "name"}}})
+		if err != nil {
+			t.Fatalf("Format() failed: %v", err)
+		}
+		assert.Equal(t, "id name", string(got))
+		assert.False(t, f.Binary())
+	})
+}
+
+func TestFormatters_Builtin(t *testing.T) {
+	assert.Equal(t, []string{"json", "csv", "table", "markdown", "ndjson", "yaml", "xml", "xlsx", "arrow", "parquet"}, utils.FormatNames())
+	assert.Equal(t, []string{"json", "csv", "html", "md", "ndjson", "yaml", "xml", "xlsx", "arrow", "parquet"}, utils.FormatExts())
+
+	tests := []struct {
+		name       string
+		format     string
+		wantMime   string
+		wantBinary bool
+	}{
+		{name: "Happy Flow - table is HTML", format: "table", wantMime: "text/html"},
+		{name: "Happy Flow - markdown", format: "markdown", wantMime: "text/markdown"},
+		{name: "Happy Flow - xlsx is binary", format: "xlsx", wantMime: utils.XLSXMimeType, wantBinary: true},
+		{name: "Happy Flow - parquet is binary", format: "parquet", wantMime: utils.ParquetMimeType, wantBinary: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := utils.LookupFormatter(tt.format)
+			if !ok {
+				t.Fatalf("format %s isn't registered", tt.format)
+			}
+			assert.Equal(t, tt.wantMime, f.MimeType())
+			assert.Equal(t, tt.wantBinary, f.Binary())
+		})
+	}
+}
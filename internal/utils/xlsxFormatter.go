@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	// XLSXMimeType is the media type of an Excel workbook
+	XLSXMimeType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	xlsxSheet    = "Result"
+	// xlsxNumberDigits are the significant digits an Excel number keeps, exact numbers with more are written as text
+	xlsxNumberDigits = 15
+)
+
+// ResultToXLSX converts a result set into an Excel workbook with one sheet. The header row holds the column names in
+// select order, is bold and stays frozen while scrolling. Numbers, dates and booleans are typed cells, other values
+// are written as text the way they appear in CSV output.
+func ResultToXLSX(result *types.ResultSet) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName(f.GetSheetName(0), xlsxSheet); err != nil {
+		return nil, err
+	}
+
+	header, err := f.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true},
+		Fill:   excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9E1F2"}},
+		Border: []excelize.Border{{Type: "bottom", Color: "8EA9DB", Style: 1}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create header style: %w", err)
+	}
+
+	sw, err := f.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		return nil, err
+	}
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return nil, fmt.Errorf("failed to freeze header: %w", err)
+	}
+
+	// header
+	cells := make([]any, len(result.Columns))
+	for i, c := range result.Columns {
+		cells[i] = excelize.Cell{StyleID: header, Value: c.Name}
+	}
+	if err := sw.SetRow("A1", cells); err != nil {
+		return nil, fmt.Errorf("failed to write headers: %w", err)
+	}
+
+	// rows
+	for r, row := range result.Rows {
+		for i, val := range row {
+			cells[i] = xlsxValue(val)
+		}
+		cell, err := excelize.CoordinatesToCellName(1, r+2)
+		if err != nil {
+			return nil, err
+		}
+		if err := sw.SetRow(cell, cells[:len(row)]); err != nil {
+			return nil, fmt.Errorf("failed to write record: %w", err)
+		}
+	}
+
+	if err := sw.Flush(); err != nil {
+		return nil, fmt.Errorf("xlsx write error: %w", err)
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("xlsx write error: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// xlsxValue converts a cell value into the type of its Excel cell, nil is an empty cell
+func xlsxValue(val any) any {
+	switch v := val.(type) {
+	case nil, bool, string, time.Time,
+		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return v
+	case float64:
+		// Excel has no NaN and infinity
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return formatValue(v)
+		}
+		return v
+	case float32:
+		return xlsxValue(float64(v))
+	case json.Number:
+		if f, ok := xlsxNumber(v); ok {
+			return f
+		}
+	}
+	return formatValue(val)
+}
+
+// xlsxNumber returns an exact number as a float when Excel keeps all of its digits
+func xlsxNumber(n json.Number) (float64, bool) {
+	exact, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(n.String(), 64)
+	if err != nil {
+		return 0, false
+	}
+	// the number rounded to Excel's digits must still be the same number
+	rounded, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', xlsxNumberDigits, 64))
+	if !ok || rounded.Cmp(exact) != 0 {
+		return 0, false
+	}
+	return f, true
+}
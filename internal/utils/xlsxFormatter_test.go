@@ -0,0 +1,91 @@
+package utils_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"exmple.com/database-query-server/internal/utils"
+	"exmple.com/database-query-server/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestResultToXLSX(t *testing.T) {
+	result := &types.ResultSet{
+		Columns: []types.Column{
+			{Name: "name"}, {Name: "id"}, {Name: "price"}, {Name: "total"}, {Name: "active"}, {Name: "at"},
+			{Name: "attrs"}, {Name: "photo"}, {Name: "note"}, {Name: "ratio"},
+		},
+		Rows: [][]any{
+			{"Bob", int64(1), json.Number("10.50"), json.Number("12345678901234567890.5"), true, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				json.RawMessage(`{"a":1}`), types.Binary{0xde, 0xad}, nil, math.Inf(1)},
+		},
+	}
+
+	enco, err := utils.ResultToXLSX(result)
+	if err != nil {
+		t.Fatalf("ResultToXLSX() failed: %v", err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(enco))
+	if err != nil {
+		t.Fatalf("failed to open workbook: %v", err)
+	}
+	defer f.Close()
+	assert.Equal(t, []string{"Result"}, f.GetSheetList())
+
+	tests := []struct {
+		name     string
+		cell     string
+		want     string
+		wantType excelize.CellType
+	}{
+		{name: "Happy Flow - header keeps the column order", cell: "B1", want: "id", wantType: excelize.CellTypeInlineString},
+		{name: "Happy Flow - text", cell: "A2", want: "Bob", wantType: excelize.CellTypeInlineString},
+		{name: "Happy Flow - integer is a number", cell: "B2", want: "1", wantType: excelize.CellTypeUnset},
+		{name: "Happy Flow - exact number Excel can hold is a number", cell: "C2", want: "10.5", wantType: excelize.CellTypeUnset},
+		{name: "Happy Flow - exact number with more digits than Excel keeps is text", cell: "D2", want: "12345678901234567890.5", wantType: excelize.CellTypeInlineString},
+		{name: "Happy Flow - boolean", cell: "E2", want: "TRUE", wantType: excelize.CellTypeBool},
+		{name: "Happy Flow - timestamp is a date", cell: "F2", want: "1/2/24 03:04", wantType: excelize.CellTypeUnset},
+		{name: "Happy Flow - embedded JSON is text", cell: "G2", want: `{"a":1}`, wantType: excelize.CellTypeInlineString},
+		{name: "Happy Flow - binary is text", cell: "H2", want: `\xdead`, wantType: excelize.CellTypeInlineString},
+		{name: "Happy Flow - null is an empty cell", cell: "I2", want: "", wantType: excelize.CellTypeUnset},
+		{name: "Happy Flow - infinity is text", cell: "J2", want: "+Inf", wantType: excelize.CellTypeInlineString},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.GetCellValue("Result", tt.cell)
+			if err != nil {
+				t.Fatalf("GetCellValue() failed: %v", err)
+			}
+			assert.Equal(t, tt.want, got)
+			gotType, err := f.GetCellType("Result", tt.cell)
+			if err != nil {
+				t.Fatalf("GetCellType() failed: %v", err)
+			}
+			assert.Equal(t, tt.wantType, gotType)
+		})
+	}
+
+	t.Run("Happy Flow - header is bold and frozen", func(t *testing.T) {
+		panes, err := f.GetPanes("Result")
+		if err != nil {
+			t.Fatalf("GetPanes() failed: %v", err)
+		}
+		assert.True(t, panes.Freeze)
+		assert.Equal(t, 1, panes.YSplit)
+		assert.Equal(t, "A2", panes.TopLeftCell)
+
+		styleID, err := f.GetCellStyle("Result", "A1")
+		if err != nil {
+			t.Fatalf("GetCellStyle() failed: %v", err)
+		}
+		style, err := f.GetStyle(styleID)
+		if err != nil {
+			t.Fatalf("GetStyle() failed: %v", err)
+		}
+		assert.True(t, style.Font.Bold)
+	})
+}
@@ -0,0 +1,75 @@
+package types
+
+// ListDatabasesRequest lists the databases configured on the server, it has no arguments
+type ListDatabasesRequest struct{}
+
+type ListDatabasesResponse struct {
+	Databases []DatabaseInfo `json:"databases"` // in alphabetical order
+}
+
+// DatabaseInfo is a logical database of the server, its name is the database argument of the other tools
+type DatabaseInfo struct {
+	Name     string `json:"name"`
+	Driver   string `json:"driver"`
+	Default  bool   `json:"default,omitempty"` // used when a request doesn't name a database
+	ReadOnly bool   `json:"read_only,omitempty"`
+}
+
+type ListSchemasRequest struct {
+	Database string `json:"database"`
+	Pattern  string `json:"pattern,omitempty"` // glob pattern of the schema name ie. sales_*
+	Limit    int    `json:"limit,omitempty"`   // schemas returned, 100 by default and at most 1000
+	Offset   int    `json:"offset,omitempty"`  // schemas skipped, the next_offset of the previous page
+}
+
+type ListSchemasResponse struct {
+	Database   string       `json:"database"`
+	Schemas    []SchemaInfo `json:"schemas"`
+	NextOffset int          `json:"next_offset,omitempty"` // offset of the next page, set when there are more schemas
+}
+
+// SchemaInfo is a Postgres schema, a MySQL database or an attached SQLite database
+type SchemaInfo struct {
+	Name    string `json:"name"`
+	Owner   string `json:"owner,omitempty"`
+	Tables  int    `json:"tables"` // tables and views
+	Comment string `json:"comment,omitempty"`
+}
+
+type ListTablesRequest struct {
+	Database string `json:"database"`
+	Schema   string `json:"schema,omitempty"`  // the tables an unqualified name can refer to when empty
+	Pattern  string `json:"pattern,omitempty"` // glob pattern of the table name ie. order_*
+	Type     string `json:"type,omitempty"`    // table, view, materialized view or foreign table
+	Limit    int    `json:"limit,omitempty"`   // tables returned, 100 by default and at most 1000
+	Offset   int    `json:"offset,omitempty"`  // tables skipped, the next_offset of the previous page
+}
+
+type ListTablesResponse struct {
+	Database   string      `json:"database"`
+	Tables     []TableInfo `json:"tables"`
+	NextOffset int         `json:"next_offset,omitempty"` // offset of the next page, set when there are more tables
+}
+
+// TableInfo is a table or view with the size statistics of the database, they are estimates and absent for views
+type TableInfo struct {
+	Schema        string `json:"schema"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`                     // table, view, materialized view or foreign table
+	EstimatedRows *int64 `json:"estimated_rows,omitempty"` // from the planner statistics, absent when the table was never analyzed
+	SizeBytes     *int64 `json:"size_bytes,omitempty"`     // data and indexes
+	Comment       string `json:"comment,omitempty"`
+}
+
+// SchemaIndex is the schema://index resource, the tables and views of every configured database
+type SchemaIndex struct {
+	Tables []SchemaIndexEntry `json:"tables"`
+	Errors map[string]string  `json:"errors,omitempty"` // databases that couldn't be listed by name
+}
+
+// SchemaIndexEntry is a table of the index with the URI of its schema://{database}/{schema}/{table} resource
+type SchemaIndexEntry struct {
+	URI      string `json:"uri"`
+	Database string `json:"database"`
+	TableInfo
+}
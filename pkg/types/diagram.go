@@ -0,0 +1,18 @@
+package types
+
+type ERDiagramRequest struct {
+	Database string `json:"database"`
+	Schema   string `json:"schema,omitempty"`  // the tables an unqualified name can refer to when empty
+	Table    string `json:"table,omitempty"`   // name or schema.name of the table the diagram is centred on, every table of the schema when empty
+	Hops     int    `json:"hops,omitempty"`    // foreign keys followed from table in both directions, 1 by default
+	Columns  bool   `json:"columns,omitempty"` // list the columns of each table with their keys
+	Format   string `json:"format,omitempty"`  // mermaid (default) or dot
+}
+
+type ERDiagramResponse struct {
+	Database      string   `json:"database"`
+	Format        string   `json:"format"`
+	Diagram       string   `json:"diagram"`
+	Tables        []string `json:"tables"`        // schema.name of the entities of the diagram
+	Relationships int      `json:"relationships"` // foreign keys drawn
+}
@@ -2,16 +2,26 @@ package types
 
 type SchemaRequest struct {
 	Database string   `json:"database"`
-	Tables   []string `json:"tables,omitempty"`
+	Tables   []string `json:"tables,omitempty"` // names, schema.table names or glob patterns of the table name ie. sales.order_*
+	Schema   string   `json:"schema,omitempty"` // schema of unqualified names, every table of it is described when tables is empty
 	Detailed bool     `json:"detailed,omitempty"`
 }
 type QueryRequest struct {
 	Database   string         `json:"database"`
 	Query      string         `json:"query"`
-	Parameters map[string]any `json:"parameters,omitempty"`
-	Format     string         `json:"format,omitempty"` // json, csv, table
-	Limit      int            `json:"limit,omitempty"`
-	Timeout    int            `json:"timeout,omitempty"`
+	Parameters map[string]any `json:"parameters,omitempty"` // numbered "1".."n" for $1 or ?, or names for :name placeholders
+	Format     string         `json:"format,omitempty"`     // one of the registered formats, rows are returned without a rendering when empty
+	Limit      int            `json:"limit,omitempty"`      // rows returned, capped by the database's policy.max_rows
+	Timeout    int            `json:"timeout,omitempty"`    // seconds, capped by the server's max_query_timeout
+	Paginate   bool           `json:"paginate,omitempty"`   // return a cursor for fetch_more when there are more rows than the limit
+}
+
+// FetchMoreRequest continues the result of an execute_query call that returned a cursor
+type FetchMoreRequest struct {
+	Cursor  string `json:"cursor"`
+	Limit   int    `json:"limit,omitempty"`   // rows returned, capped by the database's policy.max_rows
+	Format  string `json:"format,omitempty"`  // one of the registered formats, see utils.FormatNames
+	Timeout int    `json:"timeout,omitempty"` // seconds, capped by the server's max_query_timeout
 }
 
 type PreparedRequest struct {
@@ -26,14 +36,43 @@ type ConnectionStatus struct {
 }
 
 type ConnectionStatusResp struct {
-	Database  string `json:"database"`
-	Connected bool   `json:"connected,omitempty"`
-	PoolStats int    `json:"pool_stats,omitempty"`
-	LastPing  string `json:"last_ping,omitempty"`
+	Database      string     `json:"database"`
+	Connected     bool       `json:"connected"`
+	Reason        string     `json:"reason,omitempty"` // why the database is unreachable
+	ServerVersion string     `json:"server_version,omitempty"`
+	PingLatency   string     `json:"ping_latency,omitempty"`
+	LastPing      string     `json:"last_ping,omitempty"`
+	Pool          *PoolStats `json:"pool,omitempty"`
+}
+
+// PoolStats are the database/sql statistics of the server's connection pool
+type PoolStats struct {
+	MaxOpenConnections int    `json:"max_open_connections"` // 0 is unlimited
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`    // connections waited for
+	WaitDuration       string `json:"wait_duration"` // total time blocked waiting for a connection
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
 }
 
+// QueryResponse is the result of a tool call, Response renders the rows in Format when the request asks for one
 type QueryResponse struct {
-	Query    string `json:"query"`
-	Response string `json:"response"`
-	Format   string `json:"format,omitempty"` // json, csv, table
+	Database  string        `json:"database"`
+	Query     string        `json:"query"`
+	Columns   []Column      `json:"columns"`             // in select order
+	Rows      [][]any       `json:"rows"`                // values in column order
+	RowCount  int           `json:"row_count"`           // rows of the result, more than the rows of a preview
+	Elapsed   string        `json:"elapsed"`             // time spent in the database
+	Truncated bool          `json:"truncated,omitempty"` // the result has more rows than the limit
+	Cursor    string        `json:"cursor,omitempty"`    // pass to fetch_more for the next rows, set when paginate was asked for
+	ResultID  string        `json:"result_id,omitempty"` // the rows are a preview, the whole result is the query-result://<result_id>.<ext> resource
+	Notice    string        `json:"notice,omitempty"`    // set when the rows are a preview of a result that couldn't be stored
+	Format    string        `json:"format,omitempty"`    // the format of Response or File
+	Response  string        `json:"response,omitempty"`
+	File      []byte        `json:"-"`                        // the rows in a binary format, sent as an embedded resource instead of Response
+	Tables    []TableSchema `json:"tables,omitempty"`         // the tables described by get_schema, grouping Rows by table
+	Missing   []string      `json:"missing_tables,omitempty"` // get_schema tables and patterns that matched no table
 }
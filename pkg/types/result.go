@@ -0,0 +1,71 @@
+package types
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// Column is a result column, Type is the database type name reported by the driver ie. INT4, VARCHAR, DECIMAL
+type Column struct {
+	Name      string `json:"name"`
+	Type      string `json:"type,omitempty"`
+	Precision int    `json:"precision,omitempty"` // digits of a decimal column, when the driver reports them
+	Scale     int    `json:"scale,omitempty"`     // fractional digits of a decimal column
+}
+
+// ResultSet holds the columns of a result in select order and its rows as arrays of values in column order
+type ResultSet struct {
+	Columns []Column `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+}
+
+// Binary is the value of a binary column ie. bytea or BLOB. It's rendered as hex with the \x prefix
+// Postgres uses for bytea, so clients can tell it apart from text.
+type Binary []byte
+
+func (b Binary) String() string {
+	return `\x` + hex.EncodeToString(b)
+}
+
+func (b Binary) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+// ResultFromMaps builds a ResultSet from rows keyed by column name, the columns are the union of the keys sorted by name
+func ResultFromMaps(data []map[string]interface{}) *ResultSet {
+	names := make(map[string]struct{})
+	for _, row := range data {
+		for k := range row {
+			names[k] = struct{}{}
+		}
+	}
+	columns := make([]Column, 0, len(names))
+	for name := range names {
+		columns = append(columns, Column{Name: name})
+	}
+	sort.Slice(columns, func(i, j int) bool { return columns[i].Name < columns[j].Name })
+
+	rows := make([][]any, 0, len(data))
+	for _, row := range data {
+		values := make([]any, len(columns))
+		for i, c := range columns {
+			values[i] = row[c.Name]
+		}
+		rows = append(rows, values)
+	}
+	return &ResultSet{Columns: columns, Rows: rows}
+}
+
+// Maps returns the rows keyed by column name, when names repeat the last column wins
+func (r *ResultSet) Maps() []map[string]interface{} {
+	var maps []map[string]interface{}
+	for _, row := range r.Rows {
+		m := make(map[string]interface{}, len(r.Columns))
+		for i, c := range r.Columns {
+			m[c.Name] = row[i]
+		}
+		maps = append(maps, m)
+	}
+	return maps
+}
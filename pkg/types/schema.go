@@ -0,0 +1,68 @@
+package types
+
+// TableName is a table or view qualified by its schema, the database of MySQL and the attached database of SQLite
+type TableName struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+}
+
+// String returns the qualified name ie. sales.orders
+func (t TableName) String() string {
+	return t.Schema + "." + t.Name
+}
+
+// TableSchema is the schema of a table or view returned by get_schema, only the columns are set unless it's detailed
+type TableSchema struct {
+	Schema      string             `json:"schema"`
+	Name        string             `json:"name"`
+	Comment     string             `json:"comment,omitempty"`
+	Columns     []ColumnSchema     `json:"columns"`                // in ordinal position order
+	PrimaryKey  []string           `json:"primary_key,omitempty"`  // column names in key order
+	ForeignKeys []ForeignKey       `json:"foreign_keys,omitempty"` // references to other tables
+	Uniques     []UniqueConstraint `json:"uniques,omitempty"`
+	Checks      []CheckConstraint  `json:"checks,omitempty"`
+	Indexes     []Index            `json:"indexes,omitempty"` // including the indexes backing the primary key and uniques
+}
+
+// ColumnSchema is a column of a TableSchema
+type ColumnSchema struct {
+	Name      string  `json:"name"`
+	DataType  string  `json:"data_type"`         // the declared type with its modifiers ie. character varying(255)
+	Nullable  bool    `json:"nullable"`          // false for NOT NULL columns
+	Default   *string `json:"default,omitempty"` // the default expression, nil when the column has none
+	Position  int     `json:"position"`          // ordinal position, starting at 1
+	Comment   string  `json:"comment,omitempty"`
+	MaxLength *int64  `json:"character_maximum_length,omitempty"` // length of character types
+}
+
+// ForeignKey references the columns of another table, Columns and RefColumns are in the same order
+type ForeignKey struct {
+	Name       string   `json:"name,omitempty"`
+	Columns    []string `json:"columns"`
+	RefSchema  string   `json:"ref_schema,omitempty"`
+	RefTable   string   `json:"ref_table"`
+	RefColumns []string `json:"ref_columns"`
+	OnUpdate   string   `json:"on_update,omitempty"` // NO ACTION, RESTRICT, CASCADE, SET NULL or SET DEFAULT
+	OnDelete   string   `json:"on_delete,omitempty"`
+}
+
+// UniqueConstraint is a set of columns whose values are unique together
+type UniqueConstraint struct {
+	Name    string   `json:"name,omitempty"`
+	Columns []string `json:"columns"`
+}
+
+// CheckConstraint is a boolean expression every row satisfies
+type CheckConstraint struct {
+	Name       string `json:"name,omitempty"`
+	Definition string `json:"definition"`
+}
+
+// Index is an index of a table, Definition is the statement that creates it when the database reports it
+type Index struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"` // column names or expressions in key order
+	Unique     bool     `json:"unique"`
+	Primary    bool     `json:"primary,omitempty"`
+	Definition string   `json:"definition,omitempty"`
+}